@@ -0,0 +1,126 @@
+package go_logger
+
+import (
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// Adapter is a custom log target. Messages are filtered by level before reaching it.
+type Adapter interface {
+	// Class returns the adapter class name used by SetLevel.
+	Class() string
+
+	// Log receives a formatted message. If raw is true, the message is a JSON object.
+	Log(level LogLevel, now time.Time, msg string, raw bool)
+
+	// Destroy is called when the logger is destroyed.
+	Destroy()
+}
+
+// RichAdapter is a custom log target that also receives the original logged object, so it can forward it to
+// another structured logging system without parsing the formatted message. If an adapter implements this interface,
+// LogObject is called instead of Log.
+type RichAdapter interface {
+	Adapter
+
+	// LogObject receives both the formatted message and the object passed to the logging method.
+	LogObject(level LogLevel, now time.Time, msg string, raw bool, obj interface{})
+}
+
+// SyncAdapter is a custom log target able to durably store the messages it received. Its Sync method is called by
+// Logger.Sync.
+type SyncAdapter interface {
+	Adapter
+
+	// Sync returns once the messages received so far are durably stored.
+	Sync() error
+}
+
+type customAdapter struct {
+	adapter     Adapter
+	richAdapter RichAdapter
+	globals     globalOptions
+}
+
+//------------------------------------------------------------------------------
+
+func createCustomAdapter(adapter Adapter, glbOpts globalOptions) internalLogger {
+	lg := &customAdapter{
+		adapter: adapter,
+		globals: glbOpts,
+	}
+	lg.richAdapter, _ = adapter.(RichAdapter)
+
+	// Done
+	return lg
+}
+
+func (lg *customAdapter) class() string {
+	return lg.adapter.Class()
+}
+
+func (lg *customAdapter) destroy() {
+	lg.adapter.Destroy()
+}
+
+func (lg *customAdapter) setLevel(level LogLevel, debugLevel uint) {
+	lg.globals.Level = level
+	lg.globals.DebugLevel = debugLevel
+}
+
+func (lg *customAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *customAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *customAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+}
+
+func (lg *customAdapter) sync() error {
+	if syncAdapter, ok := lg.adapter.(SyncAdapter); ok {
+		return syncAdapter.Sync()
+	}
+	return nil
+}
+
+func (lg *customAdapter) logError(now time.Time, msg string, raw bool) {
+	lg.logObject(LogLevelError, 0, now, msg, raw, msg)
+}
+
+func (lg *customAdapter) logWarning(now time.Time, msg string, raw bool) {
+	lg.logObject(LogLevelWarning, 0, now, msg, raw, msg)
+}
+
+func (lg *customAdapter) logInfo(now time.Time, msg string, raw bool) {
+	lg.logObject(LogLevelInfo, 0, now, msg, raw, msg)
+}
+
+func (lg *customAdapter) logDebug(level uint, now time.Time, msg string, raw bool) {
+	lg.logObject(LogLevelDebug, level, now, msg, raw, msg)
+}
+
+func (lg *customAdapter) logAudit(now time.Time, msg string, raw bool) {
+	lg.logObject(logLevelAudit, 0, now, msg, raw, msg)
+}
+
+func (lg *customAdapter) logObject(level LogLevel, debugLevel uint, now time.Time, msg string, raw bool,
+	obj interface{},
+) {
+	if level == logLevelAudit {
+		// Audit messages are not filtered and custom adapters receive them as information messages
+		level = LogLevelInfo
+	} else if lg.globals.Level < level || (level == LogLevelDebug && lg.globals.DebugLevel < debugLevel) {
+		return
+	}
+
+	if lg.richAdapter != nil {
+		lg.richAdapter.LogObject(level, now, msg, raw, obj)
+	} else {
+		lg.adapter.Log(level, now, msg, raw)
+	}
+}
@@ -0,0 +1,84 @@
+package go_logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	defaultMaxLatency = 5 * time.Second
+)
+
+// batcher runs the worker of adapters that send queued messages in batches. The adapter keeps its own queue and
+// the batcher calls flush every maxLatency, so messages never wait longer than that, or as soon as the adapter
+// reports maxBatch queued messages.
+type batcher struct {
+	sending      int32
+	maxBatch     int
+	maxLatency   time.Duration
+	flush        func()
+	flushCh      chan struct{}
+	stopCh       chan struct{}
+	workerDoneCh chan struct{}
+}
+
+//------------------------------------------------------------------------------
+
+func newBatcher(maxBatch int, maxLatency time.Duration, flush func()) *batcher {
+	if maxLatency <= 0 {
+		maxLatency = defaultMaxLatency
+	}
+	b := &batcher{
+		maxBatch:     maxBatch,
+		maxLatency:   maxLatency,
+		flush:        flush,
+		flushCh:      make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		workerDoneCh: make(chan struct{}),
+	}
+	go b.worker()
+	return b
+}
+
+// queued must be called after adding messages to the queue with its new length.
+func (b *batcher) queued(count int) {
+	if b.maxBatch > 0 && count >= b.maxBatch {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// busy returns true while the worker is flushing.
+func (b *batcher) busy() bool {
+	return atomic.LoadInt32(&b.sending) != 0
+}
+
+// stop ends the worker and waits until it exits. Queued messages must be flushed by the caller.
+func (b *batcher) stop() {
+	close(b.stopCh)
+	<-b.workerDoneCh
+}
+
+func (b *batcher) worker() {
+	ticker := time.NewTicker(b.maxLatency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			close(b.workerDoneCh)
+			return
+
+		case <-ticker.C:
+		case <-b.flushCh:
+		}
+
+		atomic.StoreInt32(&b.sending, 1)
+		b.flush()
+		atomic.StoreInt32(&b.sending, 0)
+	}
+}
@@ -0,0 +1,40 @@
+package go_logger
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// modulePath is the import path of this package, used to skip its frames when looking for the caller.
+var modulePath = reflect.TypeOf(Logger{}).PkgPath()
+
+//------------------------------------------------------------------------------
+
+// callerFunction returns the fully qualified name, like pkg.(*Type).Method, of the first function in the call stack
+// that does not belong to this module, so wrappers like the compat package or the io.Writer adapter are skipped.
+func callerFunction() string {
+	var pcs [16]uintptr
+
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isModuleFunction(frame.Function) {
+			return frame.Function
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func isModuleFunction(name string) bool {
+	if !strings.HasPrefix(name, modulePath) {
+		return false
+	}
+	rest := name[len(modulePath):]
+	return strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "/")
+}
@@ -0,0 +1,73 @@
+package go_logger
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// StartCapture temporarily writes every message, including all debug levels, to the given file, without changing
+// the targets or their levels. It is meant for troubleshooting: call the returned function to detach and close the
+// file. Captures survive Reconfigure and are closed when the logger is destroyed.
+func (lg *Logger) StartCapture(path string) (func(), error) {
+	if lg.parent != nil {
+		return lg.parent.StartCapture(path)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock access
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	if lg.destroyed {
+		return nil, errors.New("logger already destroyed")
+	}
+
+	adapter, err := createFileAdapter(FileOptions{
+		Prefix:    "capture",
+		Directory: filepath.Dir(path),
+		FixedName: filepath.Base(path),
+	}, globalOptions{
+		Level:           LogLevelDebug,
+		DebugLevel:      ^uint(0),
+		ErrorHandler:    lg.errorHandler,
+		TimestampFormat: lg.textTsFormat,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the list so messages being dispatched keep using the previous one
+	captures := make([]internalLogger, 0, len(lg.captures)+1)
+	lg.captures = append(append(captures, lg.captures...), adapter)
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			lg.stopCapture(adapter)
+		})
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (lg *Logger) stopCapture(adapter internalLogger) {
+	// Lock access
+	lg.mtx.Lock()
+	captures := make([]internalLogger, 0, len(lg.captures))
+	for _, capture := range lg.captures {
+		if capture != adapter {
+			captures = append(captures, capture)
+		}
+	}
+	lg.captures = captures
+	lg.mtx.Unlock()
+
+	adapter.destroy()
+}
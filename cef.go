@@ -0,0 +1,126 @@
+package go_logger
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// CEFOptions specifies the settings to send syslog messages in the ArcSight Common Event Format, like:
+//
+//	CEF:0|Vendor|Product|Version|SignatureID|Name|Severity|rt=1615734566535 key=value...
+//
+// The message field becomes the event name and the rest of the fields of JSON messages become extension pairs.
+// Plain text messages become the event name. The severity is derived from the logging level.
+type CEFOptions struct {
+	// Vendor of the sending device.
+	Vendor string `json:"vendor,omitempty"`
+
+	// Product name of the sending device. Defaults to the syslog application name.
+	Product string `json:"product,omitempty"`
+
+	// Version of the sending device.
+	Version string `json:"version,omitempty"`
+
+	// Event class identifier. The signatureId field of JSON messages takes precedence. Defaults to the level name.
+	SignatureID string `json:"signatureId,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+// formatCEF converts a message into a CEF event.
+func formatCEF(opts *CEFOptions, severity Severity, now time.Time, msg string, raw bool) string {
+	name := strings.TrimSuffix(msg, "\n")
+	signatureID := opts.SignatureID
+	if len(signatureID) == 0 {
+		signatureID = cefSignature(severity)
+	}
+
+	ext := strings.Builder{}
+	ext.WriteString("rt=" + strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10))
+
+	if raw {
+		fields, ok := parseJSONFields(msg)
+		if ok {
+			name = ""
+			for _, f := range fields {
+				switch f.key {
+				case "message":
+					name = f.value
+				case "signatureId":
+					signatureID = f.value
+				case "level", "timestamp":
+					// Already represented by the severity and the rt extension
+				default:
+					key := cefKey(f.key)
+					if len(key) > 0 {
+						ext.WriteString(" " + key + "=" + escapeCEFExtension(f.value))
+					}
+				}
+			}
+		}
+	}
+
+	return "CEF:0|" + escapeCEFHeader(opts.Vendor) + "|" + escapeCEFHeader(opts.Product) + "|" +
+		escapeCEFHeader(opts.Version) + "|" + escapeCEFHeader(signatureID) + "|" + escapeCEFHeader(name) + "|" +
+		strconv.Itoa(cefSeverity(severity)) + "|" + ext.String()
+}
+
+// cefSeverity maps a syslog severity into the 0 (lowest) to 10 (highest) CEF scale.
+func cefSeverity(severity Severity) int {
+	switch severity {
+	case SeverityEmergency, SeverityAlert:
+		return 10
+	case SeverityCritical:
+		return 9
+	case SeverityError:
+		return 7
+	case SeverityWarning:
+		return 5
+	case SeverityNotice:
+		return 4
+	case SeverityInformational:
+		return 3
+	}
+	return 1
+}
+
+func cefSignature(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNotice:
+		return "audit"
+	case SeverityInformational:
+		return "info"
+	case SeverityDebug:
+		return "debug"
+	}
+	return strconv.Itoa(int(severity))
+}
+
+// cefKey removes from a field name the characters not allowed in extension keys.
+func cefKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return -1
+	}, key)
+}
+
+var cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+var cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`)
+
+func escapeCEFHeader(s string) string {
+	return cefHeaderEscaper.Replace(s)
+}
+
+func escapeCEFExtension(s string) string {
+	return cefExtensionEscaper.Replace(s)
+}
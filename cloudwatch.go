@@ -0,0 +1,468 @@
+package go_logger
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	cloudWatchMaxBatchEvents = 10000
+	cloudWatchMaxBatchBytes  = 1048576
+	cloudWatchEventOverhead  = 26
+	cloudWatchMaxEventBytes  = 262144 - cloudWatchEventOverhead
+
+	cloudWatchTarget      = "Logs_20140328.PutLogEvents"
+	cloudWatchContentType = "application/x-amz-json-1.1"
+)
+
+// CloudWatchOptions specifies the Amazon CloudWatch Logs settings to use when it is created.
+type CloudWatchOptions struct {
+	// Name of the log group. It must already exist.
+	LogGroup string `json:"logGroup,omitempty"`
+
+	// Name of the log stream. It must already exist.
+	LogStream string `json:"logStream,omitempty"`
+
+	// AWS region, like us-east-1. Defaults to the AWS_REGION environment variable.
+	Region string `json:"region,omitempty"`
+
+	// Service endpoint. Defaults to https://logs.{region}.amazonaws.com.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Credentials used to sign the requests. If no access key is specified, the AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables are used.
+	AccessKeyID     string `json:"accessKeyId,omitempty"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+
+	// Amount of queued messages that triggers sending a batch. Defaults to 10000, the service limit.
+	MaxBatch int `json:"maxBatch,omitempty"`
+
+	// Maximum time a message waits in the queue before being sent. Defaults to 5 seconds.
+	MaxLatency time.Duration `json:"maxLatency,omitempty"`
+
+	// Optional HTTP client to use. Defaults to a client with a 30 seconds timeout.
+	HttpClient *http.Client `json:"-"`
+
+	// Set the initial logging level to use.
+	Level *LogLevel `json:"level,omitempty"`
+
+	// Set the initial logging level for debug output to use.
+	DebugLevel *uint `json:"debugLevel,omitempty"`
+}
+
+type cloudWatchAdapter struct {
+	nextErrNotify int64 // Keep first for atomic access alignment
+	mtx           sync.Mutex
+	queue         []cloudWatchEvent
+	queueBytes    int
+	sendMtx       sync.Mutex
+	batcher       *batcher
+	sequenceToken string
+	lastWasError  int32
+	endpoint      string
+	region        string
+	logGroup      string
+	logStream     string
+	accessKeyID   string
+	secretKey     string
+	sessionToken  string
+	maxBatch      int
+	client        *http.Client
+	globals       globalOptions
+}
+
+type cloudWatchEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+type cloudWatchPutRequest struct {
+	LogGroupName  string            `json:"logGroupName"`
+	LogStreamName string            `json:"logStreamName"`
+	LogEvents     []cloudWatchEvent `json:"logEvents"`
+	SequenceToken string            `json:"sequenceToken,omitempty"`
+}
+
+type cloudWatchPutResponse struct {
+	NextSequenceToken string `json:"nextSequenceToken"`
+}
+
+type cloudWatchErrorResponse struct {
+	Type                  string `json:"__type"`
+	Message               string `json:"message"`
+	ExpectedSequenceToken string `json:"expectedSequenceToken"`
+}
+
+//------------------------------------------------------------------------------
+
+func createCloudWatchAdapter(opts CloudWatchOptions, glbOpts globalOptions) (internalLogger, error) {
+	if len(opts.LogGroup) == 0 || len(opts.LogStream) == 0 {
+		return nil, errors.New("CloudWatch log group and stream must be specified")
+	}
+
+	// Create CloudWatch adapter
+	lg := &cloudWatchAdapter{
+		logGroup:     opts.LogGroup,
+		logStream:    opts.LogStream,
+		region:       opts.Region,
+		endpoint:     opts.Endpoint,
+		accessKeyID:  opts.AccessKeyID,
+		secretKey:    opts.SecretAccessKey,
+		sessionToken: opts.SessionToken,
+		maxBatch:     opts.MaxBatch,
+		client:       opts.HttpClient,
+		globals:      glbOpts,
+	}
+
+	// Set output level based on globals or overrides
+	if opts.Level != nil {
+		lg.globals.Level = *opts.Level
+		lg.globals.DebugLevel = 1
+	}
+	if opts.DebugLevel != nil {
+		lg.globals.DebugLevel = *opts.DebugLevel
+	}
+
+	// Set the region and the endpoint
+	if len(lg.region) == 0 {
+		lg.region = os.Getenv("AWS_REGION")
+		if len(lg.region) == 0 {
+			return nil, errors.New("CloudWatch region must be specified")
+		}
+	}
+	if len(lg.endpoint) == 0 {
+		lg.endpoint = "https://logs." + lg.region + ".amazonaws.com"
+	}
+	u, err := url.Parse(lg.endpoint)
+	if err != nil || len(u.Host) == 0 {
+		return nil, errors.New("invalid CloudWatch endpoint")
+	}
+
+	// Set the credentials
+	if len(lg.accessKeyID) == 0 {
+		lg.accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		lg.secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		lg.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if len(lg.accessKeyID) == 0 || len(lg.secretKey) == 0 {
+		return nil, errors.New("CloudWatch credentials must be specified")
+	}
+
+	if lg.maxBatch <= 0 || lg.maxBatch > cloudWatchMaxBatchEvents {
+		lg.maxBatch = cloudWatchMaxBatchEvents
+	}
+	if lg.client == nil {
+		lg.client = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
+	// Start background worker
+	lg.batcher = newBatcher(lg.maxBatch, opts.MaxLatency, func() {
+		_ = lg.sync()
+	})
+
+	// Done
+	return lg, nil
+}
+
+func (lg *cloudWatchAdapter) class() string {
+	return "cloudwatch"
+}
+
+func (lg *cloudWatchAdapter) destroy() {
+	// Stop worker and wait until exited
+	lg.batcher.stop()
+
+	// Send queued messages
+	_ = lg.sync()
+}
+
+func (lg *cloudWatchAdapter) setLevel(level LogLevel, debugLevel uint) {
+	lg.globals.Level = level
+	lg.globals.DebugLevel = debugLevel
+}
+
+func (lg *cloudWatchAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *cloudWatchAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *cloudWatchAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+}
+
+// sync sends the queued messages synchronously and returns an error if some of them could not be delivered.
+func (lg *cloudWatchAdapter) sync() error {
+	lg.sendMtx.Lock()
+	defer lg.sendMtx.Unlock()
+
+	var lastErr error
+	for {
+		batch := lg.dequeueBatch()
+		if len(batch) == 0 {
+			return lastErr
+		}
+		if err := lg.putLogEvents(batch); err != nil {
+			lastErr = err
+		}
+	}
+}
+
+func (lg *cloudWatchAdapter) logError(now time.Time, msg string, _ bool) {
+	if lg.globals.Level >= LogLevelError {
+		lg.queueMessage(now, msg)
+	}
+}
+
+func (lg *cloudWatchAdapter) logWarning(now time.Time, msg string, _ bool) {
+	if lg.globals.Level >= LogLevelWarning {
+		lg.queueMessage(now, msg)
+	}
+}
+
+func (lg *cloudWatchAdapter) logInfo(now time.Time, msg string, _ bool) {
+	if lg.globals.Level >= LogLevelInfo {
+		lg.queueMessage(now, msg)
+	}
+}
+
+func (lg *cloudWatchAdapter) logDebug(level uint, now time.Time, msg string, _ bool) {
+	if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= level {
+		lg.queueMessage(now, msg)
+	}
+}
+
+func (lg *cloudWatchAdapter) logAudit(now time.Time, msg string, _ bool) {
+	lg.queueMessage(now, msg)
+}
+
+func (lg *cloudWatchAdapter) idle() bool {
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	return len(lg.queue) == 0 && !lg.batcher.busy()
+}
+
+func (lg *cloudWatchAdapter) queueMessage(now time.Time, msg string) {
+	if len(msg) > cloudWatchMaxEventBytes {
+		msg = msg[:cloudWatchMaxEventBytes]
+	}
+
+	lg.mtx.Lock()
+	lg.queue = append(lg.queue, cloudWatchEvent{
+		Timestamp: now.UnixNano() / int64(time.Millisecond),
+		Message:   msg,
+	})
+	lg.queueBytes += len(msg) + cloudWatchEventOverhead
+	count := len(lg.queue)
+	if lg.queueBytes >= cloudWatchMaxBatchBytes {
+		// Send as soon as possible if the queue reached the request size limit
+		count = lg.maxBatch
+	}
+	lg.mtx.Unlock()
+
+	lg.batcher.queued(count)
+}
+
+// dequeueBatch removes from the queue as many events as fit in a single request.
+func (lg *cloudWatchAdapter) dequeueBatch() []cloudWatchEvent {
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	count := 0
+	size := 0
+	for count < len(lg.queue) && count < lg.maxBatch {
+		eventSize := len(lg.queue[count].Message) + cloudWatchEventOverhead
+		if size+eventSize > cloudWatchMaxBatchBytes {
+			break
+		}
+		size += eventSize
+		count += 1
+	}
+	if count == 0 {
+		return nil
+	}
+
+	batch := make([]cloudWatchEvent, count)
+	copy(batch, lg.queue)
+	lg.queue = append(lg.queue[:0], lg.queue[count:]...)
+	lg.queueBytes -= size
+	return batch
+}
+
+// putLogEvents sends a batch of events. Must be called within the send lock.
+func (lg *cloudWatchAdapter) putLogEvents(batch []cloudWatchEvent) error {
+	// Events within a batch must be in chronological order
+	sort.SliceStable(batch, func(i, j int) bool {
+		return batch[i].Timestamp < batch[j].Timestamp
+	})
+
+	err := lg.putLogEventsOnce(batch)
+	var cwErr *cloudWatchErrorResponse
+	if errors.As(err, &cwErr) && len(cwErr.ExpectedSequenceToken) > 0 {
+		switch cwErr.Type {
+		case "InvalidSequenceTokenException":
+			// Another writer used the stream, retry with the expected token
+			lg.sequenceToken = cwErr.ExpectedSequenceToken
+			err = lg.putLogEventsOnce(batch)
+
+		case "DataAlreadyAcceptedException":
+			// A previous attempt already delivered the batch
+			lg.sequenceToken = cwErr.ExpectedSequenceToken
+			err = nil
+		}
+	}
+
+	// Handle error
+	if lg.globals.shouldNotifyError(err, &lg.lastWasError, &lg.nextErrNotify) && lg.globals.ErrorHandler != nil {
+		lg.globals.ErrorHandler(fmt.Sprintf("Unable to deliver notification to CloudWatch [%v]", err))
+	}
+	return err
+}
+
+func (lg *cloudWatchAdapter) putLogEventsOnce(batch []cloudWatchEvent) error {
+	body, err := json.Marshal(cloudWatchPutRequest{
+		LogGroupName:  lg.logGroup,
+		LogStreamName: lg.logStream,
+		LogEvents:     batch,
+		SequenceToken: lg.sequenceToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, lg.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", cloudWatchContentType)
+	req.Header.Set("X-Amz-Target", cloudWatchTarget)
+	lg.signRequest(req, body, time.Now().UTC())
+
+	resp, err := lg.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 65536))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		cwErr := &cloudWatchErrorResponse{}
+		if json.Unmarshal(respBody, cwErr) != nil || len(cwErr.Type) == 0 {
+			return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+		}
+		return cwErr
+	}
+
+	putResp := cloudWatchPutResponse{}
+	if err = json.Unmarshal(respBody, &putResp); err != nil {
+		return err
+	}
+	lg.sequenceToken = putResp.NextSequenceToken
+	return nil
+}
+
+// signRequest adds the AWS Signature Version 4 headers to the request.
+func (lg *cloudWatchAdapter) signRequest(req *http.Request, body []byte, now time.Time) {
+	if len(lg.sessionToken) > 0 {
+		req.Header.Set("X-Amz-Security-Token", lg.sessionToken)
+	}
+	signRequestV4(req, body, now, lg.accessKeyID, lg.secretKey, lg.region, "logs")
+}
+
+// signRequestV4 adds the X-Amz-Date and Authorization headers of AWS Signature Version 4 to the request. The host
+// and every header already set are signed.
+func signRequestV4(req *http.Request, body []byte, now time.Time, accessKeyID string, secretKey string, region string,
+	service string,
+) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	host := req.Host
+	if len(host) == 0 {
+		host = req.URL.Host
+	}
+	headers := map[string]string{
+		"host": host,
+	}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for idx, value := range values {
+			trimmed[idx] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+	canonicalRequest := req.Method + "\n" + path + "\n" + req.URL.RawQuery + "\n" + canonicalHeaders + "\n" +
+		signedHeaders + "\n" + sha256Hex(body)
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func (e *cloudWatchErrorResponse) Error() string {
+	if len(e.Message) > 0 {
+		return e.Type + ": " + e.Message
+	}
+	return e.Type
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,59 @@
+// Package compat exposes the package-level logging functions of the legacy packages backed by a v2 Logger, so
+// code using them can be migrated incrementally.
+package compat
+
+import (
+	"sync"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+var (
+	currentLoggerMtx = sync.RWMutex{}
+	currentLogger    *logger.Logger
+)
+
+//------------------------------------------------------------------------------
+
+// SetLogger sets the logger used by the package-level functions. Pass nil to use the default logger.
+func SetLogger(lg *logger.Logger) {
+	currentLoggerMtx.Lock()
+	currentLogger = lg
+	currentLoggerMtx.Unlock()
+}
+
+// Error emits an error message through the configured logger.
+func Error(obj interface{}) {
+	getLogger().Error(obj)
+}
+
+// Warn emits a warning message through the configured logger.
+func Warn(obj interface{}) {
+	getLogger().Warning(obj)
+}
+
+// Info emits an information message through the configured logger.
+func Info(obj interface{}) {
+	getLogger().Info(obj)
+}
+
+// Debug emits a debug message, at debug level 1, through the configured logger.
+func Debug(obj interface{}) {
+	getLogger().Debug(1, obj)
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func getLogger() *logger.Logger {
+	currentLoggerMtx.RLock()
+	lg := currentLogger
+	currentLoggerMtx.RUnlock()
+
+	if lg == nil {
+		lg = logger.Default()
+	}
+	return lg
+}
@@ -1,56 +1,180 @@
 package go_logger
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gookit/color"
 )
 
 //------------------------------------------------------------------------------
 
+const (
+	consoleQueueSize = 1024
+
+	consoleDropNotifyInterval = time.Minute
+)
+
+//------------------------------------------------------------------------------
+
 // ConsoleOptions specifies the console logger settings to use when it is created.
 type ConsoleOptions struct {
 	// Disable console output.
 	Disable bool `json:"disable,omitempty"`
 
+	// Optional callback to call instead of Options.ErrorHandler if an internal error of this target must be notified.
+	ErrorHandler ErrorHandler `json:"-"`
+
 	// Set the initial logging level to use.
 	Level *LogLevel `json:"level,omitempty"`
 
 	// Set the initial logging level for debug output to use.
 	DebugLevel *uint `json:"debugLevel,omitempty"`
+
+	// Write messages from a background goroutine and drop them, instead of blocking the caller, if the terminal
+	// does not keep up.
+	NonBlocking bool `json:"nonBlocking,omitempty"`
+
+	// Pad level labels to the same width so messages start at the same column.
+	PadLevels bool `json:"padLevels,omitempty"`
+
+	// Indent JSON messages over several lines for readability. Other targets keep writing them compactly.
+	PrettyJSON bool `json:"prettyJson,omitempty"`
+
+	// Build each line in a buffer and write it with a single call, so lines are never split by concurrent writes
+	// of other code sharing the output stream.
+	LineBuffered bool `json:"lineBuffered,omitempty"`
+
+	// Also tint the text of plain text messages with the color of their level, like red for errors, so they stand
+	// out. It only applies when colors are used. JSON messages are never colored.
+	ColorizeMessage bool `json:"colorizeMessage,omitempty"`
+
+	// Write messages as logfmt lines, like ts=... level=info msg="..." key=value, instead of plain text and JSON.
+	Logfmt bool `json:"logfmt,omitempty"`
+
+	// Optional writers to use instead of the standard output and error, for example, to capture the output in tests.
+	// Info, debug and audit messages go to Stdout while errors and warnings go to Stderr. Defaults to the OS streams.
+	// Colors are only used on writers that are terminals.
+	Stdout io.Writer `json:"-"`
+	Stderr io.Writer `json:"-"`
+
+	// Optional writers, like a terminal UI widget, receiving every message instead of the standard output and error.
+	// Messages are formatted once and written to all of them. Colors are only used on writers that are terminals.
+	Writers []io.Writer `json:"-"`
 }
 
 type consoleAdapter struct {
-	themedLevels [4]string
+	themedLevels [5]string
+	plainLevels  [5]string
+	msgStyles    [5]color.Style
+	colorizeMsg  bool
+	stdout       consoleWriter
+	stderr       consoleWriter
+	writers      []consoleWriter
+	prettyJSON   bool
+	lineBuffered bool
+	useLogfmt    bool
+	lineBuf      []byte
 	globals      globalOptions
+	queue        chan consoleMessage
+	workerDoneCh chan struct{}
+	dropped      uint64
+	nextDropNote int64
+}
+
+type consoleMessage struct {
+	cw       consoleWriter
+	now      time.Time
+	levelIdx int
+	msg      string
+	raw      bool
+}
+
+type consoleWriter struct {
+	w       io.Writer
+	colored bool
 }
 
 //------------------------------------------------------------------------------
 
 var consoleMtx = sync.Mutex{}
 
+var consoleStdout io.Writer = os.Stdout
+var consoleStderr io.Writer = os.Stderr
+
+var consoleColorSupported = color.IsSupportColor
+
+// levelStyles contains the theme of the error, warning, info, debug and audit level labels.
+var levelStyles = [5]color.Style{
+	color.New(color.OpBlink, color.FgLightWhite, color.BgRed),
+	color.New(color.FgLightYellow),
+	color.New(color.FgLightGreen),
+	color.New(color.FgCyan),
+	color.New(color.FgLightMagenta),
+}
+
 //------------------------------------------------------------------------------
 
 func createConsoleAdapter(opts ConsoleOptions, glbOpts globalOptions) internalLogger {
 	// Create console adapter
 	lg := &consoleAdapter{
-		globals: glbOpts,
+		globals:      glbOpts,
+		prettyJSON:   opts.PrettyJSON,
+		lineBuffered: opts.LineBuffered,
+		useLogfmt:    opts.Logfmt,
 	}
 
-	if color.IsSupportColor() {
-		lg.themedLevels[0] = color.New(color.OpBlink, color.FgLightWhite, color.BgRed).Sprintf("[ERROR]")
-		lg.themedLevels[1] = color.New(color.FgLightYellow).Sprintf("[WARN]")
-		lg.themedLevels[2] = color.New(color.FgLightGreen).Sprintf("[INFO]")
-		lg.themedLevels[3] = color.New(color.FgCyan).Sprintf("[DEBUG]")
+	lg.plainLevels = [5]string{"[ERROR]", "[WARN]", "[INFO]", "[DEBUG]", "[AUDIT]"}
+	if consoleColorSupported() {
+		for idx, label := range lg.plainLevels {
+			lg.themedLevels[idx] = levelStyles[idx].Sprint(label)
+		}
+
+		if opts.ColorizeMessage {
+			lg.msgStyles = [5]color.Style{
+				color.New(color.FgLightRed),
+				color.New(color.FgLightYellow),
+				color.New(color.FgLightGreen),
+				color.New(color.FgCyan),
+				color.New(color.FgLightMagenta),
+			}
+			lg.colorizeMsg = true
+		}
 	} else {
-		lg.themedLevels[0] = "[ERROR]"
-		lg.themedLevels[1] = "[WARN]"
-		lg.themedLevels[2] = "[INFO]"
-		lg.themedLevels[3] = "[DEBUG]"
+		lg.themedLevels = lg.plainLevels
+	}
+	if opts.PadLevels {
+		padLabels(lg.themedLevels[:])
+		padLabels(lg.plainLevels[:])
+	}
+
+	// Set the output streams
+	lg.stdout = consoleWriter{
+		w:       consoleStdout,
+		colored: true,
+	}
+	if opts.Stdout != nil {
+		lg.stdout = newConsoleWriter(opts.Stdout)
+	}
+	lg.stderr = consoleWriter{
+		w:       consoleStderr,
+		colored: true,
+	}
+	if opts.Stderr != nil {
+		lg.stderr = newConsoleWriter(opts.Stderr)
+	}
+
+	// Decide whether to use colors on each custom writer
+	for _, w := range opts.Writers {
+		lg.writers = append(lg.writers, newConsoleWriter(w))
 	}
 
 	// Set output level based on globals or overrides
@@ -62,6 +186,13 @@ func createConsoleAdapter(opts ConsoleOptions, glbOpts globalOptions) internalLo
 		lg.globals.DebugLevel = *opts.DebugLevel
 	}
 
+	// Create a background writer if requested
+	if opts.NonBlocking {
+		lg.queue = make(chan consoleMessage, consoleQueueSize)
+		lg.workerDoneCh = make(chan struct{})
+		go lg.writerWorker()
+	}
+
 	// Done
 	return lg
 }
@@ -71,7 +202,14 @@ func (lg *consoleAdapter) class() string {
 }
 
 func (lg *consoleAdapter) destroy() {
-	// Do nothing
+	if lg.queue != nil {
+		// Stop the background writer and wait until pending messages are written
+		close(lg.queue)
+		select {
+		case <-lg.workerDoneCh:
+		case <-time.After(flushTimeout):
+		}
+	}
 }
 
 func (lg *consoleAdapter) setLevel(level LogLevel, debugLevel uint) {
@@ -79,52 +217,240 @@ func (lg *consoleAdapter) setLevel(level LogLevel, debugLevel uint) {
 	lg.globals.DebugLevel = debugLevel
 }
 
+func (lg *consoleAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *consoleAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *consoleAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+}
+
+func (lg *consoleAdapter) sync() error {
+	return nil
+}
+
+func (lg *consoleAdapter) logfmt() bool {
+	return lg.useLogfmt
+}
+
 func (lg *consoleAdapter) logError(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelError {
-		if !raw {
-			consolePrint(os.Stderr, now, lg.themedLevels[0], msg)
-		} else {
-			consolePrintRAW(os.Stderr, msg)
-		}
+		lg.print(lg.stderr, now, 0, msg, raw)
 	}
 }
 
 func (lg *consoleAdapter) logWarning(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelWarning {
-		if !raw {
-			consolePrint(os.Stderr, now, lg.themedLevels[1], msg)
-		} else {
-			consolePrintRAW(os.Stderr, msg)
-		}
+		lg.print(lg.stderr, now, 1, msg, raw)
 	}
 }
 
 func (lg *consoleAdapter) logInfo(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelInfo {
-		if !raw {
-			consolePrint(os.Stdout, now, lg.themedLevels[2], msg)
-		} else {
-			consolePrintRAW(os.Stdout, msg)
-		}
+		lg.print(lg.stdout, now, 2, msg, raw)
 	}
 }
 
 func (lg *consoleAdapter) logDebug(level uint, now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= level {
-		if !raw {
-			consolePrint(os.Stdout, now, lg.themedLevels[3], msg)
+		lg.print(lg.stdout, now, 3, msg, raw)
+	}
+}
+
+func (lg *consoleAdapter) logAudit(now time.Time, msg string, raw bool) {
+	// Audit messages are never dropped
+	lg.printBlocking(lg.stdout, now, 4, msg, raw)
+}
+
+func (lg *consoleAdapter) print(cw consoleWriter, now time.Time, levelIdx int, msg string, raw bool) {
+	if lg.queue == nil {
+		lg.write(cw, now, levelIdx, msg, raw)
+		return
+	}
+
+	// Queue the message or drop it if the queue is full
+	select {
+	case lg.queue <- consoleMessage{cw: cw, now: now, levelIdx: levelIdx, msg: msg, raw: raw}:
+	default:
+		dropped := atomic.AddUint64(&lg.dropped, 1)
+		lg.notifyDropped(dropped)
+	}
+}
+
+func (lg *consoleAdapter) printBlocking(cw consoleWriter, now time.Time, levelIdx int, msg string, raw bool) {
+	if lg.queue == nil {
+		lg.write(cw, now, levelIdx, msg, raw)
+		return
+	}
+
+	// Wait for room in the queue to keep messages in order
+	lg.queue <- consoleMessage{cw: cw, now: now, levelIdx: levelIdx, msg: msg, raw: raw}
+}
+
+// write outputs the message to the given output stream or, if set, to all the custom writers.
+func (lg *consoleAdapter) write(cw consoleWriter, now time.Time, levelIdx int, msg string, raw bool) {
+	if raw && lg.prettyJSON && !lg.useLogfmt {
+		msg = indentJSON(msg)
+	}
+	themedMsg := msg
+	if !raw && lg.colorizeMsg {
+		themedMsg = lg.msgStyles[levelIdx].Sprint(msg)
+	}
+
+	if len(lg.writers) == 0 {
+		level := lg.themedLevels[levelIdx]
+		if !cw.colored {
+			level = lg.plainLevels[levelIdx]
+			themedMsg = msg
+		}
+		if lg.lineBuffered {
+			lg.writeLine(cw.w, now, level, themedMsg, raw)
+		} else {
+			consoleWrite(cw.w, now, lg.globals.TimestampFormat, level, themedMsg, raw)
+		}
+		return
+	}
+
+	// Format the message once per color mode
+	plain := msg + "\n"
+	themed := plain
+	if !raw {
+		ts := now.Format(lg.globals.TimestampFormat)
+		plain = ts + " " + lg.plainLevels[levelIdx] + " " + msg + "\n"
+		themed = ts + " " + lg.themedLevels[levelIdx] + " " + themedMsg + "\n"
+	}
+
+	// Lock console access
+	consoleMtx.Lock()
+	for _, writer := range lg.writers {
+		if writer.colored {
+			_, _ = io.WriteString(writer.w, themed)
 		} else {
-			consolePrintRAW(os.Stdout, msg)
+			_, _ = io.WriteString(writer.w, plain)
+		}
+	}
+	// Unlock console access
+	consoleMtx.Unlock()
+}
+
+// writeLine builds the whole line in a reusable buffer and writes it with a single call.
+func (lg *consoleAdapter) writeLine(w io.Writer, now time.Time, themedLevel string, msg string, raw bool) {
+	// Lock console access
+	consoleMtx.Lock()
+
+	lg.lineBuf = lg.lineBuf[:0]
+	if !raw {
+		lg.lineBuf = now.AppendFormat(lg.lineBuf, lg.globals.TimestampFormat)
+		lg.lineBuf = append(lg.lineBuf, ' ')
+		lg.lineBuf = append(lg.lineBuf, themedLevel...)
+		lg.lineBuf = append(lg.lineBuf, ' ')
+	}
+	lg.lineBuf = append(lg.lineBuf, msg...)
+	lg.lineBuf = append(lg.lineBuf, '\n')
+	_, _ = w.Write(lg.lineBuf)
+
+	// Unlock console access
+	consoleMtx.Unlock()
+}
+
+func (lg *consoleAdapter) notifyDropped(dropped uint64) {
+	if lg.globals.ErrorHandler == nil {
+		return
+	}
+
+	// Notify at most once per interval
+	now := time.Now().UnixNano()
+	nextNote := atomic.LoadInt64(&lg.nextDropNote)
+	if now >= nextNote &&
+		atomic.CompareAndSwapInt64(&lg.nextDropNote, nextNote, now+int64(consoleDropNotifyInterval)) {
+		lg.globals.ErrorHandler(fmt.Sprintf("Console is not keeping up. %v messages were dropped so far", dropped))
+	}
+}
+
+func (lg *consoleAdapter) writerWorker() {
+	for m := range lg.queue {
+		lg.write(m.cw, m.now, m.levelIdx, m.msg, m.raw)
+	}
+	close(lg.workerDoneCh)
+}
+
+// newConsoleWriter wraps a custom writer, using colors only if it is a terminal.
+func newConsoleWriter(w io.Writer) consoleWriter {
+	colored := false
+	if f, ok := w.(*os.File); ok {
+		colored = consoleColorSupported() && color.IsTerminal(f.Fd())
+	}
+	return consoleWriter{
+		w:       w,
+		colored: colored,
+	}
+}
+
+// themeLabel applies the theme of the given level to the label, even if the console does not support colors.
+func themeLabel(levelIdx int, label string) string {
+	return color.StartSet + levelStyles[levelIdx].Code() + "m" + label + color.ResetSet
+}
+
+// indentJSON returns the JSON message indented over several lines, or the original one if it cannot be parsed.
+func indentJSON(msg string) string {
+	buf := bytes.Buffer{}
+	if json.Indent(&buf, []byte(msg), "", "  ") != nil {
+		return msg
+	}
+	return buf.String()
+}
+
+// padLabels appends spaces to the given labels so all of them have the same visible width.
+func padLabels(labels []string) {
+	maxWidth := 0
+	for _, label := range labels {
+		if w := visibleWidth(label); w > maxWidth {
+			maxWidth = w
 		}
 	}
+	for idx, label := range labels {
+		labels[idx] = label + strings.Repeat(" ", maxWidth-visibleWidth(label))
+	}
+}
+
+// visibleWidth returns the amount of characters of s that are displayed, ignoring ANSI escape sequences.
+func visibleWidth(s string) int {
+	width := 0
+	for idx := 0; idx < len(s); {
+		if s[idx] == '\x1b' && idx+1 < len(s) && s[idx+1] == '[' {
+			// Skip the control sequence up to and including its final byte
+			idx += 2
+			for idx < len(s) && (s[idx] < 0x40 || s[idx] > 0x7E) {
+				idx++
+			}
+			idx++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[idx:])
+		idx += size
+		width++
+	}
+	return width
+}
+
+func consoleWrite(w io.Writer, now time.Time, tsFormat string, themedLevel string, msg string, raw bool) {
+	if !raw {
+		consolePrint(w, now, tsFormat, themedLevel, msg)
+	} else {
+		consolePrintRAW(w, msg)
+	}
 }
 
-func consolePrint(w io.Writer, now time.Time, themedLevel string, msg string) {
+func consolePrint(w io.Writer, now time.Time, tsFormat string, themedLevel string, msg string) {
 	// Lock console access
 	consoleMtx.Lock()
 
 	// Print the message prefixed with the timestamp and level
-	_, _ = fmt.Fprintf(w, "%v %v %v\n", now.Format("2006-01-02 15:04:05.000"), themedLevel, msg)
+	_, _ = fmt.Fprintf(w, "%v %v %v\n", now.Format(tsFormat), themedLevel, msg)
 
 	// Unlock console access
 	consoleMtx.Unlock()
@@ -0,0 +1,137 @@
+package go_logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+type callSiteDedup struct {
+	mtx     sync.Mutex
+	window  time.Duration
+	entries map[uintptr]*dedupEntry
+	stopCh  chan struct{}
+}
+
+type dedupEntry struct {
+	level      LogLevel
+	debugLevel uint
+	msg        string
+	expires    time.Time
+	repeated   uint
+}
+
+type dedupSummary struct {
+	level      LogLevel
+	debugLevel uint
+	msg        string
+}
+
+//------------------------------------------------------------------------------
+
+func newCallSiteDedup(lg *Logger, window time.Duration) *callSiteDedup {
+	d := &callSiteDedup{
+		window:  window,
+		entries: make(map[uintptr]*dedupEntry),
+		stopCh:  make(chan struct{}),
+	}
+	go d.worker(lg)
+	return d
+}
+
+// suppress returns true if the message is identical to the last one logged from the same call site within the
+// window. If a different message replaces a repeated one, the summary of the latter is also returned.
+func (d *callSiteDedup) suppress(pc uintptr, level LogLevel, debugLevel uint, msg string) (bool, *dedupSummary) {
+	var summary *dedupSummary
+
+	now := time.Now()
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	entry, ok := d.entries[pc]
+	if ok && now.Before(entry.expires) && entry.level == level && entry.debugLevel == debugLevel &&
+		entry.msg == msg {
+		entry.repeated += 1
+		return true, nil
+	}
+	if ok && entry.repeated > 0 {
+		summary = entry.summary(pc)
+	}
+	d.entries[pc] = &dedupEntry{
+		level:      level,
+		debugLevel: debugLevel,
+		msg:        msg,
+		expires:    now.Add(d.window),
+	}
+	return false, summary
+}
+
+// prune removes the entries whose window closed and returns the summaries of the repeated ones.
+func (d *callSiteDedup) prune(all bool) []*dedupSummary {
+	summaries := make([]*dedupSummary, 0)
+
+	now := time.Now()
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for pc, entry := range d.entries {
+		if all || !now.Before(entry.expires) {
+			if entry.repeated > 0 {
+				summaries = append(summaries, entry.summary(pc))
+			}
+			delete(d.entries, pc)
+		}
+	}
+	return summaries
+}
+
+// stop terminates the background worker and returns the summaries of the messages still being repeated.
+func (d *callSiteDedup) stop() []*dedupSummary {
+	close(d.stopCh)
+	return d.prune(true)
+}
+
+func (d *callSiteDedup) worker(lg *Logger) {
+	ticker := time.NewTicker(d.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+
+		case <-ticker.C:
+			lg.emitDedupSummaries(d.prune(false))
+		}
+	}
+}
+
+func (e *dedupEntry) summary(pc uintptr) *dedupSummary {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return &dedupSummary{
+		level:      e.level,
+		debugLevel: e.debugLevel,
+		msg: fmt.Sprintf("message from %v:%v repeated %v times", filepath.Base(frame.File), frame.Line,
+			e.repeated),
+	}
+}
+
+// callerPC returns the program counter of the code calling the public logging method, skipping the frames of this
+// module, like emit or the methods of derived loggers and entries.
+func callerPC() uintptr {
+	var pcs [16]uintptr
+
+	n := runtime.Callers(2, pcs[:])
+	for _, pc := range pcs[:n] {
+		if fn := runtime.FuncForPC(pc - 1); fn == nil || !isModuleFunction(fn.Name()) {
+			return pc
+		}
+	}
+	return 0
+}
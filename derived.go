@@ -0,0 +1,67 @@
+package go_logger
+
+//------------------------------------------------------------------------------
+
+// WithLevel returns a lightweight logger, for example for a subsystem, sending messages to the same targets as this
+// one but applying its own level filter first. Targets are not duplicated, so files are not opened twice. The filter
+// can only restrict the output further: messages must also pass the level of each target. Audit messages always
+// pass it.
+//
+// The derived logger shares the targets and settings of this logger and keeps the fields added to it with With.
+// Every method but the logging ones and With acts on this logger, except Destroy, which only detaches the derived
+// logger, leaving the targets in place.
+func (lg *Logger) WithLevel(level LogLevel, debugLevel uint) *Logger {
+	return &Logger{
+		parent:      lg.root(),
+		filterLevel: level,
+		filterDebug: debugLevel,
+		name:        lg.name,
+		fields:      lg.scopedFields(),
+	}
+}
+
+// Named returns a lightweight logger, like WithLevel does, whose messages carry the given name. Targets can use it
+// to route them, for example, to a syslog facility set in SysLogOptions.FacilityByName. A named logger keeps the
+// level filter of this logger, if any.
+func (lg *Logger) Named(name string) *Logger {
+	derived := &Logger{
+		parent:      lg.root(),
+		filterLevel: LogLevelDebug,
+		filterDebug: ^uint(0),
+		name:        name,
+		fields:      lg.scopedFields(),
+	}
+	if lg.parent != nil {
+		derived.filterLevel = lg.filterLevel
+		derived.filterDebug = lg.filterDebug
+	}
+	return derived
+}
+
+//------------------------------------------------------------------------------
+
+// root returns the logger owning the targets.
+func (lg *Logger) root() *Logger {
+	if lg.parent != nil {
+		return lg.parent
+	}
+	return lg
+}
+
+// filterAllows returns true if a derived logger was not destroyed and its level filter lets the message pass.
+func (lg *Logger) filterAllows(level LogLevel, debugLevel uint) bool {
+	lg.mtx.RLock()
+	destroyed := lg.destroyed
+	lg.mtx.RUnlock()
+
+	if destroyed {
+		return false
+	}
+	if level == logLevelAudit {
+		return true
+	}
+	if lg.filterLevel < level {
+		return false
+	}
+	return level != LogLevelDebug || lg.filterDebug >= debugLevel
+}
@@ -0,0 +1,225 @@
+package go_logger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+//------------------------------------------------------------------------------
+
+// Entry is a JSON message being built with typed fields. Get one with Logger.Entry or Logger.DebugEntry, add fields
+// with the chainable setters and emit it with Msg or Msgf. An entry must not be used after it is emitted.
+//
+// If the message would be discarded by the logging level, a nil entry is returned and all methods are no-ops, so
+// fields are not even formatted.
+type Entry struct {
+	lg         *Logger
+	level      LogLevel
+	debugLevel uint
+	buf        []byte
+	name       string
+	fields     []field
+}
+
+// entryPayload is a preformatted JSON object, created by an Entry, passed to emit.
+type entryPayload string
+
+//------------------------------------------------------------------------------
+
+// Entry starts a structured message at the given level. Debug entries use debug level 1.
+func (lg *Logger) Entry(level LogLevel) *Entry {
+	debugLevel := uint(0)
+	if level == LogLevelDebug {
+		debugLevel = 1
+	}
+	return lg.newEntry(level, debugLevel)
+}
+
+// DebugEntry starts a structured debug message at the given debug level.
+func (lg *Logger) DebugEntry(level uint) *Entry {
+	return lg.newEntry(LogLevelDebug, level)
+}
+
+// Str adds a string field.
+func (e *Entry) Str(key string, value string) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.appendKey(key)
+	e.buf = appendJSONString(e.buf, value)
+	return e
+}
+
+// Int adds an integer field.
+func (e *Entry) Int(key string, value int) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.appendKey(key)
+	e.buf = strconv.AppendInt(e.buf, int64(value), 10)
+	return e
+}
+
+// Bool adds a boolean field.
+func (e *Entry) Bool(key string, value bool) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.appendKey(key)
+	e.buf = strconv.AppendBool(e.buf, value)
+	return e
+}
+
+// Float adds a floating point field. NaN and infinite values, not supported by JSON, are added as strings.
+func (e *Entry) Float(key string, value float64) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.appendKey(key)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		e.buf = appendJSONString(e.buf, strconv.FormatFloat(value, 'g', -1, 64))
+	} else {
+		e.buf = strconv.AppendFloat(e.buf, value, 'g', -1, 64)
+	}
+	return e
+}
+
+// Err adds the error message as the error field, or null if err is nil.
+func (e *Entry) Err(err error) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.appendKey("error")
+	if err != nil {
+		e.buf = appendJSONString(e.buf, err.Error())
+	} else {
+		e.buf = append(e.buf, "null"...)
+	}
+	return e
+}
+
+// Time adds a timestamp field using the RFC 3339 layout with nanoseconds.
+func (e *Entry) Time(key string, value time.Time) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.appendKey(key)
+	e.buf = append(e.buf, '"')
+	e.buf = value.AppendFormat(e.buf, time.RFC3339Nano)
+	e.buf = append(e.buf, '"')
+	return e
+}
+
+// Dur adds a duration field as an amount of nanoseconds, like durations added with With.
+func (e *Entry) Dur(key string, value time.Duration) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.appendKey(key)
+	e.buf = strconv.AppendInt(e.buf, int64(value), 10)
+	return e
+}
+
+// Msg emits the entry with the given text as the message field.
+func (e *Entry) Msg(msg string) {
+	if e == nil {
+		return
+	}
+	e.lg.emitNamed(e.name, e.fields, e.level, e.debugLevel, e.payload(msg))
+}
+
+// Msgf emits the entry with the formatted text as the message field.
+func (e *Entry) Msgf(format string, args ...interface{}) {
+	if e == nil {
+		return
+	}
+	e.lg.emitNamed(e.name, e.fields, e.level, e.debugLevel, e.payload(fmt.Sprintf(format, args...)))
+}
+
+//------------------------------------------------------------------------------
+
+func (lg *Logger) newEntry(level LogLevel, debugLevel uint) *Entry {
+	if lg.parent != nil {
+		if !lg.filterAllows(level, debugLevel) {
+			return nil
+		}
+		entry := lg.parent.newEntry(level, debugLevel)
+		if entry != nil {
+			entry.name = lg.name
+			entry.fields = lg.scopedFields()
+		}
+		return entry
+	}
+
+	lg.checkLevelSchedule()
+
+	// Lock access
+	lg.mtx.RLock()
+	enabled := !lg.destroyed && lg.isEnabled(level, debugLevel)
+	lg.mtx.RUnlock()
+
+	if !enabled {
+		return nil
+	}
+	return &Entry{
+		lg:         lg,
+		level:      level,
+		debugLevel: debugLevel,
+		buf:        make([]byte, 0, 128),
+	}
+}
+
+func (e *Entry) appendKey(key string) {
+	e.buf = append(e.buf, ',')
+	e.buf = appendJSONString(e.buf, key)
+	e.buf = append(e.buf, ':')
+}
+
+func (e *Entry) payload(msg string) entryPayload {
+	b := make([]byte, 0, len(msg)+len(e.buf)+16)
+	b = append(b, `{"message":`...)
+	b = appendJSONString(b, msg)
+	b = append(b, e.buf...)
+	b = append(b, '}')
+	return entryPayload(b)
+}
+
+// appendJSONString appends s to b as a quoted JSON string. Invalid UTF-8 sequences are replaced with U+FFFD.
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	b = append(b, '"')
+	for idx := 0; idx < len(s); {
+		ch := s[idx]
+		if ch < utf8.RuneSelf {
+			switch {
+			case ch == '"' || ch == '\\':
+				b = append(b, '\\', ch)
+			case ch == '\n':
+				b = append(b, '\\', 'n')
+			case ch == '\r':
+				b = append(b, '\\', 'r')
+			case ch == '\t':
+				b = append(b, '\\', 't')
+			case ch < 0x20:
+				b = append(b, '\\', 'u', '0', '0', hex[ch>>4], hex[ch&0xF])
+			default:
+				b = append(b, ch)
+			}
+			idx += 1
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[idx:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, "\ufffd"...)
+		} else {
+			b = append(b, s[idx:idx+size]...)
+		}
+		idx += size
+	}
+	return append(b, '"')
+}
@@ -0,0 +1,139 @@
+package go_logger
+
+import (
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// ErrorBurstOptions specifies when a burst of error messages is signaled. Messages are still logged as usual.
+type ErrorBurstOptions struct {
+	// Amount of error messages within Window that starts a burst.
+	Threshold int `json:"threshold,omitempty"`
+
+	// Length of the sliding window errors are counted in. Defaults to one second.
+	Window time.Duration `json:"window,omitempty"`
+
+	// Called once, from another goroutine, when a burst starts with the amount of errors logged within the window.
+	OnErrorBurst func(count int, window time.Duration) `json:"-"`
+
+	// Optionally called when the burst subsides, that is, when the amount of errors within the window goes below
+	// the threshold again, with the total amount of errors logged during the burst and its duration.
+	OnErrorBurstEnd func(count int, duration time.Duration) `json:"-"`
+}
+
+type errorBurstDetector struct {
+	mtx        sync.Mutex
+	opts       ErrorBurstOptions
+	times      []time.Time
+	inBurst    bool
+	burstStart time.Time
+	burstCount int
+	timer      *time.Timer
+	stopped    bool
+}
+
+//------------------------------------------------------------------------------
+
+func newErrorBurstDetector(opts ErrorBurstOptions) *errorBurstDetector {
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+	return &errorBurstDetector{
+		opts:  opts,
+		times: make([]time.Time, 0, opts.Threshold),
+	}
+}
+
+// add accounts an error message and calls OnErrorBurst if it starts a burst.
+func (d *errorBurstDetector) add() {
+	now := time.Now()
+
+	d.mtx.Lock()
+
+	if d.stopped {
+		d.mtx.Unlock()
+		return
+	}
+	d.prune(now)
+	if len(d.times) >= d.opts.Threshold {
+		// Older errors are not needed to know the threshold is reached
+		d.times = append(d.times[:0], d.times[1:]...)
+	}
+	d.times = append(d.times, now)
+
+	if d.inBurst {
+		d.burstCount += 1
+		d.mtx.Unlock()
+		return
+	}
+	if len(d.times) < d.opts.Threshold {
+		d.mtx.Unlock()
+		return
+	}
+
+	// A burst started, check periodically when it ends
+	d.inBurst = true
+	d.burstStart = d.times[0]
+	d.burstCount = len(d.times)
+	count := d.burstCount
+	d.timer = time.AfterFunc(d.opts.Window, d.checkEnd)
+
+	d.mtx.Unlock()
+
+	// Do not block the caller, it is still holding the logger lock, so the callback can log messages too
+	if d.opts.OnErrorBurst != nil {
+		go d.opts.OnErrorBurst(count, d.opts.Window)
+	}
+}
+
+func (d *errorBurstDetector) checkEnd() {
+	now := time.Now()
+
+	d.mtx.Lock()
+
+	if d.stopped || !d.inBurst {
+		d.mtx.Unlock()
+		return
+	}
+	d.prune(now)
+	if len(d.times) >= d.opts.Threshold {
+		d.timer = time.AfterFunc(d.opts.Window, d.checkEnd)
+		d.mtx.Unlock()
+		return
+	}
+
+	d.inBurst = false
+	d.timer = nil
+	count := d.burstCount
+	duration := now.Sub(d.burstStart)
+
+	d.mtx.Unlock()
+
+	if d.opts.OnErrorBurstEnd != nil {
+		d.opts.OnErrorBurstEnd(count, duration)
+	}
+}
+
+// prune removes the errors that fell out of the window. Must be called within the lock.
+func (d *errorBurstDetector) prune(now time.Time) {
+	idx := 0
+	for idx < len(d.times) && now.Sub(d.times[idx]) >= d.opts.Window {
+		idx++
+	}
+	if idx > 0 {
+		d.times = append(d.times[:0], d.times[idx:]...)
+	}
+}
+
+// stop cancels pending checks. An ongoing burst is not reported as ended.
+func (d *errorBurstDetector) stop() {
+	d.mtx.Lock()
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.mtx.Unlock()
+}
@@ -0,0 +1,76 @@
+package go_logger
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+//------------------------------------------------------------------------------
+
+const defaultFatalExitCode = 1
+
+var osExit = os.Exit
+
+//------------------------------------------------------------------------------
+
+// Fatal emits an error message, flushes and destroys the logger and ends the process. The exit code is chosen by
+// Options.FatalExitCodeFunc, if set, from the logged value, so supervisors can tell failure types apart, or is
+// Options.FatalExitCodeDefault otherwise. Errors are logged using their message.
+func (lg *Logger) Fatal(obj interface{}) {
+	root := lg.root()
+
+	root.mtx.RLock()
+	code := root.fatalCode
+	if root.fatalCodeFn != nil {
+		code = root.fatalCodeFn(obj)
+	}
+	root.mtx.RUnlock()
+
+	if err, ok := obj.(error); ok {
+		lg.emit(LogLevelError, 0, err.Error())
+	} else {
+		lg.emit(LogLevelError, 0, obj)
+	}
+
+	// Make sure the message reaches the targets before exiting
+	_ = root.Sync()
+	root.Destroy()
+	osExit(code)
+}
+
+// FlushAtExit destroys the logger, sending queued messages and syncing files, when the process receives SIGINT or
+// SIGTERM, and then ends it with the exit code the default handler would cause, 128 plus the signal number. Call the
+// returned function to stop handling the signals.
+//
+// Go has no real atexit hook, so this is best-effort: nothing is flushed if the process ends with os.Exit, a panic
+// or SIGKILL, and finalizers are not run at exit either. Do not use it if the application handles those signals to
+// shut down gracefully; destroy the logger at the end of that path instead.
+func (lg *Logger) FlushAtExit() func() {
+	if lg.parent != nil {
+		return lg.parent.FlushAtExit()
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	stopCh := make(chan struct{})
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-sigCh:
+			lg.Destroy()
+			osExit(signalExitCode(sig))
+
+		case <-stopCh:
+		}
+	}()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigCh)
+			close(stopCh)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !plan9
+
+package go_logger
+
+import (
+	"os"
+	"syscall"
+)
+
+//------------------------------------------------------------------------------
+
+// signalExitCode returns the exit code of a process terminated by the given signal, following the shell convention.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
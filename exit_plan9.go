@@ -0,0 +1,13 @@
+package go_logger
+
+import (
+	"os"
+)
+
+//------------------------------------------------------------------------------
+
+// signalExitCode returns the exit code of a process terminated by the given signal. Plan 9 notes have no number
+// so it is always 1.
+func signalExitCode(_ os.Signal) int {
+	return 1
+}
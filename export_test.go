@@ -0,0 +1,126 @@
+package go_logger
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gookit/color"
+)
+
+//------------------------------------------------------------------------------
+
+// SetOsExecutable replaces the function used to get the executable path and returns a function to restore it.
+func SetOsExecutable(fn func() (string, error)) func() {
+	saved := osExecutable
+	osExecutable = fn
+	return func() {
+		osExecutable = saved
+	}
+}
+
+// SetTimeNow replaces the function used to get the timestamp of messages and returns a function to restore it.
+func SetTimeNow(fn func() time.Time) func() {
+	saved := timeNow
+	timeNow = fn
+	return func() {
+		timeNow = saved
+	}
+}
+
+// SetOsExit replaces the function used to end the process and returns a function to restore it.
+func SetOsExit(fn func(code int)) func() {
+	saved := osExit
+	osExit = fn
+	return func() {
+		osExit = saved
+	}
+}
+
+// SetConsoleWriters replaces the console output streams and returns a function to restore them.
+func SetConsoleWriters(stdout io.Writer, stderr io.Writer) func() {
+	savedStdout := consoleStdout
+	savedStderr := consoleStderr
+	consoleStdout = stdout
+	consoleStderr = stderr
+	return func() {
+		consoleStdout = savedStdout
+		consoleStderr = savedStderr
+	}
+}
+
+// SetFileSync replaces the function used to sync log files after writing a message and returns a function to
+// restore it.
+func SetFileSync(fn func(fd *os.File) error) func() {
+	saved := fileSync
+	fileSync = fn
+	return func() {
+		fileSync = saved
+	}
+}
+
+// ForceConsoleColors makes the console use colors even if the output is not a terminal and returns a function to
+// restore the detection.
+func ForceConsoleColors() func() {
+	saved := consoleColorSupported
+	savedLevel := color.ForceColor()
+	consoleColorSupported = func() bool {
+		return true
+	}
+	return func() {
+		consoleColorSupported = saved
+		color.ForceSetColorLevel(savedLevel)
+	}
+}
+
+// SetFileWriteString replaces the function used to write to log files and returns a function to restore it.
+func SetFileWriteString(fn func(fd *os.File, s string) (int, error)) func() {
+	saved := fileWriteString
+	fileWriteString = fn
+	return func() {
+		fileWriteString = saved
+	}
+}
+
+// OpenFileHandles returns the amount of log files tracked by the shared open files cache.
+func OpenFileHandles() int {
+	return sharedFileHandles.count()
+}
+
+// ReplaceLogFile replaces the file being written by the file target of the given logger.
+func ReplaceLogFile(lg *Logger, fd *os.File) {
+	for _, adapter := range lg.adapters {
+		if fa, ok := adapter.(*fileAdapter); ok {
+			fa.mtx.Lock()
+			if fa.fd != nil {
+				_ = fa.fd.Close()
+			}
+			fa.fd = fd
+			fa.mtx.Unlock()
+		}
+	}
+}
+
+// SysLogTLSConfig returns the TLS configuration used by the syslog target of the given logger, if any.
+func SysLogTLSConfig(lg *Logger) *tls.Config {
+	for _, adapter := range lg.adapters {
+		if sa, ok := adapter.(*syslogAdapter); ok {
+			return sa.tlsConfig
+		}
+	}
+	return nil
+}
+
+// VisibleWidth returns the amount of characters of s that are displayed, ignoring ANSI escape sequences.
+func VisibleWidth(s string) int {
+	return visibleWidth(s)
+}
+
+// SignRequestV4 adds the AWS Signature Version 4 headers to the request, like the CloudWatch adapter does.
+func SignRequestV4(req *http.Request, body []byte, now time.Time, accessKeyID string, secretKey string, region string,
+	service string,
+) {
+	signRequestV4(req, body, now, accessKeyID, secretKey, region, service)
+}
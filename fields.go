@@ -0,0 +1,244 @@
+package go_logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+//------------------------------------------------------------------------------
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+//------------------------------------------------------------------------------
+
+// With returns a lightweight logger, like WithLevel does, adding the field to every message it emits along with the
+// fields of this logger. Fields are added to the JSON payload and appended as key=value pairs to plain text messages.
+//
+// This logger is not modified, so the fields are scoped to the flow of execution using the returned logger and
+// other goroutines using this one never see them. Use PushContext to add fields for the duration of a block without
+// passing a logger around.
+func (lg *Logger) With(key string, value interface{}) *Logger {
+	fields := lg.scopedFields()
+	derived := &Logger{
+		parent:      lg.root(),
+		filterLevel: LogLevelDebug,
+		filterDebug: ^uint(0),
+		name:        lg.name,
+		fields: append(fields[:len(fields):len(fields)], field{
+			key:   key,
+			value: value,
+		}),
+	}
+	if lg.parent != nil {
+		derived.filterLevel = lg.filterLevel
+		derived.filterDebug = lg.filterDebug
+	}
+	return derived
+}
+
+// scopedFields returns the fields added with With to the messages of this logger.
+func (lg *Logger) scopedFields() []field {
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
+
+	return lg.fields
+}
+
+//------------------------------------------------------------------------------
+
+// PushContext returns a context carrying the field along with the fields pushed to ctx before, and a function to
+// remove it. Messages logged with ErrorContext, WarningContext, InfoContext or DebugContext and the returned context,
+// or one derived from it, include the field until the function is called:
+//
+//	ctx, pop := logger.PushContext(ctx, "txn", txnID)
+//	defer pop()
+//
+// Fields follow the flow of execution the context is passed to, so other goroutines only see them if they are given
+// the context. Calling the function more than once has no effect.
+func PushContext(ctx context.Context, key string, value interface{}) (context.Context, func()) {
+	parent, _ := ctx.Value(contextFieldsKey{}).(*contextField)
+	f := &contextField{
+		parent: parent,
+		field: field{
+			key:   key,
+			value: value,
+		},
+	}
+	return context.WithValue(ctx, contextFieldsKey{}, f), func() {
+		atomic.StoreInt32(&f.popped, 1)
+	}
+}
+
+// ErrorContext emits an error message, like Error does, adding the fields pushed to the context with PushContext.
+func (lg *Logger) ErrorContext(ctx context.Context, obj interface{}) {
+	lg.emitFields(contextFields(ctx), LogLevelError, 0, obj)
+}
+
+// WarningContext emits a warning message, like Warning does, adding the fields pushed to the context with
+// PushContext.
+func (lg *Logger) WarningContext(ctx context.Context, obj interface{}) {
+	lg.emitFields(contextFields(ctx), LogLevelWarning, 0, obj)
+}
+
+// InfoContext emits an information message, like Info does, adding the fields pushed to the context with
+// PushContext.
+func (lg *Logger) InfoContext(ctx context.Context, obj interface{}) {
+	lg.emitFields(contextFields(ctx), LogLevelInfo, 0, obj)
+}
+
+// DebugContext emits a debug message, like Debug does, adding the fields pushed to the context with PushContext.
+func (lg *Logger) DebugContext(ctx context.Context, level uint, obj interface{}) {
+	if level > 0 && atomic.LoadInt32(&lg.debugHint) != 0 {
+		lg.notifyDebugSuppressed()
+	}
+	lg.emitFields(contextFields(ctx), LogLevelDebug, level, obj)
+}
+
+type contextFieldsKey struct{}
+
+// contextField is an entry of the stack of fields pushed to a context.
+type contextField struct {
+	parent *contextField
+	field  field
+	popped int32
+}
+
+// contextFields returns the fields pushed to the context and not removed yet, in the order they were pushed.
+func contextFields(ctx context.Context) []field {
+	var fields []field
+	for f, _ := ctx.Value(contextFieldsKey{}).(*contextField); f != nil; f = f.parent {
+		if atomic.LoadInt32(&f.popped) == 0 {
+			fields = append(fields, f.field)
+		}
+	}
+	for i, j := 0, len(fields)-1; i < j; i, j = i+1, j-1 {
+		fields[i], fields[j] = fields[j], fields[i]
+	}
+	return fields
+}
+
+//------------------------------------------------------------------------------
+
+// withDefaultFields returns the default fields, except those overridden by the given fields or by the top-level keys
+// of the JSON message, if any, followed by the given fields.
+func withDefaultFields(defaults []field, fields []field, jsonMsg string) []field {
+	overridden := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		overridden[f.key] = struct{}{}
+	}
+	if len(jsonMsg) > 0 {
+		for key := range jsonTopLevelKeys(jsonMsg) {
+			overridden[key] = struct{}{}
+		}
+	}
+
+	merged := make([]field, 0, len(defaults)+len(fields))
+	for _, f := range defaults {
+		if _, ok := overridden[f.key]; !ok {
+			merged = append(merged, f)
+		}
+	}
+	return append(merged, fields...)
+}
+
+// jsonTopLevelKeys returns the keys of the given JSON object, ignoring those of nested objects.
+func jsonTopLevelKeys(s string) map[string]struct{} {
+	var obj map[string]json.RawMessage
+
+	keys := make(map[string]struct{})
+	if json.Unmarshal([]byte(s), &obj) == nil {
+		for key := range obj {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
+
+func addFieldsToJSON(s string, fields []field) string {
+	if len(fields) == 0 {
+		return s
+	}
+
+	sb := strings.Builder{}
+	for idx, f := range fields {
+		if idx > 0 {
+			_, _ = sb.WriteRune(',')
+		}
+		b, _ := json.Marshal(f.key)
+		_, _ = sb.Write(b)
+		_, _ = sb.WriteRune(':')
+		b, err := json.Marshal(f.value)
+		if err != nil {
+			b, _ = json.Marshal(fmt.Sprint(f.value))
+		}
+		_, _ = sb.Write(b)
+	}
+
+	// Embed fields
+	sep := ""
+	if len(s) != 2 || s[1] != '}' {
+		sep = "," // Add the comma separator if not an empty json object
+	}
+
+	// Return modified string
+	return s[:1] + sb.String() + sep + s[1:]
+}
+
+func addFieldsToText(s string, fields []field) string {
+	if len(fields) == 0 {
+		return s
+	}
+
+	sb := strings.Builder{}
+	_, _ = sb.WriteString(s)
+	for _, f := range fields {
+		_, _ = sb.WriteRune(' ')
+		_, _ = sb.WriteString(f.key)
+		_, _ = sb.WriteRune('=')
+		_, _ = sb.WriteString(formatTextFieldValue(f.value))
+	}
+	return sb.String()
+}
+
+// jsonToKeyValue renders the members of a JSON object as key=value pairs separated by spaces. Members of nested
+// objects get dotted keys, like parent.child=value.
+func jsonToKeyValue(s string) string {
+	sb := strings.Builder{}
+	appendJSONKeyValues(&sb, "", s)
+	return sb.String()
+}
+
+func appendJSONKeyValues(sb *strings.Builder, prefix string, s string) {
+	jsonFields, ok := parseJSONFields(s)
+	if !ok {
+		return
+	}
+	for _, f := range jsonFields {
+		if f.raw[0] == '{' {
+			appendJSONKeyValues(sb, prefix+f.key+".", f.raw)
+			continue
+		}
+		if sb.Len() > 0 {
+			_ = sb.WriteByte(' ')
+		}
+		_, _ = sb.WriteString(prefix + f.key)
+		_ = sb.WriteByte('=')
+		_, _ = sb.WriteString(formatTextFieldValue(f.value))
+	}
+}
+
+func formatTextFieldValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if len(s) == 0 || strings.ContainsAny(s, " \t\r\n\"=") {
+		s = strconv.Quote(s)
+	}
+	return s
+}
@@ -1,21 +1,45 @@
 package go_logger
 
 import (
+	"container/list"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
 //------------------------------------------------------------------------------
 
+const (
+	defaultRetryBufferSize = 1024
+
+	retryNotifyInitialBackoff = 5 * time.Second
+	retryNotifyMaxBackoff     = 5 * time.Minute
+
+	writeRetryBackoff = 10 * time.Millisecond
+
+	defaultRingFileSize = 10 * 1024 * 1024
+)
+
+//------------------------------------------------------------------------------
+
+var fileWriteString = func(fd *os.File, s string) (int, error) {
+	return fd.WriteString(s)
+}
+
+var fileSync = func(fd *os.File) error {
+	return fd.Sync()
+}
+
+//------------------------------------------------------------------------------
+
 // FileOptions specifies the file logger settings to use when it is created.
 type FileOptions struct {
-	// Filename prefix to use when a file is created. Defaults to the binary name.
+	// Filename prefix to use when a file is created. Defaults to the default application name.
 	Prefix string `json:"prefix,omitempty"`
 
 	// Destination directory to store log files.
@@ -24,6 +48,74 @@ type FileOptions struct {
 	// Amount of days to keep old logs.
 	DaysToKeep uint   `json:"daysToKeep,omitempty"`
 
+	// Keep messages that cannot be written in memory and write them once the file becomes writable again. It
+	// also notifies the error handler periodically while the error condition persists. Messages that still cannot
+	// be written when the logger is destroyed are reported to the error handler.
+	RetryOnError bool `json:"retryOnError,omitempty"`
+
+	// Set the maximum amount of messages to keep in memory while writes are failing. Defaults to 1024.
+	RetryBufferSize uint `json:"retryBufferSize,omitempty"`
+
+	// Write to this file name, inside Directory, instead of creating a new file every day. Old files are not
+	// deleted. Use it along with external tools like logrotate, calling Logger.Reopen once the file was rotated.
+	FixedName string `json:"fixedName,omitempty"`
+
+	// Empty the file named FixedName when the logger opens it for the first time, so each run of the process starts
+	// with a fresh log, instead of appending to it. Files opened again, for example by Logger.Reopen, are not emptied.
+	Truncate bool `json:"truncate,omitempty"`
+
+	// Give each run of the process its own files by adding the time the logger was created to the file names, like
+	// prefix.2006-01-02.150405.log. Files are still rotated every day. Ignored if FixedName is set.
+	PerRunFile bool `json:"perRunFile,omitempty"`
+
+	// Write to a ring of this amount of files, named prefix.0.log to prefix.N-1.log, instead of creating a new file
+	// every day. When the current file reaches RingFileSize, the next one is truncated and used, so disk usage is
+	// bounded. The current index is kept in prefix.ring to resume writing on restart. Ignored if FixedName is set.
+	RingFiles uint `json:"ringFiles,omitempty"`
+
+	// Size, in bytes, at which the ring advances to the next file. Defaults to 10 MiB.
+	RingFileSize uint64 `json:"ringFileSize,omitempty"`
+
+	// Also start a new file when the current one grows beyond this size, in bytes, adding an increasing suffix to
+	// its name, like prefix.2006-01-02.1.log. Files are still rotated every day. Zero means no limit. Ignored if
+	// FixedName or RingFiles are set.
+	MaxSizeBytes uint64 `json:"maxSizeBytes,omitempty"`
+
+	// Retry writes failing with transient errors, like EINTR or EAGAIN, up to this amount of times before giving up.
+	// Other errors, like a full disk, are not retried.
+	WriteRetries uint `json:"writeRetries,omitempty"`
+
+	// Hold an exclusive advisory lock on prefix.lock, in Directory, while the logger exists, so another process
+	// using the same directory and prefix fails to create its logger instead of messing with rotation.
+	ExclusiveLock bool `json:"exclusiveLock,omitempty"`
+
+	// Write the process id to prefix.pid, in Directory, when the logger is created and delete it when destroyed. A
+	// file left by a crashed run is replaced but, if the process it names is still running, creation fails.
+	WritePIDFile bool `json:"writePidFile,omitempty"`
+
+	// Also sync the directory after creating a new file, so the file itself is not lost if the system crashes right
+	// after a rotation. It has no effect on platforms not supporting it.
+	SyncDir bool `json:"syncDir,omitempty"`
+
+	// Sync the file to disk after writing messages of this level or a more severe one, so they survive a crash of
+	// the system, while less severe ones are left to the operating system cache. Messages are not buffered by the
+	// logger, so each sync is an fsync call. Nil disables it. Audit messages are always synced.
+	FlushOnLevel *LogLevel `json:"flushOnLevel,omitempty"`
+
+	// Write messages as logfmt lines, like ts=... level=info msg="..." key=value, instead of plain text and JSON.
+	Logfmt bool `json:"logfmt,omitempty"`
+
+	// Also write the messages of a level to a separate file whose name adds the given suffix, like app.errors.log
+	// for LogLevelError and "errors", besides the main file. Level files are rotated like the main one.
+	LevelFiles map[LogLevel]string `json:"levelFiles,omitempty"`
+
+	// Color the level labels using the console theme, for example, to follow the file with tail in a terminal. Colors
+	// are written even if the console does not support them.
+	Color bool `json:"color,omitempty"`
+
+	// Optional callback to call instead of Options.ErrorHandler if an internal error of this target must be notified.
+	ErrorHandler ErrorHandler `json:"-"`
+
 	// Set the initial logging level to use.
 	Level *LogLevel `json:"level,omitempty"`
 
@@ -32,14 +124,41 @@ type FileOptions struct {
 }
 
 type fileAdapter struct {
-	mtx          sync.Mutex
-	fd           *os.File
-	lastWasError int32
-	directory    string
-	daysToKeep   uint
-	prefix       string
-	dayOfFile    int
-	globals      globalOptions
+	nextErrNotify int64 // Keep first for atomic access alignment
+	mtx           sync.Mutex
+	fd            *os.File
+	lastWasError  int32
+	directory     string
+	daysToKeep    uint
+	prefix        string
+	fixedName     string
+	truncate      bool
+	perRun        bool
+	runStart      time.Time
+	ringFiles     uint
+	ringFileSize  uint64
+	ringIndex     uint
+	ringLoaded    bool
+	written       uint64
+	dayOfFile     int
+	maxSize       uint64
+	sizeIndex     uint
+	retryOnError  bool
+	retryQueue    *list.List
+	retryMaxSize  uint
+	writeRetries  uint
+	flushLevel    LogLevel
+	syncDir       bool
+	useLogfmt     bool
+	labels        [5]string
+	levelFiles    map[LogLevel]*fileAdapter
+	pidFile       string
+	lockFile      string
+	nextNotify    time.Time
+	notifyBackoff time.Duration
+	handles       *fileHandleCache
+	handleElem    *list.Element
+	globals       globalOptions
 }
 
 //------------------------------------------------------------------------------
@@ -48,24 +167,52 @@ func createFileAdapter(opts FileOptions, glbOpts globalOptions) (internalLogger,
 	var err error
 
 	if len(opts.Prefix) == 0 {
-		// If no prefix was given, use the base name of the executable.
-		opts.Prefix, err = os.Executable()
+		// If no prefix was given, use the default application name.
+		opts.Prefix, err = getDefaultAppName()
 		if err != nil {
 			return nil, err
 		}
-		opts.Prefix = filepath.Base(opts.Prefix)
-
-		extLen := len(filepath.Ext(opts.Prefix))
-		if len(opts.Prefix) > extLen {
-			opts.Prefix = opts.Prefix[:(len(opts.Prefix) - extLen)]
-		}
 	}
 
 	// Create file adapter
 	lg := &fileAdapter{
-		prefix:    opts.Prefix,
-		dayOfFile: -1,
-		globals:   glbOpts,
+		prefix:       opts.Prefix,
+		fixedName:    opts.FixedName,
+		truncate:     opts.Truncate,
+		perRun:       opts.PerRunFile,
+		runStart:     timeNow(),
+		ringFiles:    opts.RingFiles,
+		ringFileSize: opts.RingFileSize,
+		dayOfFile:    -1,
+		maxSize:      opts.MaxSizeBytes,
+		retryOnError: opts.RetryOnError,
+		retryMaxSize: opts.RetryBufferSize,
+		writeRetries: opts.WriteRetries,
+		flushLevel:   LogLevelQuiet,
+		syncDir:      opts.SyncDir,
+		useLogfmt:    opts.Logfmt,
+		globals:      glbOpts,
+	}
+	if opts.FlushOnLevel != nil {
+		lg.flushLevel = *opts.FlushOnLevel
+	}
+	lg.labels = [5]string{"[ERROR]", "[WARNING]", "[INFO]", "[DEBUG]", "[AUDIT]"}
+	if opts.Color {
+		for idx, label := range lg.labels {
+			lg.labels[idx] = themeLabel(idx, label)
+		}
+	}
+	if lg.ringFiles > 0 && lg.ringFileSize == 0 {
+		lg.ringFileSize = defaultRingFileSize
+	}
+	if glbOpts.MaxOpenFiles > 0 {
+		lg.handles = sharedFileHandles
+	}
+	if lg.retryOnError {
+		lg.retryQueue = list.New()
+		if lg.retryMaxSize == 0 {
+			lg.retryMaxSize = defaultRetryBufferSize
+		}
 	}
 
 	// Set output level based on globals or overrides
@@ -85,40 +232,179 @@ func createFileAdapter(opts FileOptions, glbOpts globalOptions) (internalLogger,
 	}
 
 	// Establishes the target directory
-	if len(opts.Directory) > 0 {
-		lg.directory = filepath.ToSlash(opts.Directory)
-	} else {
-		lg.directory = "logs"
+	lg.directory, err = resolveLogDirectory(opts.Directory)
+	if err != nil {
+		return nil, err
 	}
 
-	if !filepath.IsAbs(lg.directory) {
-		var workingDir string
-
-		workingDir, err = os.Getwd()
+	// Ensure no other process uses the same files
+	if opts.ExclusiveLock {
+		_ = os.MkdirAll(lg.directory, 0755)
+		lockFile := lg.directory + strings.ToLower(lg.prefix) + ".lock"
+		err = acquireLockFile(lockFile)
 		if err != nil {
 			return nil, err
 		}
+		lg.lockFile = lockFile
+	}
 
-		lg.directory = filepath.Join(workingDir, lg.directory)
+	// Delete old files
+	if len(lg.fixedName) == 0 && lg.ringFiles == 0 {
+		lg.cleanOldFiles()
 	}
-	lg.directory = filepath.Clean(lg.directory)
-	if !strings.HasSuffix(lg.directory, string(filepath.Separator)) {
-		lg.directory += string(filepath.Separator)
+
+	// Write the PID file
+	if opts.WritePIDFile {
+		_ = os.MkdirAll(lg.directory, 0755)
+		pidFile := lg.directory + strings.ToLower(lg.prefix) + ".pid"
+		err = acquirePIDFile(pidFile)
+		if err != nil {
+			if len(lg.lockFile) > 0 {
+				releaseLockFile(lg.lockFile)
+			}
+			return nil, err
+		}
+		lg.pidFile = pidFile
 	}
 
-	// Delete old files
-	lg.cleanOldFiles()
+	// Create the level files
+	for level, suffix := range opts.LevelFiles {
+		var levelFile internalLogger
+
+		if level < LogLevelError || level > LogLevelDebug || len(suffix) == 0 {
+			lg.destroy()
+			return nil, fmt.Errorf("invalid level file [%v: %v]", level, suffix)
+		}
+		levelFile, err = createFileAdapter(levelFileOptions(opts, suffix), glbOpts)
+		if err != nil {
+			lg.destroy()
+			return nil, err
+		}
+		if lg.levelFiles == nil {
+			lg.levelFiles = make(map[LogLevel]*fileAdapter)
+		}
+		lg.levelFiles[level] = levelFile.(*fileAdapter)
+		// The main file already filtered the messages
+		lg.levelFiles[level].setLevel(LogLevelDebug, ^uint(0))
+	}
 
 	// Done
 	return lg, nil
 }
 
+// levelFileOptions returns the options of the file storing the messages of a level.
+func levelFileOptions(opts FileOptions, suffix string) FileOptions {
+	levelOpts := opts
+	levelOpts.Prefix = opts.Prefix + "." + suffix
+	if len(opts.FixedName) > 0 {
+		ext := filepath.Ext(opts.FixedName)
+		levelOpts.FixedName = strings.TrimSuffix(opts.FixedName, ext) + "." + suffix + ext
+	}
+	levelOpts.LevelFiles = nil
+	levelOpts.ExclusiveLock = false
+	levelOpts.WritePIDFile = false
+	return levelOpts
+}
+
+// resolveLogDirectory returns the absolute path, ending with a separator, of the directory to store log files.
+func resolveLogDirectory(dir string) (string, error) {
+	if len(dir) > 0 {
+		dir = filepath.ToSlash(dir)
+	} else {
+		dir = "logs"
+	}
+
+	if !filepath.IsAbs(dir) {
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+
+		dir = filepath.Join(workingDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return dir, nil
+}
+
+// fileSinkKey identifies the files written by a file target with the given options, to detect targets writing to the
+// same files.
+func fileSinkKey(opts FileOptions) (string, error) {
+	dir, err := resolveLogDirectory(opts.Directory)
+	if err != nil {
+		return "", err
+	}
+	if len(opts.FixedName) > 0 {
+		return "path:" + dir + opts.FixedName, nil
+	}
+
+	prefix := opts.Prefix
+	if len(prefix) == 0 {
+		prefix, err = getDefaultAppName()
+		if err != nil {
+			return "", err
+		}
+	}
+	return "file:" + dir + strings.ToLower(prefix), nil
+}
+
 func (lg *fileAdapter) class() string {
 	return "file"
 }
 
 func (lg *fileAdapter) destroy() {
+	for _, levelFile := range lg.levelFiles {
+		levelFile.destroy()
+	}
+
+	lost := 0
+	var lostErr error
+
+	lg.mtx.Lock()
+	if lg.handles != nil {
+		lg.handles.remove(lg)
+	}
+	if lg.retryOnError && lg.retryQueue.Len() > 0 {
+		// Try to write the pending messages, opening the file again if needed
+		lostErr = lg.openOrRotateFile(timeNow())
+		if lostErr == nil {
+			lostErr = lg.flushRetryQueue()
+		}
+		lost = lg.retryQueue.Len()
+		lg.retryQueue.Init()
+	}
+	if lg.fd != nil {
+		_ = lg.fd.Sync()
+		_ = lg.fd.Close()
+		lg.fd = nil
+	}
+	if len(lg.pidFile) > 0 {
+		releasePIDFile(lg.pidFile)
+		lg.pidFile = ""
+	}
+	if len(lg.lockFile) > 0 {
+		releaseLockFile(lg.lockFile)
+		lg.lockFile = ""
+	}
+	lg.mtx.Unlock()
+
+	if lost > 0 && lg.globals.ErrorHandler != nil {
+		lg.globals.ErrorHandler(fmt.Sprintf("Unable to save %v pending messages in file [%v]", lost, lostErr))
+	}
+}
+
+// reopen closes the current file so it is opened again, or created if it was moved, on the next write.
+func (lg *fileAdapter) reopen() {
+	for _, levelFile := range lg.levelFiles {
+		levelFile.reopen()
+	}
+
 	lg.mtx.Lock()
+	if lg.handles != nil {
+		lg.handles.remove(lg)
+	}
 	if lg.fd != nil {
 		_ = lg.fd.Sync()
 		_ = lg.fd.Close()
@@ -132,12 +418,60 @@ func (lg *fileAdapter) setLevel(level LogLevel, debugLevel uint) {
 	lg.globals.DebugLevel = debugLevel
 }
 
+func (lg *fileAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *fileAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *fileAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+}
+
+func (lg *fileAdapter) sync() error {
+	var err error
+
+	for _, levelFile := range lg.levelFiles {
+		if levelErr := levelFile.sync(); levelErr != nil {
+			err = levelErr
+		}
+	}
+
+	// Lock access
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	if lg.fd != nil {
+		var fileErr error
+
+		if lg.retryOnError {
+			fileErr = lg.flushRetryQueue()
+		}
+		if fileErr == nil {
+			fileErr = lg.fd.Sync()
+		}
+		if fileErr != nil {
+			err = fileErr
+		}
+	}
+	return err
+}
+
+func (lg *fileAdapter) logfmt() bool {
+	return lg.useLogfmt
+}
+
 func (lg *fileAdapter) logError(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelError {
 		if !raw {
-			lg.write(now, "ERROR", msg)
+			lg.write(now, 0, msg, lg.flushLevel >= LogLevelError)
 		} else {
-			lg.writeRAW(now, msg)
+			lg.writeRAW(now, msg, lg.flushLevel >= LogLevelError)
+		}
+		if levelFile := lg.levelFiles[LogLevelError]; levelFile != nil {
+			levelFile.logError(now, msg, raw)
 		}
 	}
 }
@@ -145,9 +479,12 @@ func (lg *fileAdapter) logError(now time.Time, msg string, raw bool) {
 func (lg *fileAdapter) logWarning(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelWarning {
 		if !raw {
-			lg.write(now, "WARNING", msg)
+			lg.write(now, 1, msg, lg.flushLevel >= LogLevelWarning)
 		} else {
-			lg.writeRAW(now, msg)
+			lg.writeRAW(now, msg, lg.flushLevel >= LogLevelWarning)
+		}
+		if levelFile := lg.levelFiles[LogLevelWarning]; levelFile != nil {
+			levelFile.logWarning(now, msg, raw)
 		}
 	}
 }
@@ -155,9 +492,12 @@ func (lg *fileAdapter) logWarning(now time.Time, msg string, raw bool) {
 func (lg *fileAdapter) logInfo(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelInfo {
 		if !raw {
-			lg.write(now, "INFO", msg)
+			lg.write(now, 2, msg, lg.flushLevel >= LogLevelInfo)
 		} else {
-			lg.writeRAW(now, msg)
+			lg.writeRAW(now, msg, lg.flushLevel >= LogLevelInfo)
+		}
+		if levelFile := lg.levelFiles[LogLevelInfo]; levelFile != nil {
+			levelFile.logInfo(now, msg, raw)
 		}
 	}
 }
@@ -165,50 +505,177 @@ func (lg *fileAdapter) logInfo(now time.Time, msg string, raw bool) {
 func (lg *fileAdapter) logDebug(level uint, now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= level {
 		if !raw {
-			lg.write(now, "DEBUG", msg)
+			lg.write(now, 3, msg, lg.flushLevel >= LogLevelDebug)
 		} else {
-			lg.writeRAW(now, msg)
+			lg.writeRAW(now, msg, lg.flushLevel >= LogLevelDebug)
+		}
+		if levelFile := lg.levelFiles[LogLevelDebug]; levelFile != nil {
+			levelFile.logDebug(level, now, msg, raw)
 		}
 	}
 }
 
-func (lg *fileAdapter) write(now time.Time, level string, msg string) {
+func (lg *fileAdapter) logAudit(now time.Time, msg string, raw bool) {
+	if !raw {
+		lg.writeLine(now, now.Format(lg.globals.TimestampFormat)+" "+lg.labels[4]+": "+msg+newLine, true)
+	} else {
+		lg.writeLine(now, msg+newLine, true)
+	}
+}
+
+func (lg *fileAdapter) write(now time.Time, levelIdx int, msg string, sync bool) {
+	lg.writeLine(now, now.Format(lg.globals.TimestampFormat)+" "+lg.labels[levelIdx]+": "+msg+newLine, sync)
+}
+
+func (lg *fileAdapter) writeRAW(now time.Time, msg string, sync bool) {
+	lg.writeLine(now, msg+newLine, sync)
+}
+
+func (lg *fileAdapter) writeLine(now time.Time, line string, sync bool) {
+	var evicted []*fileAdapter
+
+	renotify := false
+
 	// Lock access
 	lg.mtx.Lock()
 
 	err := lg.openOrRotateFile(now)
+	if err == nil && lg.handles != nil {
+		evicted = lg.handles.touch(lg)
+	}
+	if err == nil && lg.retryOnError {
+		// Write pending messages first to keep them in order
+		err = lg.flushRetryQueue()
+	}
 	if err == nil {
 		// Save message to file
-		_, err = lg.fd.WriteString(now.Format("2006-01-02 15:04:05.000") + " [" + level + "]: " + msg + newLine)
+		err = lg.writeString(line)
+	}
+	if err == nil && sync {
+		err = fileSync(lg.fd)
+	}
+	if lg.retryOnError {
+		if err != nil {
+			lg.queueRetry(line)
+			renotify = lg.shouldRenotify(now)
+		} else {
+			lg.nextNotify = time.Time{}
+		}
 	}
 
 	// Unlock access
 	lg.mtx.Unlock()
 
+	// Close the least recently used files if the limit of open files was reached
+	for _, victim := range evicted {
+		victim.closeEvictedFile()
+	}
+
 	// Handle error
-	lg.handleLoggingError(err)
+	lg.handleLoggingError(err, renotify)
 }
 
-func (lg *fileAdapter) writeRAW(now time.Time, msg string) {
-	// Lock access
+// closeEvictedFile closes the current file if it was evicted from the open files cache. It is reopened on demand.
+func (lg *fileAdapter) closeEvictedFile() {
 	lg.mtx.Lock()
+	// Skip if the file was used again after being evicted
+	if lg.fd != nil && !lg.handles.contains(lg) {
+		_ = lg.fd.Sync()
+		_ = lg.fd.Close()
+		lg.fd = nil
+	}
+	lg.mtx.Unlock()
+}
 
-	err := lg.openOrRotateFile(now)
-	if err == nil {
-		// Save message to file
-		_, err = lg.fd.WriteString(msg + newLine)
+// writeString writes s to the current file retrying, up to writeRetries times, the writes failing with transient
+// errors. Must be called within the lock.
+func (lg *fileAdapter) writeString(s string) error {
+	for attempt := uint(0); ; attempt++ {
+		n, err := fileWriteString(lg.fd, s)
+		lg.written += uint64(n)
+		if err == nil || attempt >= lg.writeRetries || !isRetryableWriteError(err) {
+			return err
+		}
+
+		// Skip the part already written and wait a little longer on each attempt
+		s = s[n:]
+		time.Sleep(time.Duration(attempt+1) * writeRetryBackoff)
+	}
+}
+
+func (lg *fileAdapter) queueRetry(line string) {
+	if uint(lg.retryQueue.Len()) >= lg.retryMaxSize {
+		elem := lg.retryQueue.Front()
+		if elem != nil {
+			lg.retryQueue.Remove(elem)
+		}
 	}
+	lg.retryQueue.PushBack(line)
+}
 
-	// Unlock access
-	lg.mtx.Unlock()
+func (lg *fileAdapter) flushRetryQueue() error {
+	for {
+		elem := lg.retryQueue.Front()
+		if elem == nil {
+			return nil // Reached the end
+		}
 
-	// Handle error
-	lg.handleLoggingError(err)
+		err := lg.writeString(elem.Value.(string))
+		if err != nil {
+			return err
+		}
+		lg.retryQueue.Remove(elem)
+	}
+}
+
+// shouldRenotify returns true if the error handler must be notified again because the error condition persists.
+// The first notification is handled by the lastWasError flag.
+func (lg *fileAdapter) shouldRenotify(now time.Time) bool {
+	if lg.nextNotify.IsZero() {
+		lg.notifyBackoff = retryNotifyInitialBackoff
+		lg.nextNotify = now.Add(lg.notifyBackoff)
+		return false
+	}
+	if now.Before(lg.nextNotify) {
+		return false
+	}
+
+	// Double the interval for the next notification
+	lg.notifyBackoff *= 2
+	if lg.notifyBackoff > retryNotifyMaxBackoff {
+		lg.notifyBackoff = retryNotifyMaxBackoff
+	}
+	lg.nextNotify = now.Add(lg.notifyBackoff)
+	return true
 }
 
 func (lg *fileAdapter) openOrRotateFile(now time.Time) error {
+	// Files with a fixed name are only opened again after a reopen request
+	if len(lg.fixedName) > 0 {
+		if lg.fd == nil {
+			var err error
+
+			flag := 0
+			if lg.truncate {
+				flag = os.O_TRUNC
+			}
+			_ = os.MkdirAll(lg.directory, 0755)
+			lg.fd, err = lg.openLogFile(lg.directory+lg.fixedName, flag)
+			if err != nil {
+				return err
+			}
+			lg.truncate = false
+		}
+		return nil
+	}
+	if lg.ringFiles > 0 {
+		return lg.openOrAdvanceRing()
+	}
+
 	// Check if we have to rotate files
-	if lg.fd == nil || now.Day() != lg.dayOfFile {
+	newDay := now.Day() != lg.dayOfFile
+	full := lg.fd != nil && lg.maxSize > 0 && lg.written >= lg.maxSize
+	if lg.fd == nil || newDay || full {
 		var err error
 
 		if lg.fd != nil {
@@ -217,35 +684,152 @@ func (lg *fileAdapter) openOrRotateFile(now time.Time) error {
 			lg.fd = nil
 		}
 
-		// Delete old files
-		lg.cleanOldFiles()
+		// Delete old files unless we are just reopening a file closed by the open files cache
+		if newDay {
+			lg.cleanOldFiles()
+			lg.sizeIndex = 0
+		} else if full {
+			lg.sizeIndex++
+		}
 
 		// Create target directory if it does not exist
 		_ = os.MkdirAll(lg.directory, 0755)
 
-		filename := lg.directory + strings.ToLower(lg.prefix) + "." + now.Format("2006-01-02") + ".log"
+		for {
+			// Create a new log file
+			lg.fd, err = lg.openLogFile(lg.dailyFilename(now), 0)
+			if err != nil {
+				return err
+			}
+
+			lg.written = 0
+			if lg.maxSize == 0 {
+				break
+			}
+			fi, statErr := lg.fd.Stat()
+			if statErr == nil {
+				lg.written = uint64(fi.Size())
+			}
+
+			// Skip the files filled by a previous run
+			if lg.written < lg.maxSize {
+				break
+			}
+			_ = lg.fd.Close()
+			lg.fd = nil
+			lg.sizeIndex++
+		}
+
+		lg.dayOfFile = now.Day()
+	}
+
+	// Done
+	return nil
+}
+
+// dailyFilename returns the name of the file to write at the given time.
+func (lg *fileAdapter) dailyFilename(now time.Time) string {
+	filename := lg.directory + strings.ToLower(lg.prefix) + "." + now.Format("2006-01-02")
+	if lg.perRun {
+		filename += "." + lg.runStart.In(now.Location()).Format("150405")
+	}
+	if lg.sizeIndex > 0 {
+		filename += "." + strconv.FormatUint(uint64(lg.sizeIndex), 10)
+	}
+	return filename + ".log"
+}
+
+// openLogFile opens the given log file for appending, creating it if it does not exist. If it is created and the
+// directory must be synced, failing to do so is notified but the file is still used.
+func (lg *fileAdapter) openLogFile(filename string, flag int) (*os.File, error) {
+	created := false
+	if lg.syncDir {
+		_, err := os.Stat(filename)
+		created = os.IsNotExist(err)
+	}
+
+	fd, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE|flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		err = syncDirectory(filepath.Dir(filename))
+		if err != nil && lg.globals.ErrorHandler != nil {
+			lg.globals.ErrorHandler(fmt.Sprintf("Unable to sync log directory [%v]", err))
+		}
+	}
+	return fd, nil
+}
+
+// openOrAdvanceRing opens the current file of the ring and, if it is full, moves to the next one truncating it.
+func (lg *fileAdapter) openOrAdvanceRing() error {
+	var err error
+
+	if lg.fd == nil {
+		_ = os.MkdirAll(lg.directory, 0755)
+
+		// Resume from the file being written by a previous run
+		if !lg.ringLoaded {
+			var content []byte
+
+			content, err = os.ReadFile(lg.ringIndexFilename())
+			if err == nil {
+				index, parseErr := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 32)
+				if parseErr == nil && uint(index) < lg.ringFiles {
+					lg.ringIndex = uint(index)
+				}
+			}
+			lg.ringLoaded = true
+		}
 
-		// Create a new log file
-		lg.fd, err = os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		lg.fd, err = lg.openLogFile(lg.ringFilename(), 0)
 		if err != nil {
 			return err
 		}
 
-		lg.dayOfFile = now.Day()
+		lg.written = 0
+		fi, statErr := lg.fd.Stat()
+		if statErr == nil {
+			lg.written = uint64(fi.Size())
+		}
+	}
+
+	if lg.written >= lg.ringFileSize {
+		_ = lg.fd.Sync()
+		_ = lg.fd.Close()
+		lg.fd = nil
+
+		lg.ringIndex = (lg.ringIndex + 1) % lg.ringFiles
+		lg.fd, err = lg.openLogFile(lg.ringFilename(), os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+		lg.written = 0
+
+		err = os.WriteFile(lg.ringIndexFilename(), []byte(strconv.FormatUint(uint64(lg.ringIndex), 10)), 0644)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Done
 	return nil
 }
 
-func (lg *fileAdapter) handleLoggingError(err error) {
+func (lg *fileAdapter) ringFilename() string {
+	return lg.directory + strings.ToLower(lg.prefix) + "." + strconv.FormatUint(uint64(lg.ringIndex), 10) + ".log"
+}
+
+func (lg *fileAdapter) ringIndexFilename() string {
+	return lg.directory + strings.ToLower(lg.prefix) + ".ring"
+}
+
+func (lg *fileAdapter) handleLoggingError(err error, renotify bool) {
 	// Handle error
-	if err == nil {
-		atomic.StoreInt32(&lg.lastWasError, 0)
-	} else {
-		if atomic.CompareAndSwapInt32(&lg.lastWasError, 0, 1) && lg.globals.ErrorHandler != nil {
-			lg.globals.ErrorHandler(fmt.Sprintf("Unable to save notification in file [%v]", err))
-		}
+	notify := lg.globals.shouldNotifyError(err, &lg.lastWasError, &lg.nextErrNotify)
+	if (notify || renotify) && lg.globals.ErrorHandler != nil {
+		lg.globals.ErrorHandler(fmt.Sprintf("Unable to save notification in file [%v]", err))
 	}
 }
 
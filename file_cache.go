@@ -0,0 +1,82 @@
+package go_logger
+
+import (
+	"container/list"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// fileHandleCache limits the amount of log files kept open at the same time by closing the least recently used
+// ones. It is shared by all the file adapters created with the MaxOpenFiles option.
+type fileHandleCache struct {
+	mtx     sync.Mutex
+	maxOpen int
+	lru     *list.List
+}
+
+//------------------------------------------------------------------------------
+
+var sharedFileHandles = &fileHandleCache{
+	lru: list.New(),
+}
+
+//------------------------------------------------------------------------------
+
+// setLimit sets the maximum amount of open files unless a stricter limit was already set.
+func (c *fileHandleCache) setLimit(maxOpen int) {
+	c.mtx.Lock()
+	if c.maxOpen == 0 || maxOpen < c.maxOpen {
+		c.maxOpen = maxOpen
+	}
+	c.mtx.Unlock()
+}
+
+// touch marks the file of the given adapter as the most recently used one and returns the adapters whose files must
+// be closed to honor the limit. The caller must hold the adapter lock and close the returned ones after releasing it.
+func (c *fileHandleCache) touch(lg *fileAdapter) []*fileAdapter {
+	var evicted []*fileAdapter
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if lg.handleElem != nil {
+		c.lru.MoveToFront(lg.handleElem)
+	} else {
+		lg.handleElem = c.lru.PushFront(lg)
+	}
+
+	for c.maxOpen > 0 && c.lru.Len() > c.maxOpen {
+		elem := c.lru.Back()
+		victim := elem.Value.(*fileAdapter)
+		c.lru.Remove(elem)
+		victim.handleElem = nil
+		evicted = append(evicted, victim)
+	}
+
+	// Done
+	return evicted
+}
+
+func (c *fileHandleCache) remove(lg *fileAdapter) {
+	c.mtx.Lock()
+	if lg.handleElem != nil {
+		c.lru.Remove(lg.handleElem)
+		lg.handleElem = nil
+	}
+	c.mtx.Unlock()
+}
+
+func (c *fileHandleCache) contains(lg *fileAdapter) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return lg.handleElem != nil
+}
+
+func (c *fileHandleCache) count() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.lru.Len()
+}
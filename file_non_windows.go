@@ -1,9 +0,0 @@
-//go:build !windows
-
-package go_logger
-
-//------------------------------------------------------------------------------
-
-const (
-	newLine = "\n"
-)
@@ -0,0 +1,41 @@
+//go:build !windows && !plan9
+
+package go_logger
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	newLine = "\n"
+)
+
+//------------------------------------------------------------------------------
+
+// syncDirectory flushes the entries of the given directory to disk. File systems not supporting it are ignored.
+func syncDirectory(dir string) error {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = fd.Sync()
+	_ = fd.Close()
+	if err != nil && errors.Is(err, syscall.EINVAL) {
+		err = nil
+	}
+	return err
+}
+
+// processExists returns true if a process with the given id is running.
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
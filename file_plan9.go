@@ -1,14 +1,37 @@
 package go_logger
 
 import (
+	"errors"
 	"os"
+	"strconv"
 	"syscall"
 	"time"
 )
 
 //------------------------------------------------------------------------------
 
+const (
+	newLine = "\n"
+)
+
+//------------------------------------------------------------------------------
+
 func getFileCreationTime(fi os.FileInfo) time.Time {
 	stat := fi.Sys().(*syscall.Dir)
 	return time.Unix(int64(stat.Mtime), 0)
 }
+
+func isRetryableWriteError(err error) bool {
+	return errors.Is(err, syscall.EINTR)
+}
+
+// syncDirectory does nothing because directories cannot be synced on Plan 9.
+func syncDirectory(_ string) error {
+	return nil
+}
+
+// processExists returns true if a process with the given id is running.
+func processExists(pid int) bool {
+	_, err := os.Stat("/proc/" + strconv.Itoa(pid))
+	return err == nil
+}
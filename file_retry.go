@@ -0,0 +1,14 @@
+//go:build !plan9
+
+package go_logger
+
+import (
+	"errors"
+	"syscall"
+)
+
+//------------------------------------------------------------------------------
+
+func isRetryableWriteError(err error) bool {
+	return errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.EAGAIN)
+}
@@ -18,3 +18,18 @@ func getFileCreationTime(fi os.FileInfo) time.Time {
 	stat := fi.Sys().(*syscall.Win32FileAttributeData)
 	return time.Unix(0, stat.CreationTime.Nanoseconds())
 }
+
+// syncDirectory does nothing because directories cannot be synced on Windows.
+func syncDirectory(_ string) error {
+	return nil
+}
+
+// processExists returns true if a process with the given id is running.
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
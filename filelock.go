@@ -0,0 +1,70 @@
+package go_logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// lockFiles keeps the lock files held by this process, counting the file targets using each one, so a logger being
+// reconfigured does not conflict with itself.
+var lockFiles = struct {
+	mtx   sync.Mutex
+	files map[string]*heldLockFile
+}{
+	files: make(map[string]*heldLockFile),
+}
+
+type heldLockFile struct {
+	fd   *os.File
+	refs int
+}
+
+//------------------------------------------------------------------------------
+
+// acquireLockFile takes an exclusive advisory lock on the given file, creating it if needed. It fails right away if
+// another process holds it.
+func acquireLockFile(filename string) error {
+	lockFiles.mtx.Lock()
+	defer lockFiles.mtx.Unlock()
+
+	if held, ok := lockFiles.files[filename]; ok {
+		held.refs += 1
+		return nil
+	}
+
+	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	err = lockFile(fd)
+	if err != nil {
+		_ = fd.Close()
+		return fmt.Errorf("log files are in use by another process, unable to lock %v [%w]", filename, err)
+	}
+
+	lockFiles.files[filename] = &heldLockFile{
+		fd:   fd,
+		refs: 1,
+	}
+	return nil
+}
+
+// releaseLockFile releases the lock once no target uses it. The file is kept to avoid races with other processes
+// opening it.
+func releaseLockFile(filename string) {
+	lockFiles.mtx.Lock()
+	defer lockFiles.mtx.Unlock()
+
+	held, ok := lockFiles.files[filename]
+	if !ok {
+		return
+	}
+	held.refs -= 1
+	if held.refs <= 0 {
+		delete(lockFiles.files, filename)
+		_ = held.fd.Close()
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package go_logger
+
+import (
+	"errors"
+	"os"
+)
+
+//------------------------------------------------------------------------------
+
+func lockFile(_ *os.File) error {
+	return errors.New("file locking is not supported on this platform")
+}
@@ -0,0 +1,14 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package go_logger
+
+import (
+	"os"
+	"syscall"
+)
+
+//------------------------------------------------------------------------------
+
+func lockFile(fd *os.File) error {
+	return syscall.Flock(int(fd.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
@@ -0,0 +1,28 @@
+package go_logger
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+//------------------------------------------------------------------------------
+
+func lockFile(fd *os.File) error {
+	ol := syscall.Overlapped{}
+	r1, _, err := procLockFileEx.Call(fd.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0,
+		uintptr(unsafe.Pointer(&ol)))
+	if r1 == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package go_logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+//------------------------------------------------------------------------------
+
+// jsonObject is a JSON object whose members are marshaled in the order they were added, like struct fields.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+//------------------------------------------------------------------------------
+
+// marshalFriendly marshals a struct like json.Marshal does but with durations as strings, like "1.5s", and times
+// formatted with the given layout.
+func marshalFriendly(obj interface{}, timeLayout string) ([]byte, error) {
+	return json.Marshal(friendlyValue(reflect.ValueOf(obj), timeLayout))
+}
+
+func friendlyValue(v reflect.Value, timeLayout string) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String()
+	case timeType:
+		if epoch, ok := epochTimestamp(v.Interface().(time.Time), timeLayout); ok {
+			return epoch
+		}
+		return v.Interface().(time.Time).Format(timeLayout)
+	}
+	// Respect custom marshalers
+	if v.Type().Implements(jsonMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return friendlyValue(v.Elem(), timeLayout)
+
+	case reflect.Struct:
+		return friendlyStruct(v, timeLayout, make(jsonObject, 0, v.NumField()))
+
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		items := make([]interface{}, v.Len())
+		for idx := range items {
+			items[idx] = friendlyValue(v.Index(idx), timeLayout)
+		}
+		return items
+
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		items := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			items[iter.Key().String()] = friendlyValue(iter.Value(), timeLayout)
+		}
+		return items
+	}
+
+	return v.Interface()
+}
+
+// friendlyStruct adds the fields of a struct to obj following the json tags. Fields of embedded structs are promoted.
+func friendlyStruct(v reflect.Value, timeLayout string, obj jsonObject) jsonObject {
+	t := v.Type()
+	for idx := 0; idx < t.NumField(); idx++ {
+		sf := t.Field(idx)
+
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fv := v.Field(idx)
+		if sf.Anonymous && len(name) == 0 && fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			obj = friendlyStruct(fv, timeLayout, obj)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyValue(fv) {
+			continue
+		}
+		if len(name) == 0 {
+			name = sf.Name
+		}
+
+		obj = append(obj, jsonMember{
+			key:   name,
+			value: friendlyValue(fv, timeLayout),
+		})
+	}
+	return obj
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for idx, member := range o {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(member.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
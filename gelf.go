@@ -0,0 +1,359 @@
+package go_logger
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+// GELFOptions specifies the Graylog Extended Log Format settings to use when it is created.
+type GELFOptions struct {
+	// GELF input host name. Defaults to 127.0.0.1.
+	Host string `json:"host,omitempty"`
+
+	// GELF input port. Defaults to 12201.
+	Port uint16 `json:"port,omitempty"`
+
+	// Use TCP instead of UDP. Messages are delimited by a null byte.
+	UseTcp bool `json:"useTcp,omitempty"`
+
+	// Maximum size of an UDP datagram. Larger messages are split in chunks. Defaults to 1420 bytes.
+	MaxChunkSize uint `json:"maxChunkSize,omitempty"`
+
+	// Host name to send in the messages. Defaults to the computer's host name.
+	Hostname string `json:"hostname,omitempty"`
+
+	// Set the initial logging level to use.
+	Level *LogLevel `json:"level,omitempty"`
+
+	// Set the initial logging level for debug output to use.
+	DebugLevel *uint `json:"debugLevel,omitempty"`
+}
+
+type gelfAdapter struct {
+	nextErrNotify int64 // Keep first for atomic access alignment
+	mtx           sync.Mutex
+	conn          net.Conn
+	lastWasError  int32
+	serverAddress string
+	useTcp        bool
+	maxChunkSize  int
+	hostname      string
+	dialer        *net.Dialer
+	globals       globalOptions
+}
+
+//------------------------------------------------------------------------------
+
+func createGELFAdapter(opts GELFOptions, glbOpts globalOptions) (internalLogger, error) {
+	// Create GELF adapter
+	lg := &gelfAdapter{
+		useTcp:       opts.UseTcp,
+		maxChunkSize: int(opts.MaxChunkSize),
+		hostname:     opts.Hostname,
+		dialer: &net.Dialer{
+			Timeout: 10 * time.Second,
+		},
+		globals: glbOpts,
+	}
+
+	// Set output level based on globals or overrides
+	if opts.Level != nil {
+		lg.globals.Level = *opts.Level
+		lg.globals.DebugLevel = 1
+	}
+	if opts.DebugLevel != nil {
+		lg.globals.DebugLevel = *opts.DebugLevel
+	}
+
+	// Set the server address
+	if len(opts.Host) > 0 {
+		lg.serverAddress = opts.Host
+	} else {
+		lg.serverAddress = "127.0.0.1"
+	}
+	port := opts.Port
+	if port == 0 {
+		port = 12201
+	}
+	lg.serverAddress += ":" + strconv.Itoa(int(port))
+
+	// Set the chunk size
+	if lg.maxChunkSize == 0 {
+		lg.maxChunkSize = 1420
+	} else if lg.maxChunkSize <= gelfChunkHeaderSize {
+		return nil, errors.New("invalid GELF chunk size")
+	}
+
+	// Set the client host name
+	if len(lg.hostname) == 0 {
+		var err error
+
+		lg.hostname, err = os.Hostname()
+		if err != nil {
+			lg.hostname = "localhost"
+		}
+	}
+
+	// Done
+	return lg, nil
+}
+
+func (lg *gelfAdapter) class() string {
+	return "gelf"
+}
+
+func (lg *gelfAdapter) destroy() {
+	lg.mtx.Lock()
+	lg.disconnect()
+	lg.mtx.Unlock()
+}
+
+func (lg *gelfAdapter) setLevel(level LogLevel, debugLevel uint) {
+	lg.globals.Level = level
+	lg.globals.DebugLevel = debugLevel
+}
+
+func (lg *gelfAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *gelfAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *gelfAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+}
+
+func (lg *gelfAdapter) sync() error {
+	// Messages are sent synchronously
+	return nil
+}
+
+func (lg *gelfAdapter) logError(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelError {
+		lg.send(SeverityError, now, msg, raw)
+	}
+}
+
+func (lg *gelfAdapter) logWarning(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelWarning {
+		lg.send(SeverityWarning, now, msg, raw)
+	}
+}
+
+func (lg *gelfAdapter) logInfo(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelInfo {
+		lg.send(SeverityInformational, now, msg, raw)
+	}
+}
+
+func (lg *gelfAdapter) logDebug(level uint, now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= level {
+		lg.send(SeverityDebug, now, msg, raw)
+	}
+}
+
+func (lg *gelfAdapter) logAudit(now time.Time, msg string, raw bool) {
+	lg.send(SeverityNotice, now, msg, raw)
+}
+
+func (lg *gelfAdapter) send(severity Severity, now time.Time, msg string, raw bool) {
+	var packets [][]byte
+
+	b, err := formatGELF(lg.hostname, severity, now, msg, raw)
+	if err == nil {
+		if lg.useTcp {
+			packets = [][]byte{append(b, 0)}
+		} else {
+			packets, err = chunkGELF(b, lg.maxChunkSize)
+		}
+	}
+
+	if err == nil {
+		lg.mtx.Lock()
+		err = lg.writePackets(packets)
+		lg.mtx.Unlock()
+	}
+
+	// Handle error
+	if lg.globals.shouldNotifyError(err, &lg.lastWasError, &lg.nextErrNotify) && lg.globals.ErrorHandler != nil {
+		lg.globals.ErrorHandler(fmt.Sprintf("Unable to deliver notification to GELF [%v]", err))
+	}
+}
+
+func (lg *gelfAdapter) connect() error {
+	var err error
+
+	lg.disconnect()
+
+	if lg.useTcp {
+		lg.conn, err = lg.dialer.Dial("tcp", lg.serverAddress)
+	} else {
+		lg.conn, err = lg.dialer.Dial("udp", lg.serverAddress)
+	}
+	return err
+}
+
+func (lg *gelfAdapter) disconnect() {
+	if lg.conn != nil {
+		_ = lg.conn.Close()
+		lg.conn = nil
+	}
+}
+
+func (lg *gelfAdapter) writePackets(packets [][]byte) error {
+	var err error
+
+	// Send the message if connected
+	if lg.conn != nil {
+		err = writeAllPackets(lg.conn, packets)
+		if err == nil {
+			return nil
+		}
+	}
+
+	// On error or if disconnected, try to connect
+	err = lg.connect()
+	if err == nil {
+		err = writeAllPackets(lg.conn, packets)
+		if err != nil {
+			lg.disconnect()
+		}
+	}
+
+	// Done
+	return err
+}
+
+func writeAllPackets(conn net.Conn, packets [][]byte) error {
+	for _, p := range packets {
+		if _, err := conn.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatGELF converts a message into a GELF 1.1 payload. The message field of JSON messages becomes the short message
+// and the rest of the fields become additional fields.
+func formatGELF(hostname string, severity Severity, now time.Time, msg string, raw bool) ([]byte, error) {
+	ms := now.UnixNano() / int64(time.Millisecond)
+	timestamp := json.RawMessage(fmt.Sprintf("%d.%03d", ms/1000, ms%1000))
+
+	obj := jsonObject{
+		{key: "version", value: "1.1"},
+		{key: "host", value: hostname},
+	}
+
+	text := msg
+	var extra jsonObject
+	if raw {
+		if fields, ok := parseJSONFields(msg); ok {
+			text = ""
+			for _, f := range fields {
+				switch f.key {
+				case "message":
+					text = f.value
+				case "level", "timestamp":
+					// Already represented by the GELF level and timestamp
+				default:
+					key := gelfKey(f.key)
+					if len(key) == 0 {
+						continue
+					}
+					member := jsonMember{key: key, value: f.value}
+					if c := f.raw[0]; c == '-' || (c >= '0' && c <= '9') {
+						// Keep numbers as numbers, other values are sent as strings
+						member.value = json.RawMessage(f.raw)
+					}
+					extra = append(extra, member)
+				}
+			}
+			if len(text) == 0 {
+				text = msg
+			}
+		}
+	}
+
+	text = strings.TrimRight(text, "\r\n")
+	if idx := strings.IndexAny(text, "\r\n"); idx >= 0 {
+		obj = append(obj, jsonMember{key: "short_message", value: text[:idx]})
+		obj = append(obj, jsonMember{key: "full_message", value: text})
+	} else {
+		obj = append(obj, jsonMember{key: "short_message", value: text})
+	}
+	obj = append(obj, jsonMember{key: "timestamp", value: timestamp})
+	obj = append(obj, jsonMember{key: "level", value: int(severity)})
+	obj = append(obj, extra...)
+
+	return json.Marshal(obj)
+}
+
+// gelfKey converts a field name into a GELF additional field name.
+func gelfKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '.' ||
+			r == '-' {
+			return r
+		}
+		return -1
+	}, key)
+	if len(key) == 0 {
+		return ""
+	}
+	if key == "id" {
+		// The _id field is reserved
+		return "_id_"
+	}
+	return "_" + key
+}
+
+// chunkGELF splits a GELF payload into datagrams of up to maxSize bytes.
+func chunkGELF(b []byte, maxSize int) ([][]byte, error) {
+	if len(b) <= maxSize {
+		return [][]byte{b}, nil
+	}
+
+	chunkSize := maxSize - gelfChunkHeaderSize
+	count := (len(b) + chunkSize - 1) / chunkSize
+	if count > gelfMaxChunks {
+		return nil, errors.New("message too large")
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		part := b[seq*chunkSize:]
+		if len(part) > chunkSize {
+			part = part[:chunkSize]
+		}
+
+		chunk := make([]byte, 0, gelfChunkHeaderSize+len(part))
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunk = append(chunk, part...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
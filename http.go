@@ -0,0 +1,192 @@
+package go_logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+type httpRequestMessage struct {
+	Message    string  `json:"message"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+	Bytes      int64   `json:"bytes"`
+}
+
+type levelHTTPState struct {
+	Level      string `json:"level"`
+	DebugLevel uint   `json:"debugLevel"`
+}
+
+type levelHTTPRequest struct {
+	Class      string `json:"class"`
+	Level      string `json:"level"`
+	DebugLevel *uint  `json:"debugLevel"`
+}
+
+type httpResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+//------------------------------------------------------------------------------
+
+// HTTPHandler returns a middleware that logs the method, path, response status, duration and response size of each
+// request handled by next. Requests are logged at information level, or error level if the status is 5xx, using the
+// JSON format.
+func (lg *Logger) HTTPHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rw := &httpResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		next.ServeHTTP(rw, req)
+
+		msg := httpRequestMessage{
+			Message:    "HTTP request",
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Status:     rw.status,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			Bytes:      rw.bytes,
+		}
+		if rw.status >= 500 {
+			lg.Error(msg)
+		} else {
+			lg.Info(msg)
+		}
+	})
+}
+
+// LevelHTTPHandler returns a handler to query and change the logging levels at runtime, for example, from an
+// administration endpoint. GET returns the levels of each target class as a JSON object. PUT and POST set the level
+// of a class, or of all of them if the class is empty or "all", from a JSON body like:
+//
+//	{"class": "file", "level": "debug", "debugLevel": 2}
+//
+// Valid levels are quiet, error, warning, info and debug. The debug level defaults to 1 for the debug level and to
+// 0 for the rest. Invalid requests are answered with 400 Bad Request.
+func (lg *Logger) LevelHTTPHandler() http.Handler {
+	if lg.parent != nil {
+		return lg.parent.LevelHTTPHandler()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			// Nothing to change
+
+		case http.MethodPut, http.MethodPost:
+			var body levelHTTPRequest
+
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid request body [%v]", err), http.StatusBadRequest)
+				return
+			}
+			level, ok := parseLevelName(body.Level)
+			if !ok {
+				http.Error(w, fmt.Sprintf("invalid level [%v]", body.Level), http.StatusBadRequest)
+				return
+			}
+			if _, _, ok = lg.GetLevel(body.Class); !ok {
+				http.Error(w, fmt.Sprintf("invalid class [%v]", body.Class), http.StatusBadRequest)
+				return
+			}
+			debugLevel := uint(0)
+			if body.DebugLevel != nil {
+				debugLevel = *body.DebugLevel
+			} else if level == LogLevelDebug {
+				debugLevel = 1
+			}
+			lg.SetLevel(level, debugLevel, body.Class)
+
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Reply with the current levels
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(lg.levelStates())
+	})
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func (lg *Logger) levelStates() map[string]levelHTTPState {
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
+
+	states := make(map[string]levelHTTPState)
+	for _, adapter := range lg.adapters {
+		if _, ok := states[adapter.class()]; !ok {
+			level, debugLevel := adapter.getLevel()
+			name := "quiet"
+			if level > LogLevelQuiet {
+				name = levelName(level)
+			}
+			states[adapter.class()] = levelHTTPState{
+				Level:      name,
+				DebugLevel: debugLevel,
+			}
+		}
+	}
+	return states
+}
+
+func parseLevelName(name string) (LogLevel, bool) {
+	switch name {
+	case "quiet":
+		return LogLevelQuiet, true
+	case "error":
+		return LogLevelError, true
+	case "warning":
+		return LogLevelWarning, true
+	case "info":
+		return LogLevelInfo, true
+	case "debug":
+		return LogLevelDebug, true
+	}
+	return LogLevelQuiet, false
+}
+
+func (w *httpResponseWriter) WriteHeader(status int) {
+	// Only the first call takes effect
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *httpResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client if the underlying writer supports it.
+func (w *httpResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer so http.ResponseController can reach it.
+func (w *httpResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -12,9 +12,50 @@ type internalLogger interface {
 	//NOTE: Called within an exclusive lock
 	setLevel(level LogLevel, debugLevel uint)
 
+	//NOTE: Called within a shared lock
+	getLevel() (LogLevel, uint)
+
+	//NOTE: Called within a shared lock
+	enabled(level LogLevel, debugLevel uint) bool
+
+	//NOTE: Called within a shared lock
+	setEnabled(enabled bool)
+
 	//NOTE: Called within a shared lock
 	logError(now time.Time, msg string, raw bool)
 	logWarning(now time.Time, msg string, raw bool)
 	logInfo(now time.Time, msg string, raw bool)
 	logDebug(level uint, now time.Time, msg string, raw bool)
+
+	//NOTE: Called within a shared lock. Must not return until the message is durably stored or delivered.
+	logAudit(now time.Time, msg string, raw bool)
+
+	//NOTE: Called within a shared lock. Must not return until previous messages are durably stored or delivered.
+	sync() error
+}
+
+// internalQueue is implemented by adapters delivering messages in the background.
+type internalQueue interface {
+	//NOTE: Called within a shared lock. Returns true if there are no queued or in-flight messages.
+	idle() bool
+}
+
+// internalLogfmtLogger is implemented by adapters that can write messages as logfmt lines.
+type internalLogfmtLogger interface {
+	//NOTE: Called within a shared lock. Returns true if messages must be sent as logfmt lines, flagged as raw.
+	logfmt() bool
+}
+
+// internalNamedLogger is implemented by adapters that route the messages of named loggers differently. If
+// implemented, it is called instead of the per-level methods for messages sent through a named logger.
+type internalNamedLogger interface {
+	//NOTE: Called within a shared lock
+	logNamed(name string, level LogLevel, debugLevel uint, now time.Time, msg string, raw bool)
+}
+
+// internalObjectLogger is implemented by adapters that also want to receive the original logged object. If
+// implemented, it is called instead of the per-level methods.
+type internalObjectLogger interface {
+	//NOTE: Called within a shared lock
+	logObject(level LogLevel, debugLevel uint, now time.Time, msg string, raw bool, obj interface{})
 }
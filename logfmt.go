@@ -0,0 +1,86 @@
+package go_logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// formatLogfmt returns a logfmt line with the core fields, ts, level and msg, first and then the given fields in
+// order.
+func formatLogfmt(ts string, level string, msg string, fields []field) string {
+	sb := strings.Builder{}
+	sb.WriteString("ts=" + logfmtValue(ts))
+	sb.WriteString(" level=" + logfmtValue(level))
+	sb.WriteString(" msg=" + logfmtValue(msg))
+	for _, f := range fields {
+		key := logfmtKey(f.key)
+		if len(key) > 0 {
+			sb.WriteString(" " + key + "=" + logfmtValue(fmt.Sprint(f.value)))
+		}
+	}
+	return sb.String()
+}
+
+// jsonToLogfmt converts a JSON message, already including the timestamp and level fields, into a logfmt line. The
+// rest of the members keep the order they have in the JSON object. Nested objects and arrays become compact JSON
+// values.
+func jsonToLogfmt(msg string) string {
+	jsonFields, ok := parseJSONFields(msg)
+	if !ok {
+		return formatLogfmt("", "", msg, nil)
+	}
+
+	var ts, level, message string
+	fields := make([]field, 0, len(jsonFields))
+	for _, f := range jsonFields {
+		switch f.key {
+		case "timestamp":
+			ts = f.value
+		case "level":
+			level = f.value
+		case "message":
+			message = f.value
+		default:
+			fields = append(fields, field{
+				key:   f.key,
+				value: f.value,
+			})
+		}
+	}
+	return formatLogfmt(ts, level, message, fields)
+}
+
+// logfmtLevel returns the level as encoded in JSON messages, without quotes.
+func logfmtLevel(level LogLevel, encoder JSONLevelEncoder) string {
+	s := encodeJSONLevel(level, encoder)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+// logfmtKey replaces the characters not allowed in logfmt keys.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7F {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue quotes the value if empty or if it contains spaces, quotes, equal signs or control characters.
+func logfmtValue(s string) string {
+	if len(s) == 0 {
+		return `""`
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7F {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
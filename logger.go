@@ -1,7 +1,12 @@
 package go_logger
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 //------------------------------------------------------------------------------
@@ -15,16 +20,97 @@ const (
 	LogLevelWarning LogLevel = 2
 	LogLevelInfo    LogLevel = 3
 	LogLevelDebug   LogLevel = 4
+
+	// logLevelAudit is used internally to dispatch audit messages.
+	logLevelAudit LogLevel = 255
+)
+
+const (
+	// DefaultJSONTimestampFormat is the RFC 3339 layout, with milliseconds, used for the timestamp field of
+	// JSON messages.
+	DefaultJSONTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+	// LegacyJSONTimestampFormat is the layout used for the timestamp field of JSON messages by older versions.
+	LegacyJSONTimestampFormat = "2006-01-02 15:04:05.000"
+
+	// JSONTimestampUnix, JSONTimestampUnixMilli and JSONTimestampUnixNano are special values of JSONTimestampFormat
+	// to write the timestamp field as a number of seconds, milliseconds or nanoseconds since the Unix epoch.
+	JSONTimestampUnix      = "unix"
+	JSONTimestampUnixMilli = "unixmilli"
+	JSONTimestampUnixNano  = "unixnano"
+
+	textTimestampFormat = "2006-01-02 15:04:05.000"
+
+	deliveryPollInterval = 5 * time.Millisecond
+)
+
+// TimePrecision defines the amount of fractional second digits of timestamps.
+type TimePrecision string
+
+const (
+	TimePrecisionSecond TimePrecision = "second"
+	TimePrecisionMilli  TimePrecision = "milli"
+	TimePrecisionMicro  TimePrecision = "micro"
+	TimePrecisionNano   TimePrecision = "nano"
+)
+
+// StructTextMode defines how structs passed as messages are rendered.
+type StructTextMode string
+
+const (
+	StructTextModeJSON     StructTextMode = "json"
+	StructTextModeKeyValue StructTextMode = "keyvalue"
 )
 
 // Logger is the object that controls logging.
 type Logger struct {
+	seq            uint64 // Keep first for atomic access alignment
+	scheduleUntil  int64
 	mtx            sync.RWMutex
 	//level          LogLevel
 	//debugLevel     uint
 	//disableConsole bool
 	adapters       []internalLogger
+	captures       []internalLogger
+	subscribers    []*subscriber
 	useLocalTime   bool
+	swallowPanics  bool
+	includeSeq     bool
+	warnDestroyed  bool
+	maxPayload     int
+	textScalars    bool
+	skipEmpty      bool
+	includeFunc    bool
+	hostname       string
+	headTail       *HeadTailOptions
+	friendlyDur    bool
+	destroyed      bool
+	destroyWarned  int32
+	jsonTsFormat   string
+	textTsFormat   string
+	jsonLevelEnc   JSONLevelEncoder
+	fields         []field
+	defaultFields  []field
+	numGoroutine   bool
+	debugCats      map[string]struct{}
+	errorHandler   ErrorHandler
+	debugHint      int32
+	dedup          *callSiteDedup
+	errorBurst     *errorBurstDetector
+	schedule       []levelScheduleEntry
+	scheduleActive int
+	scheduleSaved  []savedLevel
+	parent         *Logger
+	filterLevel    LogLevel
+	filterDebug    uint
+	fatalCode      int
+	fatalCodeFn    func(obj interface{}) int
+	stackDepth     int
+	name           string
+	mutes          map[string]muteRule
+	structText     StructTextMode
+	transform      func(level LogLevel, msg string) string
+	metrics        *loggerMetrics
 }
 
 // Options specifies the logger settings to use when initialized.
@@ -38,6 +124,21 @@ type Options struct {
 	// Optionally enable syslog logging and establish its settings.
 	SysLog *SysLogOptions `json:"sysLog,omitempty"`
 
+	// Optionally enable Graylog GELF logging and establish its settings.
+	GELF *GELFOptions `json:"gelf,omitempty"`
+
+	// Optionally enable Amazon CloudWatch Logs logging and establish its settings.
+	CloudWatch *CloudWatchOptions `json:"cloudWatch,omitempty"`
+
+	// Optionally enable OpenTelemetry logging, exporting to a collector with OTLP, and establish its settings.
+	OTLP *OTLPOptions `json:"otlp,omitempty"`
+
+	// Optionally enable logging to a pipe and establish its settings.
+	Pipe *PipeOptions `json:"-"`
+
+	// Optional custom log targets.
+	Adapters []Adapter `json:"-"`
+
 	// Set the initial logging level to use.
 	Level LogLevel `json:"level,omitempty"`
 
@@ -47,10 +148,161 @@ type Options struct {
 	// Use the local computer time instead of UTC.
 	UseLocalTime  bool `json:"useLocalTime,omitempty"`
 
+	// Do not raise again panics captured by CapturePanics.
+	SwallowPanics bool `json:"swallowPanics,omitempty"`
+
+	// Log panics captured by CapturePanics as JSON messages with the stack trace stored in the stack field as an
+	// array of {func, file, line} objects, limited to this amount of frames, instead of the raw text of debug.Stack.
+	StackTraceMaxDepth int `json:"stackTraceMaxDepth,omitempty"`
+
+	// Tag each message with a sequence number, shared by all the targets, to detect dropped or reordered messages.
+	// It is added as the seq field in JSON messages and as a #N prefix in plain text ones.
+	IncludeSequence bool `json:"includeSequence,omitempty"`
+
+	// Notify, only once, when a message is logged after the logger was destroyed. The ErrorHandler is called if set,
+	// otherwise, the warning is written to the standard error.
+	WarnOnUseAfterDestroy bool `json:"warnOnUseAfterDestroy,omitempty"`
+
+	// Layout to use for the timestamp field of JSON messages, or JSONTimestampUnix, JSONTimestampUnixMilli or
+	// JSONTimestampUnixNano to write it as a number. Defaults to DefaultJSONTimestampFormat.
+	JSONTimestampFormat string `json:"jsonTimestampFormat,omitempty"`
+
+	// Use the layout of older versions for the timestamp field of JSON messages. Ignored if JSONTimestampFormat is set.
+	LegacyJSONTimestamp bool `json:"legacyJsonTimestamp,omitempty"`
+
+	// Precision of the timestamps of plain text messages and of the default JSON layouts. Defaults to milliseconds.
+	TimePrecision TimePrecision `json:"timePrecision,omitempty"`
+
+	// Fields to add to every message, like the service name or version. Fields of JSON messages and fields added
+	// with With or PushContext take precedence over them.
+	DefaultFields map[string]interface{} `json:"defaultFields,omitempty"`
+
+	// Process and runtime metadata to add to every message, like the process id. Like DefaultFields, other fields
+	// with the same name take precedence over them.
+	RuntimeFields RuntimeFieldsOptions `json:"runtimeFields,omitempty"`
+
+	// Output booleans and numbers as plain text messages, like DATE [INFO] 42, instead of JSON messages with the
+	// value stored in the message field.
+	TextScalars bool `json:"textScalars,omitempty"`
+
+	// How to render structs passed as messages: StructTextModeJSON, the default, outputs JSON messages and
+	// StructTextModeKeyValue outputs plain text messages like DATE [INFO] field=value field2=value2, using the JSON
+	// names of the fields. Members of nested structs get dotted keys, like parent.child=value.
+	StructTextMode StructTextMode `json:"structTextMode,omitempty"`
+
+	// Optional function to rewrite the final text of every message before it is sent to the targets, for example,
+	// to mask credit card numbers anywhere in it. It receives plain text, JSON or logfmt messages, depending on the
+	// target, and must keep them valid, for example, by not replacing quotes or braces in JSON ones. Custom adapters
+	// receiving the original object get it unchanged.
+	Transform func(level LogLevel, msg string) string `json:"-"`
+
+	// Add the fully qualified name of the calling function, like pkg.(*Type).Method, as the func field of JSON
+	// messages and as a func=name token of plain text ones.
+	IncludeFunction bool `json:"includeFunction,omitempty"`
+
+	// Add the host name as the host field of JSON messages and as a [host] prefix of plain text ones, useful when
+	// the logs of several hosts are aggregated. The host name is read once.
+	IncludeHostname bool `json:"includeHostname,omitempty"`
+
+	// Host name to add if IncludeHostname is set, like a container or service identity, instead of the computer's one.
+	HostnameOverride string `json:"hostnameOverride,omitempty"`
+
+	// Drop empty messages: strings that are empty or only contain white space, and structs that marshal to an empty
+	// JSON object, {}, before fields are added.
+	SkipEmpty bool `json:"skipEmpty,omitempty"`
+
+	// Output time.Duration fields of JSON messages as strings, like "1.5s", instead of amounts of nanoseconds, and
+	// time.Time fields using the JSON timestamp layout.
+	FriendlyDurations bool `json:"friendlyDurations,omitempty"`
+
+	// Function to encode the level field of JSON messages, like UppercaseLevelEncoder or SeverityLevelEncoder.
+	// Defaults to LowercaseLevelEncoder.
+	JSONLevelEncoder JSONLevelEncoder `json:"-"`
+
+	// Limit the amount of log files kept open at the same time. The least recently used files are closed when the
+	// limit is reached and reopened on demand. The limit is shared by all the loggers setting it and the smallest
+	// value set in the process applies, so a logger cannot raise the limit another one relies on. Zero means no
+	// limit.
+	MaxOpenFiles int `json:"maxOpenFiles,omitempty"`
+
+	// Truncate JSON messages larger than this amount of bytes, keeping the beginning as a string in the message
+	// field, and notify the ErrorHandler. Zero means no limit.
+	MaxPayloadBytes int `json:"maxPayloadBytes,omitempty"`
+
+	// Suppress messages identical to the last one logged from the same call site within this window. A "repeated
+	// N times" message is emitted when the window closes. Zero disables it. Audit messages are never suppressed.
+	DedupByCallSite time.Duration `json:"dedupByCallSite,omitempty"`
+
+	// Truncate long messages keeping their beginning and their end, where stack traces or error codes usually are,
+	// joined by "...". It applies to the formatted message, including fields, so all targets get the same one.
+	TruncateHeadTail *HeadTailOptions `json:"truncateHeadTail,omitempty"`
+
+	// Signal bursts of error messages, like more than 100 per second, through a callback, so a single alert can be
+	// raised instead of one per message. Messages are logged as usual.
+	ErrorBurst *ErrorBurstOptions `json:"errorBurst,omitempty"`
+
+	// Set the level of all the targets during daily time ranges, like errors only at night. The first range
+	// containing the current time applies and, outside all of them, the levels in use before entering the first one
+	// are restored. Times are in UTC unless UseLocalTime is set. Calls to SetLevel while a range applies only last
+	// until it ends.
+	LevelSchedule []ScheduleEntry `json:"levelSchedule,omitempty"`
+
+	// Do not check whether several targets write to the same files, or the same custom adapter is added more than
+	// once. By default, Create and Reconfigure fail in that case, as messages would be written twice.
+	AllowDuplicateSinks bool `json:"allowDuplicateSinks,omitempty"`
+
+	// Also log the internal errors of a target, at warning level, through the rest of the targets. For example, a
+	// syslog delivery failure is written to the log file. Errors are never logged through the failing target.
+	LogInternalErrors bool `json:"logInternalErrors,omitempty"`
+
+	// Debug categories, like "sql" or "http", whose messages logged with DebugCat are output. Category messages
+	// still require the debug level to be enabled but do not depend on DebugLevel.
+	EnabledDebugCategories []string `json:"enabledDebugCategories,omitempty"`
+
+	// Call the ErrorHandler again on this cadence while a target keeps failing, so long outages are noticed. Zero
+	// means only the first failure after a success is notified.
+	ErrorNotifyInterval time.Duration `json:"errorNotifyInterval,omitempty"`
+
+	// Exit code used by Fatal. Defaults to 1.
+	FatalExitCodeDefault int `json:"fatalExitCodeDefault,omitempty"`
+
+	// Optional function used by Fatal to choose the exit code from the logged value, like the code of an error.
+	FatalExitCodeFunc func(obj interface{}) int `json:"-"`
+
+	// Do not notify the ErrorHandler when the options of a target are set but empty, like &SysLogOptions{}, so the
+	// target silently uses the defaults for everything, like sending to the local host.
+	SuppressDefaultsHint bool `json:"suppressDefaultsHint,omitempty"`
+
 	// A callback to call if an internal error is encountered.
 	ErrorHandler ErrorHandler
 }
 
+// HeadTailOptions specifies how long messages are truncated. Messages longer than Head+Tail bytes keep their first
+// Head and last Tail bytes, without splitting UTF-8 sequences. Truncated JSON messages are replaced by an object with
+// the truncated JSON text in the message field.
+type HeadTailOptions struct {
+	// Amount of bytes to keep from the beginning of the message.
+	Head int `json:"head,omitempty"`
+
+	// Amount of bytes to keep from the end of the message.
+	Tail int `json:"tail,omitempty"`
+}
+
+// RuntimeFieldsOptions selects the process and runtime metadata fields to add to every message.
+type RuntimeFieldsOptions struct {
+	// Add the process id as the pid field.
+	PID bool `json:"pid,omitempty"`
+
+	// Add the Go version the application was built with as the go_version field.
+	GoVersion bool `json:"goVersion,omitempty"`
+
+	// Add the amount of goroutines at the time the message is logged as the num_goroutine field.
+	NumGoroutine bool `json:"numGoroutine,omitempty"`
+
+	// Add the host name as the hostname field.
+	Hostname bool `json:"hostname,omitempty"`
+}
+
 // ErrorHandler is a callback to call if an internal error must be notified.
 type ErrorHandler func(message string)
 
@@ -59,6 +311,9 @@ type ErrorHandler func(message string)
 var (
 	defaultLoggerInit = sync.Once{}
 	defaultLogger     *Logger
+
+	defaultAppNameMtx = sync.RWMutex{}
+	defaultAppName    string
 )
 
 //------------------------------------------------------------------------------
@@ -73,6 +328,14 @@ func Default() *Logger {
 	return defaultLogger
 }
 
+// SetDefaultAppName sets the name to use when no file prefix or syslog application name is given instead of
+// deriving it from the executable name. Pass an empty string to restore the default behavior.
+func SetDefaultAppName(name string) {
+	defaultAppNameMtx.Lock()
+	defaultAppName = name
+	defaultAppNameMtx.Unlock()
+}
+
 // WithLevel is a helper to set up a log level override.
 func WithLevel(level LogLevel) *LogLevel {
 	return &level
@@ -87,51 +350,67 @@ func WithDebugLevel(debugLevel uint) *uint {
 func Create(opts Options) (*Logger, error) {
 	// Create logger
 	lg := &Logger{
-		mtx:      sync.RWMutex{},
-		adapters: make([]internalLogger, 0),
+		mtx:     sync.RWMutex{},
+		metrics: newLoggerMetrics(),
 	}
 
-	// Initialize global options
-	glbOpts := globalOptions{
-		Level:        opts.Level,
-		DebugLevel:   opts.DebugLevel,
-		ErrorHandler: opts.ErrorHandler,
+	// Create adapters
+	adapters, err := createAdapters(lg, opts)
+	if err != nil {
+		return nil, err
 	}
+	lg.adapters = adapters
+	lg.setOptions(opts)
+	notifyDefaultsUsed(opts)
 
-	// Create console adapter
-	if !opts.Console.Disable {
-		adapter := createConsoleAdapter(opts.Console, glbOpts)
+	// Done
+	return lg, nil
+}
 
-		// Add to list of adapters
-		lg.adapters = append(lg.adapters, adapter)
+// Reconfigure replaces the logger settings and targets with the new ones. Current targets are flushed and destroyed
+// after the new ones are in place. If the new targets cannot be created, the current configuration is kept and the
+// error is returned.
+func (lg *Logger) Reconfigure(opts Options) error {
+	if lg.parent != nil {
+		return lg.parent.Reconfigure(opts)
 	}
 
-	// Create file adapter if opts were specified
-	if opts.File != nil {
-		adapter, err := createFileAdapter(*opts.File, glbOpts)
-		if err != nil {
-			lg.Destroy()
-			return nil, err
-		}
+	// Create the new adapters
+	adapters, err := createAdapters(lg, opts)
+	if err != nil {
+		return err
+	}
 
-		// Add to list of adapters
-		lg.adapters = append(lg.adapters, adapter)
+	// Swap them. Waiting for the exclusive lock ensures in-flight messages are dispatched to the old adapters.
+	lg.mtx.Lock()
+	if lg.destroyed {
+		lg.mtx.Unlock()
+		destroyAdapters(adapters)
+		return errors.New("logger already destroyed")
+	}
+	oldAdapters := lg.adapters
+	oldDedup := lg.dedup
+	lg.dedup = nil
+	lg.adapters = adapters
+	lg.setOptions(opts)
+	lg.mtx.Unlock()
+	notifyDefaultsUsed(opts)
+
+	// Report the messages still being repeated through the new adapters
+	if oldDedup != nil {
+		lg.emitDedupSummaries(oldDedup.stop())
 	}
 
-	// Create syslog adapter if opts were specified
-	if opts.SysLog != nil {
-		adapter, err := createSysLogAdapter(*opts.SysLog, glbOpts)
-		if err != nil {
-			lg.Destroy()
-			return nil, err
+	// Destroy the old adapters except custom ones being reused
+	for _, adapter := range oldAdapters {
+		if ca, ok := adapter.(*customAdapter); ok && containsAdapter(opts.Adapters, ca.adapter) {
+			continue
 		}
-
-		// Add to list of adapters
-		lg.adapters = append(lg.adapters, adapter)
+		adapter.destroy()
 	}
 
 	// Done
-	return lg, nil
+	return nil
 }
 
 // Destroy shuts down the logger.
@@ -141,15 +420,41 @@ func (lg *Logger) Destroy() {
 		return
 	}
 
+	// Report the messages still being repeated
+	lg.mtx.Lock()
+	dedup := lg.dedup
+	lg.dedup = nil
+	lg.mtx.Unlock()
+	if dedup != nil {
+		lg.emitDedupSummaries(dedup.stop())
+	}
+
+	// Detach the adapters
+	lg.mtx.Lock()
+	if lg.errorBurst != nil {
+		lg.errorBurst.stop()
+		lg.errorBurst = nil
+	}
+	adapters := append(lg.adapters, lg.captures...)
+	lg.adapters = nil
+	lg.captures = nil
+	lg.closeSubscribers()
+	lg.destroyed = true
+	lg.mtx.Unlock()
+
 	// Destroy all adapters
-	for _, adapter := range lg.adapters {
+	for _, adapter := range adapters {
 		adapter.destroy()
 	}
-	lg.adapters = nil
 }
 
 // SetLevel sets the minimum level for all messages.
 func (lg *Logger) SetLevel(level LogLevel, debugLevel uint, class string) {
+	if lg.parent != nil {
+		lg.parent.SetLevel(level, debugLevel, class)
+		return
+	}
+
 	// Lock access
 	lg.mtx.Lock()
 	defer lg.mtx.Unlock()
@@ -159,104 +464,227 @@ func (lg *Logger) SetLevel(level LogLevel, debugLevel uint, class string) {
 			adapter.setLevel(level, debugLevel)
 		}
 	}
+	if (class == "" || class == "all") && (level < LogLevelDebug || debugLevel > 0) {
+		atomic.StoreInt32(&lg.debugHint, 0)
+	}
 }
 
-// Error emits an error message into the configured targets.
-// If a string is passed, output format will be in DATE [LEVEL] MESSAGE.
-// If a struct is passed, output will be in json with level and timestamp fields automatically added.
-func (lg *Logger) Error(obj interface{}) {
+// Enabled returns true if a message of the given level, and debug level for debug messages, would be output by at
+// least one target. Use it to skip building expensive messages, like structs, that would be discarded. Passing them
+// to the logging methods allocates even if they are discarded, while constant strings and deferred messages do not.
+func (lg *Logger) Enabled(level LogLevel, debugLevel uint) bool {
+	if lg.parent != nil {
+		return lg.filterAllows(level, debugLevel) && lg.parent.Enabled(level, debugLevel)
+	}
+
+	lg.checkLevelSchedule()
+
 	// Lock access
 	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
 
-	msg, isJSON, ok := lg.parseObj(obj)
-	if ok {
-		now := lg.getTimestamp()
-		raw := false
-		if isJSON {
-			msg = addPayloadToJSON(msg, now, "error")
-			raw = true
-		}
+	return !lg.destroyed && lg.isEnabled(level, debugLevel)
+}
 
-		for _, adapter := range lg.adapters {
-			adapter.logError(now, msg, raw)
-		}
+// GetLevel returns the current logging level and debug level of the first target of the given class, or of the
+// first target if class is empty or "all". The last return value is false if there is no such target.
+func (lg *Logger) GetLevel(class string) (LogLevel, uint, bool) {
+	if lg.parent != nil {
+		return lg.parent.GetLevel(class)
 	}
 
-	// Unlock access
-	lg.mtx.RUnlock()
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
+
+	for _, adapter := range lg.adapters {
+		if class == "" || class == "all" || class == adapter.class() {
+			level, debugLevel := adapter.getLevel()
+			return level, debugLevel, true
+		}
+	}
+	return LogLevelQuiet, 0, false
 }
 
-// Warning emits a warning message into the configured targets.
-// If a string is passed, output format will be in DATE [LEVEL] MESSAGE.
-// If a struct is passed, output will be in json with level and timestamp fields automatically added.
-func (lg *Logger) Warning(obj interface{}) {
+// SetEnabled temporarily disables or enables again the targets of the given class, or all of them if class is empty
+// or "all". Disabled targets drop new messages, including audit ones, unless SysLogOptions.QueueWhileDisabled is
+// set, but keep their state, like open files or syslog connections and queues, so they can be enabled again
+// instantly.
+func (lg *Logger) SetEnabled(class string, enabled bool) {
+	if lg.parent != nil {
+		lg.parent.SetEnabled(class, enabled)
+		return
+	}
+
 	// Lock access
 	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
 
-	msg, isJSON, ok := lg.parseObj(obj)
-	if ok {
-		now := lg.getTimestamp()
-		raw := false
-		if isJSON {
-			msg = addPayloadToJSON(msg, now, "warning")
-			raw = true
+	for _, adapter := range lg.adapters {
+		if class == "" || class == "all" || class == adapter.class() {
+			adapter.setEnabled(enabled)
 		}
+	}
+}
+
+// Sync waits until the messages logged so far are durably stored: files are synced to disk and messages queued for
+// the syslog server are sent. It returns the errors of the targets that could not be synced, prefixed by their
+// class, usually, when the application exits:
+//
+//	defer func() { _ = lg.Sync() }()
+func (lg *Logger) Sync() error {
+	if lg.parent != nil {
+		return lg.parent.Sync()
+	}
+
+	var errs syncErrors
+
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
 
-		for _, adapter := range lg.adapters {
-			adapter.logWarning(now, msg, raw)
+	for _, adapter := range lg.adapters {
+		if err := adapter.sync(); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", adapter.class(), err))
 		}
 	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
 
-	// Unlock access
-	lg.mtx.RUnlock()
+// WaitForDelivery blocks until the targets sending messages in the background, like syslog, have no queued or
+// in-flight messages, or until ctx is done, in which case its error is returned. Unlike Sync, it does not send
+// anything itself. Messages the targets fail to deliver are dropped, or spooled, and not waited for.
+func (lg *Logger) WaitForDelivery(ctx context.Context) error {
+	if lg.parent != nil {
+		return lg.parent.WaitForDelivery(ctx)
+	}
+
+	for {
+		if lg.deliveryIdle() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(deliveryPollInterval):
+		}
+	}
 }
 
-// Info emits an information message into the configured targets.
-// If a string is passed, output format will be in DATE [LEVEL] MESSAGE.
-// If a struct is passed, output will be in json with level and timestamp fields automatically added.
-func (lg *Logger) Info(obj interface{}) {
+// Reopen closes the files of the file target so they are opened again on the next write. Call it, for example, on
+// SIGHUP after an external tool like logrotate moved a file written using FileOptions.FixedName.
+func (lg *Logger) Reopen() {
+	if lg.parent != nil {
+		lg.parent.Reopen()
+		return
+	}
+
 	// Lock access
 	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
 
-	msg, isJSON, ok := lg.parseObj(obj)
-	if ok {
-		now := lg.getTimestamp()
-		raw := false
-		if isJSON {
-			msg = addPayloadToJSON(msg, now, "info")
-			raw = true
+	for _, adapter := range lg.adapters {
+		if fa, ok := adapter.(*fileAdapter); ok {
+			fa.reopen()
 		}
+	}
+}
+
+// SysLogHealth returns the delivery statistics of the syslog target. The second return value is false if syslog
+// logging is not enabled.
+func (lg *Logger) SysLogHealth() (SysLogHealth, bool) {
+	if lg.parent != nil {
+		return lg.parent.SysLogHealth()
+	}
+
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
 
-		for _, adapter := range lg.adapters {
-			adapter.logInfo(now, msg, raw)
+	for _, adapter := range lg.adapters {
+		if sa, ok := adapter.(*syslogAdapter); ok {
+			return sa.health(), true
 		}
 	}
+	return SysLogHealth{}, false
+}
 
-	// Unlock access
-	lg.mtx.RUnlock()
+// Error emits an error message into the configured targets.
+// If a string is passed, output format will be in DATE [LEVEL] MESSAGE.
+// If a struct is passed, output will be in json with level and timestamp fields automatically added.
+// If an error is passed, output will be in json with its message in the error field and the messages of the errors
+// it wraps in the error_chain field.
+// If a boolean or a number is passed, output will be in json with the value stored in the message field, or in
+// plain text if TextScalars is set.
+// If a func() interface{} is passed, it is only called if the message is not discarded and its result is logged. It
+// may log messages too.
+func (lg *Logger) Error(obj interface{}) {
+	lg.emit(LogLevelError, 0, obj)
+}
+
+// Warning emits a warning message into the configured targets.
+// If a string is passed, output format will be in DATE [LEVEL] MESSAGE.
+// If a struct is passed, output will be in json with level and timestamp fields automatically added.
+// If an error is passed, output will be in json with its message in the error field and the messages of the errors
+// it wraps in the error_chain field.
+// If a boolean or a number is passed, output will be in json with the value stored in the message field, or in
+// plain text if TextScalars is set.
+// If a func() interface{} is passed, it is only called if the message is not discarded and its result is logged. It
+// may log messages too.
+func (lg *Logger) Warning(obj interface{}) {
+	lg.emit(LogLevelWarning, 0, obj)
+}
+
+// Info emits an information message into the configured targets.
+// If a string is passed, output format will be in DATE [LEVEL] MESSAGE.
+// If a struct is passed, output will be in json with level and timestamp fields automatically added.
+// If an error is passed, output will be in json with its message in the error field and the messages of the errors
+// it wraps in the error_chain field.
+// If a boolean or a number is passed, output will be in json with the value stored in the message field, or in
+// plain text if TextScalars is set.
+// If a func() interface{} is passed, it is only called if the message is not discarded and its result is logged. It
+// may log messages too.
+func (lg *Logger) Info(obj interface{}) {
+	lg.emit(LogLevelInfo, 0, obj)
 }
 
 // Debug emits a debug message into the configured targets.
 // If a string is passed, output format will be in DATE [LEVEL] MESSAGE.
 // If a struct is passed, output will be in json with level and timestamp fields automatically added.
+// If an error is passed, output will be in json with its message in the error field and the messages of the errors
+// it wraps in the error_chain field.
+// If a boolean or a number is passed, output will be in json with the value stored in the message field, or in
+// plain text if TextScalars is set.
+// If a func() interface{} is passed, it is only called if the message is not discarded and its result is logged. It
+// may log messages too.
 func (lg *Logger) Debug(level uint, obj interface{}) {
+	if level > 0 && atomic.LoadInt32(&lg.debugHint) != 0 {
+		lg.notifyDebugSuppressed()
+	}
+	lg.emit(LogLevelDebug, level, obj)
+}
+
+// DebugCat emits a debug message of the given category into the configured targets. It is discarded unless the
+// category is listed in EnabledDebugCategories.
+// Output format follows the same rules as the other methods.
+func (lg *Logger) DebugCat(category string, obj interface{}) {
 	// Lock access
 	lg.mtx.RLock()
+	_, enabled := lg.debugCats[category]
+	lg.mtx.RUnlock()
 
-	msg, isJSON, ok := lg.parseObj(obj)
-	if ok {
-		now := lg.getTimestamp()
-		raw := false
-		if isJSON {
-			msg = addPayloadToJSON(msg, now, "debug")
-			raw = true
-		}
-
-		for _, adapter := range lg.adapters {
-			adapter.logDebug(level, now, msg, raw)
-		}
+	if enabled {
+		lg.emit(LogLevelDebug, 0, obj)
 	}
+}
 
-	// Unlock access
-	lg.mtx.RUnlock()
+// Audit emits an audit message into the configured targets regardless of the logging level.
+// The call blocks until the message is durably written to files and delivered to syslog servers.
+// Output format follows the same rules as the other methods.
+func (lg *Logger) Audit(obj interface{}) {
+	lg.emit(logLevelAudit, 0, obj)
 }
@@ -0,0 +1,162 @@
+package go_logger_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+type testAdapterEntry struct {
+	level logger.LogLevel
+	msg   string
+	raw   bool
+}
+
+type testAdapter struct {
+	mtx     sync.Mutex
+	entries []testAdapterEntry
+}
+
+type richTestAdapter struct {
+	testAdapter
+	kinds []string
+}
+
+//------------------------------------------------------------------------------
+
+func TestCustomAdapter(t *testing.T) {
+	adapter := &testAdapter{}
+	lg := createTestAdapterLogger(t, adapter)
+
+	printTestMessages(lg)
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if len(entries) != 8 {
+		t.Fatalf("unexpected number of entries [got: %v, expected: 8]", len(entries))
+	}
+	if entries[0].level != logger.LogLevelError || entries[0].msg != "This is an error message sample" ||
+		entries[0].raw {
+		t.Errorf("unexpected first entry [%+v]", entries[0])
+	}
+	if entries[4].level != logger.LogLevelError || !entries[4].raw {
+		t.Errorf("unexpected fifth entry [%+v]", entries[4])
+	}
+}
+
+func TestRichAdapter(t *testing.T) {
+	adapter := &richTestAdapter{}
+	lg := createTestAdapterLogger(t, adapter)
+
+	lg.Info("plain text")
+	lg.Info(JsonMessage{
+		Message: "struct",
+	})
+	lg.Info(&JsonMessage{
+		Message: "struct pointer",
+	})
+	lg.Destroy()
+
+	expected := []string{"string:plain text", "JsonMessage:struct", "*JsonMessage:struct pointer"}
+	if len(adapter.kinds) != len(expected) {
+		t.Fatalf("unexpected number of objects [got: %v, expected: %v]", len(adapter.kinds), len(expected))
+	}
+	for idx, s := range expected {
+		if adapter.kinds[idx] != s {
+			t.Errorf("unexpected object #%v [got: %v, expected: %v]", idx+1, adapter.kinds[idx], s)
+		}
+	}
+	if len(adapter.Entries()) != len(expected) {
+		t.Errorf("formatted messages were not received")
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, dir := createTestFileLogger(t, "SetEnabled", func(opts *logger.Options) {
+		opts.Adapters = []logger.Adapter{adapter}
+	})
+
+	lg.Info("first")
+	lg.SetEnabled("test", false)
+	lg.Info("second")
+	lg.Audit("third")
+	lg.SetEnabled("test", true)
+	lg.Info("fourth")
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if len(entries) != 2 || entries[0].msg != "first" || entries[1].msg != "fourth" {
+		t.Errorf("unexpected entries in the toggled target [%+v]", entries)
+	}
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "SetEnabled")), "\n")
+	if len(lines) != 4 {
+		t.Errorf("other targets were affected [got: %v lines, expected: 4]", len(lines))
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func createTestAdapterLogger(t *testing.T, adapter logger.Adapter) *logger.Logger {
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters:   []logger.Adapter{adapter},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg
+}
+
+func (a *testAdapter) Class() string {
+	return "test"
+}
+
+func (a *testAdapter) Log(level logger.LogLevel, _ time.Time, msg string, raw bool) {
+	a.mtx.Lock()
+	a.entries = append(a.entries, testAdapterEntry{
+		level: level,
+		msg:   msg,
+		raw:   raw,
+	})
+	a.mtx.Unlock()
+}
+
+func (a *testAdapter) Destroy() {
+}
+
+func (a *testAdapter) Entries() []testAdapterEntry {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return append([]testAdapterEntry{}, a.entries...)
+}
+
+func (a *richTestAdapter) LogObject(level logger.LogLevel, now time.Time, msg string, raw bool, obj interface{}) {
+	a.Log(level, now, msg, raw)
+
+	// Inspect the original object without parsing the formatted message
+	a.mtx.Lock()
+	switch v := obj.(type) {
+	case string:
+		a.kinds = append(a.kinds, "string:"+v)
+	case JsonMessage:
+		a.kinds = append(a.kinds, "JsonMessage:"+v.Message)
+	case *JsonMessage:
+		a.kinds = append(a.kinds, "*JsonMessage:"+v.Message)
+	default:
+		a.kinds = append(a.kinds, fmt.Sprintf("%T", v))
+	}
+	a.mtx.Unlock()
+}
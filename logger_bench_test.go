@@ -0,0 +1,82 @@
+package go_logger_test
+
+import (
+	"io"
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func BenchmarkStringInfo(b *testing.B) {
+	lg := createBenchmarkLogger(b)
+	defer lg.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lg.Info("This is an information message sample")
+	}
+}
+
+func BenchmarkStructInfo(b *testing.B) {
+	lg := createBenchmarkLogger(b)
+	defer lg.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lg.Info(JsonMessage{
+			Message: "This is an information message sample",
+		})
+	}
+}
+
+func BenchmarkDisabledDebug(b *testing.B) {
+	lg := createBenchmarkLogger(b)
+	defer lg.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lg.Debug(1, "This is a debug message sample")
+	}
+}
+
+func TestDisabledDebugAllocs(t *testing.T) {
+	lg := createBenchmarkLogger(t)
+	defer lg.Destroy()
+
+	derived := lg.WithLevel(logger.LogLevelDebug, 1)
+
+	// Suppressed calls must not allocate, neither with plain strings nor deferred messages or guarded structs
+	allocs := testing.AllocsPerRun(100, func() {
+		lg.Debug(1, "This is a debug message sample")
+		lg.Debug(1, func() interface{} {
+			return "This is a deferred debug message sample"
+		})
+		if lg.Enabled(logger.LogLevelDebug, 1) {
+			lg.Debug(1, JsonMessage{
+				Message: "This is a debug message sample",
+			})
+		}
+		derived.Debug(1, "This is a derived debug message sample")
+	})
+	if allocs != 0 {
+		t.Errorf("suppressed debug messages allocate [%v allocs/op]", allocs)
+	}
+}
+
+func createBenchmarkLogger(tb testing.TB) *logger.Logger {
+	restore := logger.SetConsoleWriters(io.Discard, io.Discard)
+	tb.Cleanup(restore)
+
+	lg, err := logger.Create(logger.Options{
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		tb.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg
+}
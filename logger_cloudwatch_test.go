@@ -0,0 +1,259 @@
+package go_logger_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+type cloudWatchTestServer struct {
+	*httptest.Server
+	mtx      sync.Mutex
+	requests []cloudWatchTestRequest
+	accepted chan cloudWatchTestRequest
+}
+
+type cloudWatchTestRequest struct {
+	LogGroupName  string `json:"logGroupName"`
+	LogStreamName string `json:"logStreamName"`
+	LogEvents     []struct {
+		Timestamp int64  `json:"timestamp"`
+		Message   string `json:"message"`
+	} `json:"logEvents"`
+	SequenceToken string `json:"sequenceToken"`
+}
+
+func TestCloudWatch(t *testing.T) {
+	srv := startTestCloudWatchServer(t)
+	defer srv.Close()
+
+	lg := createTestCloudWatchLogger(t, srv, func(opts *logger.CloudWatchOptions) {
+		opts.MaxLatency = 200 * time.Millisecond
+	})
+	defer lg.Destroy()
+
+	// Messages logged together are sent in a single batch
+	lg.Error("first message")
+	lg.Warning("second message")
+	lg.Info(JsonMessage{
+		Message: "third message",
+	})
+
+	req := srv.next(t)
+	if req.LogGroupName != "test-group" || req.LogStreamName != "test-stream" {
+		t.Fatalf("unexpected log group or stream [%v/%v]", req.LogGroupName, req.LogStreamName)
+	}
+	if len(req.LogEvents) != 3 {
+		t.Fatalf("unexpected amount of events in batch [%v]", len(req.LogEvents))
+	}
+	if !strings.HasSuffix(req.LogEvents[0].Message, "first message") ||
+		!strings.HasSuffix(req.LogEvents[1].Message, "second message") ||
+		!strings.Contains(req.LogEvents[2].Message, `"third message"`) {
+		t.Errorf("unexpected batch events [%v]", req.LogEvents)
+	}
+	if req.LogEvents[0].Timestamp <= 0 || req.LogEvents[2].Timestamp < req.LogEvents[0].Timestamp {
+		t.Errorf("unexpected event timestamps [%v]", req.LogEvents)
+	}
+
+	// The first attempt was rejected and retried with the expected sequence token
+	if req.SequenceToken != "token-1" {
+		t.Errorf("unexpected sequence token on retry [%v]", req.SequenceToken)
+	}
+
+	// Next batches use the token returned by the last request
+	lg.Info("fourth message")
+	if err := lg.Sync(); err != nil {
+		t.Fatalf("unable to sync. [%v]", err)
+	}
+	req = srv.next(t)
+	if len(req.LogEvents) != 1 || !strings.HasSuffix(req.LogEvents[0].Message, "fourth message") {
+		t.Errorf("unexpected batch events [%v]", req.LogEvents)
+	}
+	if req.SequenceToken != "token-2" {
+		t.Errorf("unexpected sequence token [%v]", req.SequenceToken)
+	}
+
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+	if len(srv.requests) != 3 || len(srv.requests[0].SequenceToken) != 0 {
+		t.Errorf("unexpected requests [%v]", srv.requests)
+	}
+}
+
+func TestCloudWatchMaxLatency(t *testing.T) {
+	srv := startTestCloudWatchServer(t)
+	defer srv.Close()
+
+	const maxLatency = 300 * time.Millisecond
+	lg := createTestCloudWatchLogger(t, srv, func(opts *logger.CloudWatchOptions) {
+		opts.MaxLatency = maxLatency
+	})
+	defer lg.Destroy()
+
+	// A single message must not wait for the batch to be full
+	start := time.Now()
+	lg.Info("lonely message")
+	req := srv.next(t)
+	if elapsed := time.Since(start); elapsed > maxLatency+250*time.Millisecond {
+		t.Errorf("message was not flushed within the configured latency [%v]", elapsed)
+	}
+	if len(req.LogEvents) != 1 || !strings.HasSuffix(req.LogEvents[0].Message, "lonely message") {
+		t.Errorf("unexpected batch events [%v]", req.LogEvents)
+	}
+}
+
+func TestCloudWatchMaxBatch(t *testing.T) {
+	srv := startTestCloudWatchServer(t)
+	defer srv.Close()
+
+	lg := createTestCloudWatchLogger(t, srv, func(opts *logger.CloudWatchOptions) {
+		opts.MaxBatch = 2
+		opts.MaxLatency = time.Hour
+	})
+	defer lg.Destroy()
+
+	// A full batch is sent without waiting for the latency timer
+	lg.Info("first message")
+	lg.Info("second message")
+	req := srv.next(t)
+	if len(req.LogEvents) != 2 {
+		t.Errorf("unexpected batch events [%v]", req.LogEvents)
+	}
+}
+
+func TestCloudWatchSignature(t *testing.T) {
+	// Vectors of the AWS Signature Version 4 test suite
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:   "get-vanilla",
+			method: http.MethodGet,
+			expected: "SignedHeaders=host;x-amz-date, " +
+				"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:        "post-x-www-form-urlencoded",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "Param1=value1",
+			expected: "SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, "https://example.amazonaws.com/", strings.NewReader(test.body))
+		if err != nil {
+			t.Fatalf("unable to create request. [%v]", err)
+		}
+		if len(test.contentType) > 0 {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		logger.SignRequestV4(req, []byte(test.body), now, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			"us-east-1", "service")
+
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " + test.expected
+		if req.Header.Get("Authorization") != expected {
+			t.Errorf("unexpected authorization header of %v [%v]", test.name, req.Header.Get("Authorization"))
+		}
+		if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+			t.Errorf("unexpected date header of %v [%v]", test.name, req.Header.Get("X-Amz-Date"))
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func startTestCloudWatchServer(t *testing.T) *cloudWatchTestServer {
+	srv := &cloudWatchTestServer{
+		accepted: make(chan cloudWatchTestRequest, 16),
+	}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "Logs_20140328.PutLogEvents" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(r.Header.Get("Authorization"),
+			"/us-east-1/logs/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=") {
+			t.Errorf("unexpected authorization header [%v]", r.Header.Get("Authorization"))
+		}
+
+		req := cloudWatchTestRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		srv.mtx.Lock()
+		srv.requests = append(srv.requests, req)
+		count := len(srv.requests)
+		srv.mtx.Unlock()
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		// Reject the first request as if another writer used the stream
+		if count == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"InvalidSequenceTokenException","message":"bad token",` +
+				`"expectedSequenceToken":"token-1"}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"nextSequenceToken":"token-` + strconv.Itoa(count) + `"}`))
+		srv.accepted <- req
+	}))
+	return srv
+}
+
+func (srv *cloudWatchTestServer) next(t *testing.T) cloudWatchTestRequest {
+	select {
+	case req := <-srv.accepted:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout while waiting for CloudWatch request")
+	}
+	return cloudWatchTestRequest{}
+}
+
+func createTestCloudWatchLogger(t *testing.T, srv *cloudWatchTestServer,
+	modifier func(opts *logger.CloudWatchOptions)) *logger.Logger {
+	opts := logger.CloudWatchOptions{
+		LogGroup:        "test-group",
+		LogStream:       "test-stream",
+		Region:          "us-east-1",
+		Endpoint:        srv.URL,
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	}
+	if modifier != nil {
+		modifier(&opts)
+	}
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		CloudWatch: &opts,
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg
+}
@@ -0,0 +1,40 @@
+package go_logger_test
+
+import (
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+	"github.com/randlabs/go-logger/v2/compat"
+)
+
+//------------------------------------------------------------------------------
+
+func TestCompat(t *testing.T) {
+	adapter := &testAdapter{}
+	lg := createTestAdapterLogger(t, adapter)
+
+	compat.SetLogger(lg)
+	defer compat.SetLogger(nil)
+
+	compat.Error("x")
+	compat.Warn("This is a warning message sample")
+	compat.Info("This is an information message sample")
+	compat.Debug("This is a debug message sample")
+	lg.Destroy()
+
+	expected := []testAdapterEntry{
+		{level: logger.LogLevelError, msg: "x"},
+		{level: logger.LogLevelWarning, msg: "This is a warning message sample"},
+		{level: logger.LogLevelInfo, msg: "This is an information message sample"},
+		{level: logger.LogLevelDebug, msg: "This is a debug message sample"},
+	}
+	entries := adapter.Entries()
+	if len(entries) != len(expected) {
+		t.Fatalf("unexpected number of entries [got: %v, expected: %v]", len(entries), len(expected))
+	}
+	for idx, e := range expected {
+		if entries[idx] != e {
+			t.Errorf("unexpected entry #%v [got: %+v, expected: %+v]", idx+1, entries[idx], e)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package go_logger_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+type chunkWriter struct {
+	mtx    sync.Mutex
+	chunks []string
+}
+
+type stalledWriter struct {
+	mtx       sync.Mutex
+	releaseCh chan struct{}
+	buf       bytes.Buffer
+}
+
+//------------------------------------------------------------------------------
+
+func TestConsoleNonBlocking(t *testing.T) {
+	notificationsMtx := sync.Mutex{}
+	notifications := make([]string, 0)
+
+	w := &stalledWriter{
+		releaseCh: make(chan struct{}),
+	}
+	restore := logger.SetConsoleWriters(w, w)
+	defer restore()
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			NonBlocking: true,
+		},
+		Level: logger.LogLevelInfo,
+		ErrorHandler: func(message string) {
+			notificationsMtx.Lock()
+			notifications = append(notifications, message)
+			notificationsMtx.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 5000; i++ {
+		lg.Info("This is an information message sample")
+	}
+	elapsed := time.Since(start)
+
+	close(w.releaseCh)
+	lg.Destroy()
+
+	if elapsed > time.Second {
+		t.Errorf("logging to a stalled console took too long [%v]", elapsed)
+	}
+
+	notificationsMtx.Lock()
+	defer notificationsMtx.Unlock()
+	if len(notifications) != 1 || !strings.Contains(notifications[0], "messages were dropped") {
+		t.Errorf("unexpected drop notifications [%v]", notifications)
+	}
+
+	lines := strings.Count(w.String(), "\n")
+	if lines == 0 || lines >= 5000 {
+		t.Errorf("unexpected number of lines written [%v]", lines)
+	}
+}
+
+func TestConsolePadLevels(t *testing.T) {
+	out := &bytes.Buffer{}
+	restore := logger.SetConsoleWriters(out, out)
+	defer restore()
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			PadLevels: true,
+		},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Error("message")
+	lg.Warning("message")
+	lg.Info("message")
+	lg.Debug(1, "message")
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 4]", len(lines))
+	}
+	column := logger.VisibleWidth(lines[0][:strings.LastIndex(lines[0], "message")])
+	for idx, line := range lines[1:] {
+		if c := logger.VisibleWidth(line[:strings.LastIndex(line, "message")]); c != column {
+			t.Errorf("unaligned message in line #%v [got column: %v, expected: %v]", idx+2, c, column)
+		}
+	}
+
+	// Escape sequences must not count
+	if w := logger.VisibleWidth("\x1b[5;97;41m[ERROR]\x1b[0m"); w != 7 {
+		t.Errorf("unexpected visible width of a colored label [got: %v, expected: 7]", w)
+	}
+}
+
+func TestConsoleWriters(t *testing.T) {
+	std := &bytes.Buffer{}
+	restore := logger.SetConsoleWriters(std, std)
+	defer restore()
+
+	widget := &bytes.Buffer{}
+	detached := &bytes.Buffer{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Writers: []io.Writer{widget, detached},
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Error("This is an error message sample")
+	lg.Info("This is an information message sample")
+	lg.Info(JsonMessage{
+		Message: "This is an information message sample",
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(widget.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 3]", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], " [ERROR] This is an error message sample") ||
+		!strings.HasSuffix(lines[1], " [INFO] This is an information message sample") {
+		t.Errorf("unexpected lines [%v]", lines[:2])
+	}
+	if widget.String() != detached.String() {
+		t.Errorf("writers received different output")
+	}
+	if std.Len() != 0 {
+		t.Errorf("standard streams were written [%v]", std.String())
+	}
+}
+
+func TestConsoleStdoutStderr(t *testing.T) {
+	std := &bytes.Buffer{}
+	restore := logger.SetConsoleWriters(std, std)
+	defer restore()
+	restoreColors := logger.ForceConsoleColors()
+	defer restoreColors()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Stdout: stdout,
+			Stderr: stderr,
+		},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Error("This is an error message sample")
+	lg.Warning("This is a warning message sample")
+	lg.Info("This is an information message sample")
+	lg.Debug(1, "This is a debug message sample")
+	lg.Destroy()
+
+	// Buffers are not terminals so colors are not used
+	errLines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(errLines) != 2 ||
+		!strings.HasSuffix(errLines[0], " [ERROR] This is an error message sample") ||
+		!strings.HasSuffix(errLines[1], " [WARN] This is a warning message sample") {
+		t.Errorf("unexpected standard error output [%q]", errLines)
+	}
+	outLines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(outLines) != 2 ||
+		!strings.HasSuffix(outLines[0], " [INFO] This is an information message sample") ||
+		!strings.HasSuffix(outLines[1], " [DEBUG] This is a debug message sample") {
+		t.Errorf("unexpected standard output [%q]", outLines)
+	}
+	if std.Len() != 0 {
+		t.Errorf("OS streams were written [%v]", std.String())
+	}
+}
+
+func TestConsolePrettyJSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	restore := logger.SetConsoleWriters(out, out)
+	defer restore()
+
+	lg, dir := createTestFileLogger(t, "ConsolePrettyJSON", func(opts *logger.Options) {
+		opts.Console = logger.ConsoleOptions{
+			PrettyJSON: true,
+		}
+	})
+	lg.Info(JsonMessage{
+		Message: "This is an information message sample",
+	})
+	lg.Destroy()
+
+	if !strings.Contains(out.String(), "{\n  \"timestamp\": ") ||
+		!strings.Contains(out.String(), "\n  \"message\": \"This is an information message sample\"\n}") {
+		t.Errorf("console JSON output is not indented [%v]", out.String())
+	}
+	content := strings.TrimSpace(readTestLogFile(t, dir, "ConsolePrettyJSON"))
+	if strings.Contains(content, "\n") || strings.Contains(content, "  ") {
+		t.Errorf("file JSON output is not compact [%v]", content)
+	}
+}
+
+func TestConsoleLineBuffered(t *testing.T) {
+	out := &chunkWriter{}
+	restore := logger.SetConsoleWriters(out, out)
+	defer restore()
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			LineBuffered: true,
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	// Log from several goroutines while other code writes to the same stream
+	wg := sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				lg.Info(fmt.Sprintf("goroutine #%v message #%v %v", g, i, strings.Repeat("x", 64)))
+			}
+		}(g)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, _ = out.Write([]byte(fmt.Sprintf("foreign #%v line #%v\n", g, i)))
+			}
+		}(g)
+	}
+	wg.Wait()
+	lg.Destroy()
+
+	chunks := out.Chunks()
+	if len(chunks) != 1600 {
+		t.Fatalf("unexpected number of writes [got: %v, expected: 1600]", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if strings.Count(chunk, "\n") != 1 || !strings.HasSuffix(chunk, "\n") {
+			t.Fatalf("line was split [%q]", chunk)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	<-w.releaseCh
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *stalledWriter) String() string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.buf.String()
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.chunks = append(w.chunks, string(p))
+	return len(p), nil
+}
+
+func (w *chunkWriter) Chunks() []string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return append([]string(nil), w.chunks...)
+}
+
+func TestConsoleColorizeMessage(t *testing.T) {
+	out := &bytes.Buffer{}
+	restore := logger.SetConsoleWriters(out, out)
+	defer restore()
+	restoreColors := logger.ForceConsoleColors()
+	defer restoreColors()
+
+	for _, colorize := range []bool{true, false} {
+		out.Reset()
+
+		lg, err := logger.Create(logger.Options{
+			Console: logger.ConsoleOptions{
+				ColorizeMessage: colorize,
+			},
+			Level: logger.LogLevelInfo,
+		})
+		if err != nil {
+			t.Fatalf("unable to initialize. [%v]", err)
+		}
+		lg.Error("This is an error message sample")
+		lg.Info("This is an information message sample")
+		lg.Info(JsonMessage{
+			Message: "This is an information message sample",
+		})
+		lg.Destroy()
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("unexpected number of lines [got: %v, expected: 3]", len(lines))
+		}
+		if colorize {
+			if !strings.HasSuffix(lines[0], " \x1b[91mThis is an error message sample\x1b[0m") ||
+				!strings.HasSuffix(lines[1], " \x1b[92mThis is an information message sample\x1b[0m") {
+				t.Errorf("messages were not colorized [%q]", lines[:2])
+			}
+		} else {
+			if !strings.HasSuffix(lines[0], "m This is an error message sample") ||
+				!strings.HasSuffix(lines[1], "m This is an information message sample") {
+				t.Errorf("messages were colorized [%q]", lines[:2])
+			}
+		}
+		// JSON messages are never colored
+		if strings.Contains(lines[2], "\x1b[") {
+			t.Errorf("JSON message was colorized [%q]", lines[2])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package go_logger_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestEntry(t *testing.T) {
+	ts := time.Date(2021, 3, 14, 15, 9, 26, 535897932, time.UTC)
+
+	tests := []struct {
+		name     string
+		setter   func(e *logger.Entry) *logger.Entry
+		key      string
+		expected interface{}
+	}{
+		{
+			name:     "Str",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Str("user", "jdoe \"quoted\"\n") },
+			key:      "user",
+			expected: "jdoe \"quoted\"\n",
+		},
+		{
+			name:     "Int",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Int("count", -42) },
+			key:      "count",
+			expected: float64(-42),
+		},
+		{
+			name:     "Bool",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Bool("ok", true) },
+			key:      "ok",
+			expected: true,
+		},
+		{
+			name:     "Float",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Float("ratio", 0.25) },
+			key:      "ratio",
+			expected: 0.25,
+		},
+		{
+			name:     "FloatNaN",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Float("ratio", math.NaN()) },
+			key:      "ratio",
+			expected: "NaN",
+		},
+		{
+			name:     "Err",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Err(errors.New("access denied")) },
+			key:      "error",
+			expected: "access denied",
+		},
+		{
+			name:     "ErrNil",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Err(nil) },
+			key:      "error",
+			expected: nil,
+		},
+		{
+			name:     "Time",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Time("at", ts) },
+			key:      "at",
+			expected: "2021-03-14T15:09:26.535897932Z",
+		},
+		{
+			name:     "Dur",
+			setter:   func(e *logger.Entry) *logger.Entry { return e.Dur("elapsed", 1500*time.Millisecond) },
+			key:      "elapsed",
+			expected: float64(1500000000),
+		},
+	}
+	for _, test := range tests {
+		prefix := "Entry" + test.name
+		lg, dir := createTestFileLogger(t, prefix, nil)
+		test.setter(lg.Entry(logger.LogLevelInfo)).Msg("done")
+		lg.Destroy()
+
+		entry := parseTestJSONEntry(t, readTestLogFile(t, dir, prefix))
+		if entry["message"] != "done" || entry["level"] != "info" {
+			t.Errorf("unexpected %v entry [%v]", test.name, entry)
+		}
+		if value, ok := entry[test.key]; !ok || value != test.expected {
+			t.Errorf("unexpected %v field [got: %v, expected: %v]", test.name, value, test.expected)
+		}
+	}
+}
+
+func TestEntryLevels(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "EntryLevels", func(opts *logger.Options) {
+		opts.Level = logger.LogLevelWarning
+	})
+	lg.Entry(logger.LogLevelError).Str("user", "jdoe").Msgf("failed %v times", 3)
+	lg.Entry(logger.LogLevelInfo).Str("user", "jdoe").Msg("This message should NOT be printed")
+	lg.DebugEntry(1).Int("count", 1).Msg("This message should NOT be printed")
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "EntryLevels")), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 1]", len(lines))
+	}
+	entry := parseTestJSONEntry(t, lines[0])
+	if entry["message"] != "failed 3 times" || entry["level"] != "error" || entry["user"] != "jdoe" {
+		t.Errorf("unexpected entry [%v]", lines[0])
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "Templates", nil)
+	lg.Infot("user {user} did {action} in {elapsed}", logger.Fields{
+		"user":    "alice",
+		"action":  "login",
+		"elapsed": 1500 * time.Millisecond,
+	})
+	lg.Warningt("{{literal}} braces and {unknown} placeholders", nil)
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "Templates")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+
+	entry := parseTestJSONEntry(t, lines[0])
+	if entry["message"] != "user alice did login in 1.5s" {
+		t.Errorf("unexpected message [%v]", entry["message"])
+	}
+	if entry["message_template"] != "user {user} did {action} in {elapsed}" {
+		t.Errorf("unexpected message template [%v]", entry["message_template"])
+	}
+	if entry["user"] != "alice" || entry["action"] != "login" || entry["elapsed"] != float64(1500*time.Millisecond) {
+		t.Errorf("fields were not preserved [%v]", lines[0])
+	}
+	if entry["level"] != "info" {
+		t.Errorf("unexpected level [%v]", entry["level"])
+	}
+
+	entry = parseTestJSONEntry(t, lines[1])
+	if entry["message"] != "{literal} braces and {unknown} placeholders" {
+		t.Errorf("unexpected message [%v]", entry["message"])
+	}
+}
+
+func TestTemplatesKeyValue(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "TemplatesKeyValue", func(opts *logger.Options) {
+		opts.StructTextMode = logger.StructTextModeKeyValue
+	})
+	lg.Infot("user {user} did {action} in {elapsed}", logger.Fields{
+		"user":    "alice",
+		"action":  "login",
+		"elapsed": 1500 * time.Millisecond,
+	})
+	lg.With("request_id", "r1").Errort("{{literal}} braces and {unknown} placeholders", logger.Fields{
+		"path": "/my files",
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "TemplatesKeyValue")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+	for idx, expected := range []string{
+		" [INFO]: user alice did login in 1.5s action=login elapsed=1.5s user=alice",
+		` [ERROR]: {literal} braces and {unknown} placeholders path="/my files" request_id=r1`,
+	} {
+		if !strings.HasSuffix(lines[idx], expected) {
+			t.Errorf("unexpected line #%v [%v]", idx+1, lines[idx])
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package go_logger_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+const flushAtExitChildEnv = "GO_LOGGER_FLUSH_AT_EXIT_CHILD"
+
+func TestFlushAtExit(t *testing.T) {
+	if os.Getenv(flushAtExitChildEnv) != "" {
+		runFlushAtExitChild(t)
+		return
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("signals cannot be sent to other processes on Windows")
+	}
+	if runtime.GOOS == "plan9" {
+		t.Skip("processes are terminated by notes on Plan 9")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFlushAtExit$")
+	cmd.Env = append(os.Environ(), flushAtExitChildEnv+"=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("unable to get the child output. [%v]", err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatalf("unable to start the child. [%v]", err)
+	}
+
+	// Wait until the child logged its messages
+	readyCh := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if scanner.Text() == "ready" {
+				close(readyCh)
+				break
+			}
+		}
+		// Keep draining the output until the child exits
+		for scanner.Scan() {
+		}
+	}()
+	select {
+	case <-readyCh:
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatalf("timeout while waiting for the child")
+	}
+
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	_ = cmd.Wait()
+
+	// SIGTERM is 15 on all the supported Unix platforms
+	if code := cmd.ProcessState.ExitCode(); code != 128+15 {
+		t.Errorf("unexpected exit code [got: %v, expected: %v]", code, 128+15)
+	}
+	content := readTestLogFile(t, "testdata/logs/flushatexit", "FlushAtExit")
+	if !strings.Contains(content, "This is an information message sample") {
+		t.Errorf("unexpected log file content [%v]", content)
+	}
+}
+
+func TestFatalExitCode(t *testing.T) {
+	var codes []int
+	restore := logger.SetOsExit(func(code int) {
+		codes = append(codes, code)
+	})
+	defer restore()
+
+	lg, dir := createTestFileLogger(t, "FatalExitCode", func(opts *logger.Options) {
+		opts.FatalExitCodeDefault = 7
+		opts.FatalExitCodeFunc = func(obj interface{}) int {
+			var codedErr *exitCodeError
+			if err, ok := obj.(error); ok && errors.As(err, &codedErr) {
+				return codedErr.code
+			}
+			return 7
+		}
+	})
+	lg.Fatal(fmt.Errorf("unable to open the database. [%w]", &exitCodeError{code: 3}))
+	if len(codes) != 1 || codes[0] != 3 {
+		t.Errorf("unexpected exit codes [%v]", codes)
+	}
+	content := readTestLogFile(t, dir, "FatalExitCode")
+	if !strings.Contains(content, "[ERROR]: unable to open the database. [code 3]") {
+		t.Errorf("unexpected log file content [%v]", content)
+	}
+
+	// Without a function, the default code is used
+	lg, _ = createTestFileLogger(t, "FatalExitCode", func(opts *logger.Options) {
+		opts.FatalExitCodeDefault = 7
+	})
+	lg.Fatal("unrecoverable error")
+	if len(codes) != 2 || codes[1] != 7 {
+		t.Errorf("unexpected exit codes [%v]", codes)
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("code %v", e.code)
+}
+
+func runFlushAtExitChild(t *testing.T) {
+	lg, _ := createTestFileLogger(t, "FlushAtExit", nil)
+	_ = lg.FlushAtExit()
+
+	lg.Info("This is an information message sample")
+	os.Stdout.WriteString("ready\n")
+
+	// Wait for the signal
+	time.Sleep(time.Minute)
+}
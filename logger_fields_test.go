@@ -0,0 +1,292 @@
+package go_logger_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestWithFields(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "WithFields", nil)
+
+	lg.Info("no fields")
+	txnLg := lg.With("txn", 1234)
+	txnLg.Info("one field")
+	userLg := txnLg.With("user", "john doe")
+	userLg.Info("two fields")
+	userLg.Info(JsonMessage{
+		Message: "two fields",
+	})
+	txnLg.Info("one field again")
+	lg.Info("no fields again")
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "WithFields")), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+
+	expected := []string{
+		"[INFO]: no fields",
+		"[INFO]: one field txn=1234",
+		`[INFO]: two fields txn=1234 user="john doe"`,
+		"",
+		"[INFO]: one field again txn=1234",
+		"[INFO]: no fields again",
+	}
+	for idx, s := range expected {
+		if len(s) > 0 && !strings.HasSuffix(lines[idx], s) {
+			t.Errorf("unexpected line #%v [got: %v, expected suffix: %v]", idx+1, lines[idx], s)
+		}
+	}
+
+	entry := parseTestJSONEntry(t, lines[3])
+	if entry["txn"] != float64(1234) || entry["user"] != "john doe" || entry["message"] != "two fields" {
+		t.Errorf("unexpected JSON entry [%v]", lines[3])
+	}
+}
+
+func TestPushContext(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "PushContext", nil)
+
+	ctx := context.Background()
+	lg.InfoContext(ctx, "no fields")
+	txnCtx, popTxn := logger.PushContext(ctx, "txn", 1234)
+	lg.InfoContext(txnCtx, "one field")
+	userCtx, popUser := logger.PushContext(txnCtx, "user", "john doe")
+	lg.With("service", "billing").WarningContext(userCtx, "two fields")
+	lg.ErrorContext(userCtx, JsonMessage{
+		Message: "two fields",
+	})
+	popUser()
+	popUser() // Must be harmless
+	lg.DebugContext(userCtx, 0, "one field again")
+	popTxn()
+	lg.InfoContext(userCtx, "no fields again")
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "PushContext")), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+
+	expected := []string{
+		"[INFO]: no fields",
+		"[INFO]: one field txn=1234",
+		`[WARNING]: two fields service=billing txn=1234 user="john doe"`,
+		"",
+		"[DEBUG]: one field again txn=1234",
+		"[INFO]: no fields again",
+	}
+	for idx, s := range expected {
+		if len(s) > 0 && !strings.HasSuffix(lines[idx], s) {
+			t.Errorf("unexpected line #%v [got: %v, expected suffix: %v]", idx+1, lines[idx], s)
+		}
+	}
+
+	entry := parseTestJSONEntry(t, lines[3])
+	if entry["txn"] != float64(1234) || entry["user"] != "john doe" || entry["level"] != "error" {
+		t.Errorf("unexpected JSON entry [%v]", lines[3])
+	}
+}
+
+func TestPushContextConcurrent(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "PushContextConcurrent", nil)
+
+	// Each goroutine pushes and pops its own field while the other one is logging
+	const count = 200
+	wg := sync.WaitGroup{}
+	for _, worker := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(worker string) {
+			defer wg.Done()
+
+			for i := 0; i < count; i++ {
+				ctx, pop := logger.PushContext(context.Background(), "worker", worker)
+				lg.InfoContext(ctx, "message from worker "+worker)
+				pop()
+				lg.InfoContext(ctx, "popped message from worker "+worker)
+			}
+		}(worker)
+	}
+	wg.Wait()
+	lg.Info("message from main")
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "PushContextConcurrent")), "\n")
+	if len(lines) != 4*count+1 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+	for _, line := range lines {
+		var expected string
+		switch {
+		case strings.HasSuffix(line, "]: message from worker a"), strings.HasSuffix(line, "]: message from worker b"):
+			t.Errorf("field is missing [%v]", line)
+			continue
+		case strings.Contains(line, "popped message from worker"), strings.HasSuffix(line, "message from main"):
+			expected = ""
+		case strings.Contains(line, "message from worker a"):
+			expected = " worker=a"
+		default:
+			expected = " worker=b"
+		}
+		if strings.Contains(line, "worker=") != (len(expected) > 0) || !strings.HasSuffix(line, expected) ||
+			strings.Count(line, "worker=") > 1 {
+			t.Errorf("unexpected fields [%v]", line)
+		}
+	}
+}
+
+func TestDefaultFields(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "DefaultFields", func(opts *logger.Options) {
+		opts.DefaultFields = map[string]interface{}{
+			"service": "billing",
+			"version": "1.2.3",
+			"message": "must not replace the message",
+		}
+	})
+	lg.Info("bare message")
+	lg.With("version", "override").Info(JsonMessage{
+		Message: "struct message",
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "DefaultFields")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], `[INFO]: bare message message="must not replace the message" service=billing `+
+		`version=1.2.3`) {
+		t.Errorf("unexpected text line [%v]", lines[0])
+	}
+
+	entry := parseTestJSONEntry(t, lines[1])
+	if entry["service"] != "billing" || entry["version"] != "override" || entry["message"] != "struct message" {
+		t.Errorf("unexpected JSON entry [%v]", lines[1])
+	}
+	if strings.Count(lines[1], `"version"`) != 1 || strings.Count(lines[1], `"message"`) != 1 {
+		t.Errorf("overridden fields were added [%v]", lines[1])
+	}
+}
+
+func TestIncludeFunction(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "IncludeFunction", func(opts *logger.Options) {
+		opts.IncludeFunction = true
+	})
+	lg.Info("direct call")
+	(&functionTestService{lg: lg}).Serve()
+	_, _ = lg.LevelInferringWriter().Write([]byte("WARN: through a writer\n"))
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "IncludeFunction")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+	const pkg = "github.com/randlabs/go-logger/v2_test."
+	if !strings.HasSuffix(lines[0], "direct call func="+pkg+"TestIncludeFunction") {
+		t.Errorf("unexpected text line [%v]", lines[0])
+	}
+	if entry := parseTestJSONEntry(t, lines[1]); entry["func"] != pkg+"(*functionTestService).Serve" {
+		t.Errorf("unexpected JSON entry [%v]", lines[1])
+	}
+	// Functions of the logger are skipped
+	if !strings.HasSuffix(lines[2], "through a writer func="+pkg+"TestIncludeFunction") {
+		t.Errorf("unexpected writer line [%v]", lines[2])
+	}
+}
+
+func TestIncludeHostname(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("unable to get the host name. [%v]", err)
+	}
+
+	lg, dir := createTestFileLogger(t, "IncludeHostname", func(opts *logger.Options) {
+		opts.IncludeHostname = true
+	})
+	lg.Info("text message")
+	lg.Info(JsonMessage{
+		Message: "json message",
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "IncludeHostname")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], " ["+hostname+"] text message") {
+		t.Errorf("unexpected text line [%v]", lines[0])
+	}
+	if entry := parseTestJSONEntry(t, lines[1]); entry["host"] != hostname || entry["message"] != "json message" {
+		t.Errorf("unexpected JSON entry [%v]", lines[1])
+	}
+
+	// The override replaces the computer's host name
+	lg, dir = createTestFileLogger(t, "HostnameOverride", func(opts *logger.Options) {
+		opts.IncludeHostname = true
+		opts.HostnameOverride = "web-7f9c"
+	})
+	lg.Info("overridden")
+	lg.Destroy()
+
+	line := strings.TrimSpace(readTestLogFile(t, dir, "HostnameOverride"))
+	if !strings.HasSuffix(line, " [web-7f9c] overridden") {
+		t.Errorf("unexpected overridden line [%v]", line)
+	}
+}
+
+func TestRuntimeFields(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "RuntimeFields", func(opts *logger.Options) {
+		opts.RuntimeFields = logger.RuntimeFieldsOptions{
+			PID:          true,
+			GoVersion:    true,
+			NumGoroutine: true,
+		}
+	})
+	lg.Info("text message")
+	lg.Info(JsonMessage{
+		Message: "json message",
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "RuntimeFields")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+	if !strings.Contains(lines[0], fmt.Sprintf(" text message pid=%v go_version=%v num_goroutine=", os.Getpid(),
+		runtime.Version())) {
+		t.Errorf("unexpected text line [%v]", lines[0])
+	}
+
+	entry := parseTestJSONEntry(t, lines[1])
+	if entry["pid"] != float64(os.Getpid()) || entry["go_version"] != runtime.Version() {
+		t.Errorf("unexpected JSON entry [%v]", lines[1])
+	}
+	if n, ok := entry["num_goroutine"].(float64); !ok || n < 1 {
+		t.Errorf("unexpected amount of goroutines [%v]", entry["num_goroutine"])
+	}
+	if _, ok := entry["hostname"]; ok {
+		t.Errorf("disabled field was added [%v]", lines[1])
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+type functionTestService struct {
+	lg *logger.Logger
+}
+
+func (s *functionTestService) Serve() {
+	s.lg.Info(JsonMessage{
+		Message: "method call",
+	})
+}
@@ -1,15 +1,28 @@
 package go_logger_test
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	logger "github.com/randlabs/go-logger/v2"
+	"github.com/randlabs/go-logger/v2/syslogtest"
 )
 
 //------------------------------------------------------------------------------
 
+const exclusiveLockChildEnv = "GO_LOGGER_EXCLUSIVE_LOCK_CHILD"
+
+//------------------------------------------------------------------------------
+
 func TestFileLog(t *testing.T) {
 	if dir, err := filepath.Abs(filepath.FromSlash("./testdata/logs")); err == nil {
 		_ = os.RemoveAll(dir)
@@ -36,3 +49,892 @@ func TestFileLog(t *testing.T) {
 
 	printTestMessages(lg)
 }
+
+func TestDefaultAppName(t *testing.T) {
+	lookups := 0
+	restore := logger.SetOsExecutable(func() (string, error) {
+		lookups += 1
+		return "", errors.New("executable lookup not allowed")
+	})
+	defer restore()
+
+	logger.SetDefaultAppName("DefaultApp")
+	defer logger.SetDefaultAppName("")
+
+	dir, err := filepath.Abs(filepath.FromSlash("./testdata/logs/defaultapp"))
+	if err != nil {
+		t.Fatalf("unable to get log directory. [%v]", err)
+	}
+	_ = os.RemoveAll(dir)
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		File: &logger.FileOptions{
+			Directory: dir,
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Info("This is an information message sample")
+	lg.Destroy()
+
+	if !strings.Contains(readTestLogFile(t, dir, "DefaultApp"), "This is an information message sample") {
+		t.Errorf("the default application name was not used as the file prefix")
+	}
+	if lookups != 0 {
+		t.Errorf("the executable name was looked up")
+	}
+}
+
+func TestFileAudit(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "Audit", func(opts *logger.Options) {
+		opts.Level = logger.LogLevelError
+	})
+	defer lg.Destroy()
+
+	lg.Info("This information message should not be written")
+	lg.Audit("This is an audit message sample")
+
+	// The message must be on disk as soon as the call returns
+	content := readTestLogFile(t, dir, "Audit")
+	if !strings.Contains(content, "[AUDIT]: This is an audit message sample") {
+		t.Errorf("audit message was not written synchronously")
+	}
+	if strings.Contains(content, "information message") {
+		t.Errorf("information message was not filtered")
+	}
+}
+
+func TestFileRetryOnError(t *testing.T) {
+	var notifications int32
+
+	lg, dir := createTestFileLogger(t, "Retry", func(opts *logger.Options) {
+		opts.File.RetryOnError = true
+		opts.ErrorHandler = func(_ string) {
+			atomic.AddInt32(&notifications, 1)
+		}
+	})
+	defer lg.Destroy()
+
+	// Make writes fail by placing a regular file where the log directory should be
+	err := os.WriteFile(dir, []byte{}, 0644)
+	if err != nil {
+		t.Fatalf("unable to create blocking file. [%v]", err)
+	}
+
+	lg.Info("message #1 written while failing")
+	lg.Info("message #2 written while failing")
+	if atomic.LoadInt32(&notifications) != 1 {
+		t.Errorf("the error handler was not notified")
+	}
+
+	// Recover
+	_ = os.Remove(dir)
+	lg.Info("message #3 written after recovery")
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "Retry")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 3]", len(lines))
+	}
+	for idx, s := range []string{"message #1", "message #2", "message #3"} {
+		if !strings.Contains(lines[idx], s) {
+			t.Errorf("unexpected line #%v [%v]", idx+1, lines[idx])
+		}
+	}
+}
+
+func TestFileRetryOnErrorRenotify(t *testing.T) {
+	var notifications int32
+
+	now := time.Now()
+	restore := logger.SetTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+	restoreWrite := logger.SetFileWriteString(func(_ *os.File, _ string) (int, error) {
+		return 0, errors.New("disk full")
+	})
+	defer restoreWrite()
+
+	lg, _ := createTestFileLogger(t, "RetryRenotify", func(opts *logger.Options) {
+		opts.File.RetryOnError = true
+		opts.ErrorHandler = func(_ string) {
+			atomic.AddInt32(&notifications, 1)
+		}
+	})
+	defer lg.Destroy()
+
+	// The interval between notifications doubles while the error condition persists
+	start := now
+	for _, step := range []struct {
+		elapsed  time.Duration
+		expected int32
+	}{
+		{0, 1},
+		{4 * time.Second, 1},
+		{5 * time.Second, 2},
+		{14 * time.Second, 2},
+		{15 * time.Second, 3},
+		{34 * time.Second, 3},
+		{35 * time.Second, 4},
+	} {
+		now = start.Add(step.elapsed)
+		lg.Info("message written while failing")
+		if n := atomic.LoadInt32(&notifications); n != step.expected {
+			t.Fatalf("unexpected notifications after %v [got: %v, expected: %v]", step.elapsed, n, step.expected)
+		}
+	}
+}
+
+func TestFileRetryOnErrorDestroy(t *testing.T) {
+	var lostMessages string
+
+	lg, dir := createTestFileLogger(t, "RetryDestroy", func(opts *logger.Options) {
+		opts.File.RetryOnError = true
+	})
+
+	// Make writes fail by placing a regular file where the log directory should be
+	err := os.WriteFile(dir, []byte{}, 0644)
+	if err != nil {
+		t.Fatalf("unable to create blocking file. [%v]", err)
+	}
+	lg.Info("message #1 written while failing")
+	lg.Info("message #2 written while failing")
+
+	// Pending messages are written on destroy if the file can be opened again
+	_ = os.Remove(dir)
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "RetryDestroy")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 2]", len(lines))
+	}
+
+	// Otherwise, they are reported as lost
+	lg, dir = createTestFileLogger(t, "RetryDestroyLost", func(opts *logger.Options) {
+		opts.File.RetryOnError = true
+		opts.ErrorHandler = func(msg string) {
+			lostMessages = msg
+		}
+	})
+	err = os.WriteFile(dir, []byte{}, 0644)
+	if err != nil {
+		t.Fatalf("unable to create blocking file. [%v]", err)
+	}
+	defer func() {
+		_ = os.Remove(dir)
+	}()
+	lg.Info("message #1 written while failing")
+	lg.Info("message #2 written while failing")
+	lg.Destroy()
+
+	if !strings.Contains(lostMessages, "Unable to save 2 pending messages") {
+		t.Errorf("lost messages were not reported [%v]", lostMessages)
+	}
+}
+
+func TestFileMaxOpenFiles(t *testing.T) {
+	const maxOpenFiles = 2
+
+	loggers := make([]*logger.Logger, 5)
+	dirs := make([]string, len(loggers))
+	for idx := range loggers {
+		loggers[idx], dirs[idx] = createTestFileLogger(t, fmt.Sprintf("Tenant%v", idx), func(opts *logger.Options) {
+			// Loggers created later with a looser limit must not raise it
+			opts.MaxOpenFiles = maxOpenFiles
+			if idx > 0 {
+				opts.MaxOpenFiles = 100
+			}
+		})
+	}
+
+	// Write in round-robin so every file is closed and reopened several times
+	for round := 1; round <= 3; round++ {
+		for idx, lg := range loggers {
+			lg.Info(fmt.Sprintf("tenant #%v round #%v", idx, round))
+			if logger.OpenFileHandles() > maxOpenFiles {
+				t.Fatalf("too many open files [%v]", logger.OpenFileHandles())
+			}
+		}
+	}
+	for _, lg := range loggers {
+		lg.Destroy()
+	}
+	if logger.OpenFileHandles() != 0 {
+		t.Errorf("destroyed loggers still have open files")
+	}
+
+	for idx := range loggers {
+		lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dirs[idx], fmt.Sprintf("Tenant%v", idx))), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("unexpected number of lines in file #%v [got: %v, expected: 3]", idx, len(lines))
+		}
+		for round := 1; round <= 3; round++ {
+			if !strings.HasSuffix(lines[round-1], fmt.Sprintf("tenant #%v round #%v", idx, round)) {
+				t.Errorf("unexpected line #%v in file #%v [%v]", round, idx, lines[round-1])
+			}
+		}
+	}
+}
+
+func TestFileFixedName(t *testing.T) {
+	now := time.Date(2021, 3, 14, 23, 59, 59, 0, time.UTC)
+	restore := logger.SetTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+
+	lg, dir := createTestFileLogger(t, "FixedName", func(opts *logger.Options) {
+		opts.File.FixedName = "app.log"
+		opts.File.DaysToKeep = 1
+	})
+	defer lg.Destroy()
+
+	// Messages logged across a day boundary go to the same file
+	lg.Info("This message is logged before midnight")
+	now = now.Add(2 * time.Second)
+	lg.Info("This message is logged after midnight")
+
+	// Simulate an external rotation
+	err := os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.1"))
+	if err != nil {
+		t.Fatalf("unable to rename log file. [%v]", err)
+	}
+	lg.Reopen()
+	lg.Info("This message is logged after reopening")
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unable to read log directory. [%v]", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("unexpected number of log files [got: %v, expected: 2]", len(files))
+	}
+
+	rotated, err := os.ReadFile(filepath.Join(dir, "app.log.1"))
+	if err != nil {
+		t.Fatalf("unable to read rotated log file. [%v]", err)
+	}
+	if !strings.Contains(string(rotated), "before midnight") || !strings.Contains(string(rotated), "after midnight") {
+		t.Errorf("messages logged across the day boundary are not in the same file")
+	}
+
+	current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("unable to read log file. [%v]", err)
+	}
+	if strings.Count(string(current), "\n") != 1 || !strings.Contains(string(current), "after reopening") {
+		t.Errorf("unexpected content after reopening [%v]", string(current))
+	}
+}
+
+func TestFileTruncate(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "Truncate", func(opts *logger.Options) {
+		opts.File.FixedName = "app.log"
+	})
+	lg.Info("This message is logged by the first run")
+	lg.Destroy()
+
+	run := func(truncate bool, msg string) {
+		lg, err := logger.Create(logger.Options{
+			Console: logger.ConsoleOptions{
+				Disable: true,
+			},
+			File: &logger.FileOptions{
+				Directory: dir,
+				FixedName: "app.log",
+				Truncate:  truncate,
+			},
+			Level: logger.LogLevelInfo,
+		})
+		if err != nil {
+			t.Fatalf("unable to initialize. [%v]", err)
+		}
+		lg.Info(msg)
+		// Reopening must not empty the file again
+		lg.Reopen()
+		lg.Info(msg + " after reopening")
+		lg.Destroy()
+	}
+
+	// By default, new runs append to the file
+	run(false, "This message is logged by the second run")
+	content, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("unable to read log file. [%v]", err)
+	}
+	if !strings.Contains(string(content), "first run") || !strings.Contains(string(content), "second run") {
+		t.Errorf("unexpected log file content [%v]", string(content))
+	}
+
+	// With truncate, the content of previous runs is gone
+	run(true, "This message is logged by the third run")
+	content, err = os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatalf("unable to read log file. [%v]", err)
+	}
+	if strings.Contains(string(content), "first run") || strings.Contains(string(content), "second run") ||
+		strings.Count(string(content), "third run") != 2 {
+		t.Errorf("unexpected log file content [%v]", string(content))
+	}
+}
+
+func TestFileMaxSizeBytes(t *testing.T) {
+	const maxSize = 100
+
+	now := time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC)
+	restore := logger.SetTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+
+	lg, dir := createTestFileLogger(t, "MaxSize", func(opts *logger.Options) {
+		opts.File.MaxSizeBytes = maxSize
+	})
+	for i := 1; i <= 10; i++ {
+		lg.Info(fmt.Sprintf("Message #%02d", i))
+	}
+	lg.Destroy()
+
+	// A new run continues with the first file not yet full
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		File: &logger.FileOptions{
+			Prefix:       "MaxSize",
+			Directory:    dir,
+			MaxSizeBytes: maxSize,
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Info("Message #11")
+
+	// Daily rotation still happens and starts again without a suffix
+	now = now.AddDate(0, 0, 1)
+	lg.Info("Message #12")
+	lg.Destroy()
+
+	names := []string{
+		"maxsize.2021-03-14.log", "maxsize.2021-03-14.1.log", "maxsize.2021-03-14.2.log",
+		"maxsize.2021-03-14.3.log",
+	}
+	all := ""
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unable to read log file [%v]. [%v]", name, err)
+		}
+		// A file is only left once it reached the limit, so only its last line can exceed it
+		lines := strings.SplitAfter(strings.TrimSuffix(string(content), "\n"), "\n")
+		if len(string(content))-len(lines[len(lines)-1]) >= maxSize {
+			t.Errorf("log file [%v] grew beyond the limit [%v]", name, len(content))
+		}
+		all += string(content)
+	}
+	for i := 1; i <= 11; i++ {
+		if strings.Count(all, fmt.Sprintf("Message #%02d\n", i)) != 1 {
+			t.Errorf("message #%v not found once [%v]", i, all)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, "maxsize.2021-03-14.4.log")); err == nil {
+		t.Errorf("unexpected extra log file")
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "maxsize.2021-03-15.log"))
+	if err != nil {
+		t.Fatalf("unable to read log file of the next day. [%v]", err)
+	}
+	if !strings.HasSuffix(string(content), "Message #12\n") || strings.Count(string(content), "\n") != 1 {
+		t.Errorf("unexpected content in log file of the next day [%v]", string(content))
+	}
+}
+
+func TestFileColor(t *testing.T) {
+	for _, colored := range []bool{false, true} {
+		prefix := "NoColor"
+		if colored {
+			prefix = "Color"
+		}
+		lg, dir := createTestFileLogger(t, prefix, func(opts *logger.Options) {
+			opts.File.Color = colored
+		})
+		lg.Error("This is an error message")
+		lg.Info("This is an info message")
+		lg.Destroy()
+
+		content := readTestLogFile(t, dir, prefix)
+		if strings.Contains(content, "\x1b[") != colored {
+			t.Errorf("unexpected color escape sequences [colored: %v] [%q]", colored, content)
+		}
+		if !strings.Contains(content, "This is an error message") || !strings.Contains(content, "This is an info message") {
+			t.Errorf("unexpected log file content [%q]", content)
+		}
+		if !colored && (!strings.Contains(content, " [ERROR]: ") || !strings.Contains(content, " [INFO]: ")) {
+			t.Errorf("unexpected level labels [%q]", content)
+		}
+	}
+}
+
+func TestFilePerRunFile(t *testing.T) {
+	now := time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC)
+	restore := logger.SetTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+
+	// Create two loggers on the same day, one after the other
+	lg, dir := createTestFileLogger(t, "PerRun", func(opts *logger.Options) {
+		opts.File.PerRunFile = true
+	})
+	lg.Info("This message is logged by the first run")
+	lg.Destroy()
+
+	now = now.Add(5 * time.Second)
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		File: &logger.FileOptions{
+			Prefix:     "PerRun",
+			Directory:  dir,
+			PerRunFile: true,
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Info("This message is logged by the second run")
+	lg.Destroy()
+
+	for idx, name := range []string{"perrun.2021-03-14.100000.log", "perrun.2021-03-14.100005.log"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unable to read log file of run #%v. [%v]", idx+1, err)
+		}
+		if strings.Count(string(content), "\n") != 1 {
+			t.Errorf("unexpected content in log file of run #%v [%v]", idx+1, string(content))
+		}
+	}
+}
+
+func TestFileRingFiles(t *testing.T) {
+	modifier := func(opts *logger.Options) {
+		opts.File.RingFiles = 3
+		opts.File.RingFileSize = 100
+	}
+
+	// Each line is larger than half the file size, so each file holds two messages
+	lg, dir := createTestFileLogger(t, "RingFiles", modifier)
+	for idx := 1; idx <= 8; idx++ {
+		lg.Info(fmt.Sprintf("This is the ring message sample #%v", idx))
+	}
+	lg.Destroy()
+
+	readRingFile := func(index int) string {
+		content, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("ringfiles.%v.log", index)))
+		if err != nil {
+			t.Fatalf("unable to read ring file #%v. [%v]", index, err)
+		}
+		return string(content)
+	}
+
+	// The oldest file was overwritten by the last two messages
+	for index, expected := range [][]int{{7, 8}, {3, 4}, {5, 6}} {
+		content := readRingFile(index)
+		if strings.Count(content, "\n") != 2 {
+			t.Errorf("unexpected content in ring file #%v [%v]", index, content)
+		}
+		for _, msg := range expected {
+			if !strings.Contains(content, fmt.Sprintf("sample #%v\n", msg)) {
+				t.Errorf("message #%v not found in ring file #%v [%v]", msg, index, content)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ringfiles.3.log")); !os.IsNotExist(err) {
+		t.Errorf("ring has more files than expected")
+	}
+
+	// A new run resumes on the last file and, as it is full, moves to the next one
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		File: &logger.FileOptions{
+			Prefix:       "RingFiles",
+			Directory:    dir,
+			RingFiles:    3,
+			RingFileSize: 100,
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Info("This is the ring message sample #9")
+	lg.Destroy()
+
+	if content := readRingFile(1); strings.Count(content, "\n") != 1 || !strings.Contains(content, "sample #9") {
+		t.Errorf("unexpected content in ring file #1 after restart [%v]", content)
+	}
+}
+
+func TestFileFlushOnLevel(t *testing.T) {
+	syncs := 0
+	restore := logger.SetFileSync(func(fd *os.File) error {
+		syncs += 1
+		return fd.Sync()
+	})
+	defer restore()
+
+	// Messages are not synced by default
+	lg, _ := createTestFileLogger(t, "FlushOnLevel", nil)
+	lg.Error("This is an error message sample")
+	if syncs != 0 {
+		t.Errorf("file was synced without FlushOnLevel [syncs: %v]", syncs)
+	}
+	lg.Destroy()
+
+	// Errors are synced right away while less severe messages are not
+	level := logger.LogLevelError
+	lg, _ = createTestFileLogger(t, "FlushOnLevel", func(opts *logger.Options) {
+		opts.File.FlushOnLevel = &level
+	})
+	lg.Info("This is an information message sample")
+	if syncs != 0 {
+		t.Errorf("file was synced after an info message [syncs: %v]", syncs)
+	}
+	lg.Error("This is an error message sample")
+	if syncs != 1 {
+		t.Errorf("file was not synced after an error message [syncs: %v]", syncs)
+	}
+	lg.Destroy()
+
+	// The threshold can be lowered
+	syncs = 0
+	level = logger.LogLevelWarning
+	lg, _ = createTestFileLogger(t, "FlushOnLevel", func(opts *logger.Options) {
+		opts.File.FlushOnLevel = &level
+	})
+	lg.Info("This is an information message sample")
+	lg.Warning("This is a warning message sample")
+	if syncs != 1 {
+		t.Errorf("file was not synced after a warning message [syncs: %v]", syncs)
+	}
+	lg.Destroy()
+}
+
+func TestFileSyncDir(t *testing.T) {
+	var handledErrors []string
+
+	lg, dir := createTestFileLogger(t, "SyncDir", func(opts *logger.Options) {
+		opts.File.SyncDir = true
+		opts.ErrorHandler = func(message string) {
+			handledErrors = append(handledErrors, message)
+		}
+	})
+	lg.Info("This is an information message sample")
+	lg.Destroy()
+
+	if len(handledErrors) != 0 {
+		t.Errorf("unexpected errors [%v]", handledErrors)
+	}
+	if content := readTestLogFile(t, dir, "SyncDir"); !strings.Contains(content, "information message") {
+		t.Errorf("unexpected log file content [%v]", content)
+	}
+}
+
+func TestFilePIDFile(t *testing.T) {
+	var opts logger.Options
+
+	lg, dir := createTestFileLogger(t, "PIDFile", func(o *logger.Options) {
+		o.File.WritePIDFile = true
+		opts = *o
+	})
+	pidFile := filepath.Join(dir, "pidfile.pid")
+	readPID := func() string {
+		content, err := os.ReadFile(pidFile)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(content))
+	}
+
+	if pid := readPID(); pid != fmt.Sprint(os.Getpid()) {
+		t.Fatalf("unexpected PID file content [%v]", pid)
+	}
+
+	// Reconfiguring keeps the file
+	if err := lg.Reconfigure(opts); err != nil {
+		t.Fatalf("unable to reconfigure. [%v]", err)
+	}
+	if pid := readPID(); pid != fmt.Sprint(os.Getpid()) {
+		t.Errorf("PID file was lost after reconfiguring [%v]", pid)
+	}
+
+	lg.Destroy()
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("PID file was not removed [%v]", err)
+	}
+
+	// A file left by a crashed run is replaced
+	_ = os.WriteFile(pidFile, []byte("999999999\n"), 0644)
+	lg, err := logger.Create(opts)
+	if err != nil {
+		t.Fatalf("stale PID file was not replaced. [%v]", err)
+	}
+	if pid := readPID(); pid != fmt.Sprint(os.Getpid()) {
+		t.Errorf("unexpected PID file content [%v]", pid)
+	}
+	lg.Destroy()
+
+	// A file of a running process is not
+	_ = os.WriteFile(pidFile, []byte(fmt.Sprintf("%v\n", os.Getppid())), 0644)
+	if lg, err = logger.Create(opts); err == nil {
+		lg.Destroy()
+		t.Errorf("PID file of a running process was replaced")
+	}
+}
+
+func TestFileExclusiveLock(t *testing.T) {
+	if os.Getenv(exclusiveLockChildEnv) != "" {
+		// Hold the lock until killed
+		lg, _ := createTestFileLogger(t, "ExclusiveLock", func(opts *logger.Options) {
+			opts.File.ExclusiveLock = true
+		})
+		defer lg.Destroy()
+		_, _ = os.Stdout.WriteString("ready\n")
+		time.Sleep(time.Minute)
+		return
+	}
+
+	// Start another process holding the lock
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFileExclusiveLock$")
+	cmd.Env = append(os.Environ(), exclusiveLockChildEnv+"=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("unable to get the child output. [%v]", err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatalf("unable to start the child. [%v]", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	line, _ := bufio.NewReader(stdout).ReadString('\n')
+	if line != "ready\n" {
+		t.Fatalf("unexpected child output [%v]", line)
+	}
+
+	dir, _ := filepath.Abs(filepath.FromSlash("./testdata/logs/exclusivelock"))
+	opts := logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		File: &logger.FileOptions{
+			Prefix:        "ExclusiveLock",
+			Directory:     dir,
+			ExclusiveLock: true,
+		},
+		Level: logger.LogLevelInfo,
+	}
+	lg, err := logger.Create(opts)
+	if err == nil {
+		lg.Destroy()
+		t.Fatalf("lock held by another process was acquired")
+	}
+
+	// The lock is available once the other process exits
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	lg, err = logger.Create(opts)
+	if err != nil {
+		t.Fatalf("unable to acquire the released lock. [%v]", err)
+	}
+
+	// Reconfiguring keeps it
+	if err = lg.Reconfigure(opts); err != nil {
+		t.Errorf("unable to reconfigure. [%v]", err)
+	}
+	lg.Destroy()
+}
+
+func TestFileWriteRetries(t *testing.T) {
+	var handledErrors []string
+
+	failures := 0
+	restore := logger.SetFileWriteString(func(fd *os.File, s string) (int, error) {
+		if strings.Contains(s, "interrupted") && failures < 2 {
+			failures += 1
+			return 0, syscall.EINTR
+		}
+		if strings.Contains(s, "fatal") {
+			failures += 1
+			return 0, errors.New("no space left on device")
+		}
+		return fd.WriteString(s)
+	})
+	defer restore()
+
+	lg, dir := createTestFileLogger(t, "WriteRetries", func(opts *logger.Options) {
+		opts.File.WriteRetries = 3
+		opts.ErrorHandler = func(message string) {
+			handledErrors = append(handledErrors, message)
+		}
+	})
+
+	// Transient errors are retried until the write succeeds on the third try
+	lg.Info("This write is interrupted twice")
+	if failures != 2 || len(handledErrors) != 0 {
+		t.Errorf("transient errors were not retried [failures: %v, errors: %v]", failures, handledErrors)
+	}
+
+	// Other errors are not retried
+	failures = 0
+	lg.Info("This write has a fatal error")
+	if failures != 1 || len(handledErrors) != 1 {
+		t.Errorf("fatal errors were retried [failures: %v, errors: %v]", failures, handledErrors)
+	}
+	lg.Destroy()
+
+	content := readTestLogFile(t, dir, "WriteRetries")
+	if !strings.Contains(content, "interrupted twice") || strings.Contains(content, "fatal error") {
+		t.Errorf("unexpected log file content [%v]", content)
+	}
+}
+
+func TestFileErrorHandler(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
+
+	restore := logger.SetFileWriteString(func(fd *os.File, s string) (int, error) {
+		return 0, errors.New("no space left on device")
+	})
+	defer restore()
+
+	var fileErrors, sysLogErrors, globalErrors []string
+	lg, _ := createTestFileLogger(t, "ErrorHandler", func(opts *logger.Options) {
+		opts.ErrorHandler = func(message string) {
+			globalErrors = append(globalErrors, message)
+		}
+		opts.File.ErrorHandler = func(message string) {
+			fileErrors = append(fileErrors, message)
+		}
+		opts.SysLog = &logger.SysLogOptions{
+			Host: "127.0.0.1",
+			Port: srv.Port(),
+			ErrorHandler: func(message string) {
+				sysLogErrors = append(sysLogErrors, message)
+			},
+		}
+	})
+	lg.Error("This is an error message sample")
+	lg.Destroy()
+
+	if len(fileErrors) != 1 || !strings.Contains(fileErrors[0], "no space left on device") {
+		t.Errorf("file error was not notified to the file handler [%v]", fileErrors)
+	}
+	if len(sysLogErrors) > 0 || len(globalErrors) > 0 {
+		t.Errorf("file error was notified to other handlers [syslog: %v, global: %v]", sysLogErrors,
+			globalErrors)
+	}
+}
+
+func TestFileSync(t *testing.T) {
+	lg, _ := createTestFileLogger(t, "Sync", nil)
+	defer lg.Destroy()
+
+	lg.Info("This is an information message sample")
+	if err := lg.Sync(); err != nil {
+		t.Fatalf("unable to sync. [%v]", err)
+	}
+
+	// The read end of a pipe cannot be synced
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unable to create pipe. [%v]", err)
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+	logger.ReplaceLogFile(lg, r)
+
+	err = lg.Sync()
+	if err == nil || !strings.HasPrefix(err.Error(), "file: ") {
+		t.Errorf("unexpected sync error [%v]", err)
+	}
+}
+
+func TestFileLevelFiles(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "LevelFiles", func(opts *logger.Options) {
+		opts.File.LevelFiles = map[logger.LogLevel]string{
+			logger.LogLevelError: "errors",
+		}
+	})
+
+	lg.Info("This is an information message sample")
+	lg.Error("This is an error message sample")
+	lg.Destroy()
+
+	content := readTestLogFile(t, dir, "LevelFiles")
+	if !strings.Contains(content, "This is an information message sample") ||
+		!strings.Contains(content, "This is an error message sample") {
+		t.Errorf("unexpected log file content [%v]", content)
+	}
+
+	content = readTestLogFile(t, dir, "LevelFiles.errors")
+	if strings.Contains(content, "This is an information message sample") ||
+		!strings.Contains(content, "This is an error message sample") {
+		t.Errorf("unexpected level file content [%v]", content)
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func createTestFileLogger(t *testing.T, prefix string, modifier func(opts *logger.Options)) (*logger.Logger, string) {
+	dir, err := filepath.Abs(filepath.FromSlash("./testdata/logs/" + strings.ToLower(prefix)))
+	if err != nil {
+		t.Fatalf("unable to get log directory. [%v]", err)
+	}
+	_ = os.RemoveAll(dir)
+
+	opts := logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		File: &logger.FileOptions{
+			Prefix:    prefix,
+			Directory: dir,
+		},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	}
+	if modifier != nil {
+		modifier(&opts)
+	}
+
+	lg, err := logger.Create(opts)
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg, dir
+}
+
+func readTestLogFile(t *testing.T, dir string, prefix string) string {
+	filename := filepath.Join(dir, strings.ToLower(prefix)+"."+time.Now().UTC().Format("2006-01-02")+".log")
+	content, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("unable to read log file. [%v]", err)
+	}
+	return string(content)
+}
@@ -0,0 +1,169 @@
+package go_logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+type gelfTestServer struct {
+	conn     net.PacketConn
+	messages chan map[string]interface{}
+}
+
+func TestGELF(t *testing.T) {
+	srv := startTestGELFServer(t)
+	defer srv.conn.Close()
+
+	lg := createTestGELFLogger(t, srv, 256)
+	defer lg.Destroy()
+
+	lg.Warning(struct {
+		Message string `json:"message"`
+		Id      int    `json:"id"`
+		User    string `json:"user"`
+		Tags    []int  `json:"tags"`
+	}{
+		Message: "login failed",
+		Id:      42,
+		User:    "alice",
+		Tags:    []int{1, 2},
+	})
+
+	msg := srv.next(t)
+	if msg["version"] != "1.1" || msg["host"] != "test-host" || msg["short_message"] != "login failed" {
+		t.Fatalf("unexpected GELF header fields [%v]", msg)
+	}
+	if msg["level"] != float64(4) {
+		t.Errorf("unexpected GELF level [%v]", msg["level"])
+	}
+	if _, ok := msg["timestamp"].(float64); !ok {
+		t.Errorf("missing GELF timestamp [%v]", msg)
+	}
+	if msg["_id_"] != float64(42) || msg["_user"] != "alice" || msg["_tags"] != "[1,2]" {
+		t.Errorf("unexpected GELF additional fields [%v]", msg)
+	}
+	if _, ok := msg["_message"]; ok {
+		t.Errorf("message field also sent as an additional field [%v]", msg)
+	}
+
+	// Plain text messages with several lines
+	lg.Info("first line\nsecond line")
+	msg = srv.next(t)
+	if msg["short_message"] != "first line" || msg["full_message"] != "first line\nsecond line" {
+		t.Errorf("unexpected GELF text message [%v]", msg)
+	}
+	if msg["level"] != float64(6) {
+		t.Errorf("unexpected GELF level [%v]", msg["level"])
+	}
+}
+
+func TestGELFChunking(t *testing.T) {
+	srv := startTestGELFServer(t)
+	defer srv.conn.Close()
+
+	lg := createTestGELFLogger(t, srv, 128)
+	defer lg.Destroy()
+
+	long := strings.Repeat("0123456789", 200)
+	lg.Error(long)
+
+	msg := srv.next(t)
+	if msg["short_message"] != long {
+		t.Fatalf("chunked GELF message was not reassembled properly")
+	}
+	if msg["level"] != float64(3) {
+		t.Errorf("unexpected GELF level [%v]", msg["level"])
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func startTestGELFServer(t *testing.T) *gelfTestServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to start GELF server. [%v]", err)
+	}
+	srv := &gelfTestServer{
+		conn:     conn,
+		messages: make(chan map[string]interface{}, 16),
+	}
+	go srv.run()
+	return srv
+}
+
+func (srv *gelfTestServer) run() {
+	pending := make(map[string][][]byte)
+
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := srv.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		packet := append([]byte(nil), buf[:n]...)
+
+		if len(packet) >= 12 && packet[0] == 0x1e && packet[1] == 0x0f {
+			// Reassemble chunks by message id and sequence number
+			id := string(packet[2:10])
+			seq, count := int(packet[10]), int(packet[11])
+			if pending[id] == nil {
+				pending[id] = make([][]byte, count)
+			}
+			pending[id][seq] = packet[12:]
+
+			complete := true
+			for _, chunk := range pending[id] {
+				if chunk == nil {
+					complete = false
+				}
+			}
+			if !complete {
+				continue
+			}
+			packet = bytes.Join(pending[id], nil)
+			delete(pending, id)
+		}
+
+		msg := make(map[string]interface{})
+		if json.Unmarshal(packet, &msg) == nil {
+			srv.messages <- msg
+		}
+	}
+}
+
+func (srv *gelfTestServer) next(t *testing.T) map[string]interface{} {
+	select {
+	case msg := <-srv.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout while waiting for GELF message")
+	}
+	return nil
+}
+
+func createTestGELFLogger(t *testing.T, srv *gelfTestServer, maxChunkSize uint) *logger.Logger {
+	addr := srv.conn.LocalAddr().(*net.UDPAddr)
+
+	lg, err := logger.Create(logger.Options{
+		GELF: &logger.GELFOptions{
+			Host:         addr.IP.String(),
+			Port:         uint16(addr.Port),
+			MaxChunkSize: maxChunkSize,
+			Hostname:     "test-host",
+		},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg
+}
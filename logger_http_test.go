@@ -0,0 +1,182 @@
+package go_logger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+	"github.com/randlabs/go-logger/v2/syslogtest"
+)
+
+//------------------------------------------------------------------------------
+
+func TestHTTPHandler(t *testing.T) {
+	adapter := &testAdapter{}
+	lg := createTestAdapterLogger(t, adapter)
+	defer lg.Destroy()
+
+	handler := lg.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items?id=1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	entries := adapter.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("unexpected number of entries [got: %v, expected: 2]", len(entries))
+	}
+
+	expected := []struct {
+		level  logger.LogLevel
+		method string
+		path   string
+		status float64
+		bytes  float64
+	}{
+		{level: logger.LogLevelInfo, method: "POST", path: "/items", status: 201, bytes: 5},
+		{level: logger.LogLevelError, method: "GET", path: "/fail", status: 500, bytes: 0},
+	}
+	for idx, e := range expected {
+		var fields map[string]interface{}
+
+		if !entries[idx].raw || entries[idx].level != e.level {
+			t.Errorf("unexpected entry #%v [%+v]", idx+1, entries[idx])
+			continue
+		}
+		err := json.Unmarshal([]byte(entries[idx].msg), &fields)
+		if err != nil {
+			t.Fatalf("unable to parse JSON entry. [%v]", err)
+		}
+		if fields["method"] != e.method || fields["path"] != e.path || fields["status"] != e.status ||
+			fields["bytes"] != e.bytes {
+			t.Errorf("unexpected fields in entry #%v [%v]", idx+1, entries[idx].msg)
+		}
+		if _, ok := fields["durationMs"].(float64); !ok {
+			t.Errorf("missing duration in entry #%v [%v]", idx+1, entries[idx].msg)
+		}
+	}
+}
+
+func TestLevelHTTPHandler(t *testing.T) {
+	lg, _ := createTestFileLogger(t, "LevelHTTPHandler", func(opts *logger.Options) {
+		opts.Level = logger.LogLevelInfo
+		opts.DebugLevel = 0
+	})
+	defer lg.Destroy()
+
+	handler := lg.LevelHTTPHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"file":{"level":"info","debugLevel":0}}` {
+		t.Errorf("unexpected GET response [%v: %v]", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/",
+		strings.NewReader(`{"class":"file","level":"debug","debugLevel":2}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("unexpected POST response [%v: %v]", rec.Code, rec.Body.String())
+	}
+	level, debugLevel, ok := lg.GetLevel("file")
+	if !ok || level != logger.LogLevelDebug || debugLevel != 2 {
+		t.Errorf("level was not changed [got: %v/%v, expected: %v/2]", level, debugLevel, logger.LogLevelDebug)
+	}
+
+	// Invalid requests
+	for _, body := range []string{`{"class":"file","level":"verbose"}`, `{"class":"nope","level":"info"}`, `{`} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("unexpected response to %v [got: %v, expected: %v]", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
+
+	restore := logger.SetFileWriteString(func(fd *os.File, s string) (int, error) {
+		return 0, errors.New("no space left on device")
+	})
+	defer restore()
+
+	lg, _ := createTestFileLogger(t, "Metrics", func(opts *logger.Options) {
+		opts.SysLog = &logger.SysLogOptions{
+			Host: "127.0.0.1",
+			Port: srv.Port(),
+		}
+	})
+	defer lg.Destroy()
+
+	lg.Error("This is an error message sample")
+	lg.Error("This is another error message sample")
+	lg.Warning("This is a warning message sample")
+	lg.Info("This is an information message sample")
+	lg.Info("This is another information message sample")
+	lg.Debug(1, "This is a debug message sample")
+	lg.Debug(2, "This debug message is discarded")
+	lg.Audit("This is an audit message sample")
+	checkTestSysLogMessages(t, srv, 7)
+	_ = lg.Sync()
+
+	rec := httptest.NewRecorder()
+	lg.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected response [%v] [%v]", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	// Every sample must be well-formed and preceded by the type of its metric
+	sampleRe := regexp.MustCompile(`^([a-z_]+)(\{[a-z]+="[^"]*"\})? [0-9]+$`)
+	typed := make(map[string]bool)
+	samples := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n") {
+		if strings.HasPrefix(line, "# TYPE ") {
+			typed[strings.Fields(line)[2]] = true
+			continue
+		}
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		m := sampleRe.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("malformed metric line [%v]", line)
+			continue
+		}
+		if !typed[m[1]] {
+			t.Errorf("metric without type [%v]", line)
+		}
+		samples[line] = true
+	}
+
+	for _, expected := range []string{
+		`logger_messages_total{level="error"} 2`,
+		`logger_messages_total{level="warning"} 1`,
+		`logger_messages_total{level="info"} 2`,
+		`logger_messages_total{level="debug"} 1`,
+		`logger_messages_total{level="audit"} 1`,
+		`logger_adapter_errors_total{class="file"} 1`,
+		`logger_adapter_errors_total{class="syslog"} 0`,
+		`logger_syslog_queue_length 0`,
+		`logger_syslog_sent_total 7`,
+		`logger_syslog_dropped_total 0`,
+	} {
+		if !samples[expected] {
+			t.Errorf("metric line not found [%v] in [%v]", expected, rec.Body.String())
+		}
+	}
+}
+
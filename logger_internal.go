@@ -2,12 +2,26 @@ package go_logger
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 //------------------------------------------------------------------------------
 
+var osExecutable = os.Executable
+
+var timeNow = time.Now
+
+//------------------------------------------------------------------------------
+
 type globalOptions struct {
 	// Set the initial logging level to use.
 	Level LogLevel
@@ -17,12 +31,888 @@ type globalOptions struct {
 
 	// A callback to call if an internal error is encountered.
 	ErrorHandler ErrorHandler
+
+	// Maximum amount of log files to keep open at the same time.
+	MaxOpenFiles int
+
+	// Layout to use for the timestamp of plain text messages.
+	TimestampFormat string
+
+	// Interval to notify again an error condition that persists. Zero means only the first failure is notified.
+	ErrorNotifyInterval time.Duration
+
+	// Set to 1, atomically, when the target is temporarily disabled.
+	Disabled int32
 }
 
+// syncErrors combines the errors returned by the targets while syncing.
+type syncErrors []error
+
 //------------------------------------------------------------------------------
 
+// isEnabled returns true if the target is not disabled and a message of the given level passes the level filter.
+func (g *globalOptions) isEnabled(level LogLevel, debugLevel uint) bool {
+	if g.isDisabled() {
+		return false
+	}
+	return g.passesLevel(level, debugLevel)
+}
+
+// passesLevel returns true if a message of the given level passes the level filter. Audit messages always pass it.
+func (g *globalOptions) passesLevel(level LogLevel, debugLevel uint) bool {
+	if level == logLevelAudit {
+		return true
+	}
+	return g.Level >= level && (level != LogLevelDebug || g.DebugLevel >= debugLevel)
+}
+
+func (g *globalOptions) isDisabled() bool {
+	return atomic.LoadInt32(&g.Disabled) != 0
+}
+
+// shouldNotifyError returns true if the error handler must be called: on the first failure after a success and, if
+// ErrorNotifyInterval is set, every time the interval elapses while failures persist. Pass a nil error on success.
+func (g *globalOptions) shouldNotifyError(err error, lastWasError *int32, nextNotify *int64) bool {
+	if err == nil {
+		atomic.StoreInt32(lastWasError, 0)
+		return false
+	}
+
+	now := time.Now().UnixNano()
+	if atomic.CompareAndSwapInt32(lastWasError, 0, 1) {
+		atomic.StoreInt64(nextNotify, now+int64(g.ErrorNotifyInterval))
+		return true
+	}
+	if g.ErrorNotifyInterval > 0 {
+		next := atomic.LoadInt64(nextNotify)
+		if now >= next && atomic.CompareAndSwapInt64(nextNotify, next, now+int64(g.ErrorNotifyInterval)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *globalOptions) setEnabled(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&g.Disabled, 0)
+	} else {
+		atomic.StoreInt32(&g.Disabled, 1)
+	}
+}
+
+func (e syncErrors) Error() string {
+	msgs := make([]string, len(e))
+	for idx, err := range e {
+		msgs[idx] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// localHostname returns the host name of the computer.
+func localHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "localhost"
+	}
+	return hostname
+}
+
+// addRuntimeFields appends the static runtime fields to the default fields, unless a default field has the same
+// name. Must be called within an exclusive lock or during creation.
+func (lg *Logger) addRuntimeFields(opts RuntimeFieldsOptions) {
+	runtimeFields := make([]field, 0, 4)
+	if opts.PID {
+		runtimeFields = append(runtimeFields, field{key: "pid", value: os.Getpid()})
+	}
+	if opts.GoVersion {
+		runtimeFields = append(runtimeFields, field{key: "go_version", value: runtime.Version()})
+	}
+	if opts.Hostname {
+		runtimeFields = append(runtimeFields, field{key: "hostname", value: localHostname()})
+	}
+
+	hasDefault := func(key string) bool {
+		for _, f := range lg.defaultFields {
+			if f.key == key {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rf := range runtimeFields {
+		if !hasDefault(rf.key) {
+			lg.defaultFields = append(lg.defaultFields, rf)
+		}
+	}
+
+	// The amount of goroutines is sampled for each message
+	lg.numGoroutine = opts.NumGoroutine && !hasDefault("num_goroutine")
+}
+
+// setOptions applies the logger-wide settings. Must be called within an exclusive lock or during creation.
+func (lg *Logger) setOptions(opts Options) {
+	lg.useLocalTime = opts.UseLocalTime
+	lg.swallowPanics = opts.SwallowPanics
+	lg.includeSeq = opts.IncludeSequence
+	lg.warnDestroyed = opts.WarnOnUseAfterDestroy
+	lg.maxPayload = opts.MaxPayloadBytes
+	lg.textScalars = opts.TextScalars
+	lg.structText = opts.StructTextMode
+	lg.transform = opts.Transform
+	lg.skipEmpty = opts.SkipEmpty
+	lg.headTail = nil
+	if opts.TruncateHeadTail != nil {
+		headTail := *opts.TruncateHeadTail
+		lg.headTail = &headTail
+	}
+	lg.includeFunc = opts.IncludeFunction
+	lg.hostname = ""
+	if opts.IncludeHostname {
+		lg.hostname = opts.HostnameOverride
+		if len(lg.hostname) == 0 {
+			lg.hostname = localHostname()
+		}
+	}
+	lg.friendlyDur = opts.FriendlyDurations
+	lg.defaultFields = make([]field, 0, len(opts.DefaultFields))
+	for key, value := range opts.DefaultFields {
+		lg.defaultFields = append(lg.defaultFields, field{
+			key:   key,
+			value: value,
+		})
+	}
+	sort.Slice(lg.defaultFields, func(i, j int) bool {
+		return lg.defaultFields[i].key < lg.defaultFields[j].key
+	})
+	lg.addRuntimeFields(opts.RuntimeFields)
+	lg.debugCats = make(map[string]struct{}, len(opts.EnabledDebugCategories))
+	for _, category := range opts.EnabledDebugCategories {
+		lg.debugCats[category] = struct{}{}
+	}
+	lg.errorHandler = opts.ErrorHandler
+	lg.fatalCode = opts.FatalExitCodeDefault
+	if lg.fatalCode == 0 {
+		lg.fatalCode = defaultFatalExitCode
+	}
+	lg.fatalCodeFn = opts.FatalExitCodeFunc
+	lg.stackDepth = opts.StackTraceMaxDepth
+	schedule, _ := parseLevelSchedule(opts.LevelSchedule) // Already validated
+	lg.setLevelSchedule(schedule)
+	if lg.errorBurst != nil {
+		lg.errorBurst.stop()
+	}
+	lg.errorBurst = nil
+	if opts.ErrorBurst != nil && opts.ErrorBurst.Threshold > 0 {
+		lg.errorBurst = newErrorBurstDetector(*opts.ErrorBurst)
+	}
+	// The previous deduplicator, if any, is stopped by the caller
+	if opts.DedupByCallSite > 0 {
+		lg.dedup = newCallSiteDedup(lg, opts.DedupByCallSite)
+	}
+	lg.jsonLevelEnc = opts.JSONLevelEncoder
+	lg.textTsFormat = withTimePrecision(textTimestampFormat, opts.TimePrecision)
+	lg.jsonTsFormat = opts.JSONTimestampFormat
+	if len(lg.jsonTsFormat) == 0 {
+		if opts.LegacyJSONTimestamp {
+			lg.jsonTsFormat = withTimePrecision(LegacyJSONTimestampFormat, opts.TimePrecision)
+		} else {
+			lg.jsonTsFormat = withTimePrecision(DefaultJSONTimestampFormat, opts.TimePrecision)
+		}
+	}
+
+	// Arm the hint about suppressed debug messages if debug level is enabled but no debug message can be output
+	if opts.Level >= LogLevelDebug && opts.DebugLevel == 0 && !hasLevelOverrides(opts) {
+		atomic.StoreInt32(&lg.debugHint, 1)
+	} else {
+		atomic.StoreInt32(&lg.debugHint, 0)
+	}
+}
+
+// notifyDebugSuppressed tells the error handler, only once, that debug messages are being discarded because the
+// debug level is zero.
+func (lg *Logger) notifyDebugSuppressed() {
+	if !atomic.CompareAndSwapInt32(&lg.debugHint, 1, 0) {
+		return
+	}
+
+	lg.mtx.RLock()
+	errorHandler := lg.errorHandler
+	lg.mtx.RUnlock()
+
+	if errorHandler != nil {
+		errorHandler("debug messages suppressed: DebugLevel is 0")
+	}
+}
+
+// notifyDefaultsUsed tells the error handler, once per call, about each target whose options were set but left
+// empty, as that usually means a misconfiguration rather than a wish to use all the defaults.
+func notifyDefaultsUsed(opts Options) {
+	if opts.SuppressDefaultsHint || opts.ErrorHandler == nil {
+		return
+	}
+
+	if opts.File != nil && reflect.ValueOf(*opts.File).IsZero() {
+		opts.ErrorHandler("file options are empty: logging to the logs directory with the application name as prefix")
+	}
+	if opts.SysLog != nil && reflect.ValueOf(*opts.SysLog).IsZero() {
+		opts.ErrorHandler("syslog options are empty: sending messages to 127.0.0.1:514 using UDP")
+	}
+	if opts.GELF != nil && reflect.ValueOf(*opts.GELF).IsZero() {
+		opts.ErrorHandler("GELF options are empty: sending messages to 127.0.0.1:12201 using UDP")
+	}
+}
+
+func hasLevelOverrides(opts Options) bool {
+	if opts.Console.Level != nil || opts.Console.DebugLevel != nil {
+		return true
+	}
+	if opts.File != nil && (opts.File.Level != nil || opts.File.DebugLevel != nil) {
+		return true
+	}
+	if opts.SysLog != nil && (opts.SysLog.Level != nil || opts.SysLog.DebugLevel != nil) {
+		return true
+	}
+	if opts.Pipe != nil && (opts.Pipe.Level != nil || opts.Pipe.DebugLevel != nil) {
+		return true
+	}
+	return false
+}
+
+func createAdapters(lg *Logger, opts Options) ([]internalLogger, error) {
+	adapters := make([]internalLogger, 0)
+
+	if !opts.TimePrecision.isValid() {
+		return nil, fmt.Errorf("invalid time precision [%v]", opts.TimePrecision)
+	}
+	if !opts.StructTextMode.isValid() {
+		return nil, fmt.Errorf("invalid struct text mode [%v]", opts.StructTextMode)
+	}
+	if _, err := parseLevelSchedule(opts.LevelSchedule); err != nil {
+		return nil, err
+	}
+	if !opts.AllowDuplicateSinks {
+		if err := checkDuplicateSinks(opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Initialize global options
+	glbOpts := globalOptions{
+		Level:               opts.Level,
+		DebugLevel:          opts.DebugLevel,
+		ErrorHandler:        opts.ErrorHandler,
+		MaxOpenFiles:        opts.MaxOpenFiles,
+		TimestampFormat:     withTimePrecision(textTimestampFormat, opts.TimePrecision),
+		ErrorNotifyInterval: opts.ErrorNotifyInterval,
+	}
+	if opts.MaxOpenFiles > 0 {
+		sharedFileHandles.setLimit(opts.MaxOpenFiles)
+	}
+
+	// globalsFor returns the global options for a target of the given class, replacing the global error handler with
+	// the target's own one, if any, and counting the errors it notifies
+	globalsFor := func(class string, errorHandler ErrorHandler) globalOptions {
+		g := glbOpts
+		if errorHandler != nil {
+			g.ErrorHandler = errorHandler
+		}
+		g.ErrorHandler = lg.metrics.errorCounter(class, g.ErrorHandler)
+		if opts.LogInternalErrors {
+			g.ErrorHandler = lg.internalErrorHandler(class, g.ErrorHandler)
+		}
+		return g
+	}
+
+	// Create console adapter
+	if !opts.Console.Disable {
+		adapter := createConsoleAdapter(opts.Console, globalsFor("console", opts.Console.ErrorHandler))
+
+		// Add to list of adapters
+		adapters = append(adapters, adapter)
+	}
+
+	// Create file adapter if opts were specified
+	if opts.File != nil {
+		adapter, err := createFileAdapter(*opts.File, globalsFor("file", opts.File.ErrorHandler))
+		if err != nil {
+			destroyAdapters(adapters)
+			return nil, err
+		}
+
+		// Add to list of adapters
+		adapters = append(adapters, adapter)
+	}
+
+	// Create syslog adapter if opts were specified
+	if opts.SysLog != nil {
+		adapter, err := createSysLogAdapter(*opts.SysLog, globalsFor("syslog", opts.SysLog.ErrorHandler))
+		if err != nil {
+			destroyAdapters(adapters)
+			return nil, err
+		}
+
+		// Add to list of adapters
+		adapters = append(adapters, adapter)
+	}
+
+	// Create GELF adapter if opts were specified
+	if opts.GELF != nil {
+		adapter, err := createGELFAdapter(*opts.GELF, globalsFor("gelf", nil))
+		if err != nil {
+			destroyAdapters(adapters)
+			return nil, err
+		}
+
+		// Add to list of adapters
+		adapters = append(adapters, adapter)
+	}
+
+	// Create CloudWatch adapter if opts were specified
+	if opts.CloudWatch != nil {
+		adapter, err := createCloudWatchAdapter(*opts.CloudWatch, globalsFor("cloudwatch", nil))
+		if err != nil {
+			destroyAdapters(adapters)
+			return nil, err
+		}
+
+		// Add to list of adapters
+		adapters = append(adapters, adapter)
+	}
+
+	// Create OTLP adapter if opts were specified
+	if opts.OTLP != nil {
+		adapter, err := createOTLPAdapter(*opts.OTLP, globalsFor("otlp", nil))
+		if err != nil {
+			destroyAdapters(adapters)
+			return nil, err
+		}
+
+		// Add to list of adapters
+		adapters = append(adapters, adapter)
+	}
+
+	// Create pipe adapter if opts were specified
+	if opts.Pipe != nil {
+		adapter, err := createPipeAdapter(*opts.Pipe, globalsFor("pipe", nil))
+		if err != nil {
+			destroyAdapters(adapters)
+			return nil, err
+		}
+
+		// Add to list of adapters
+		adapters = append(adapters, adapter)
+	}
+
+	// Create custom adapters
+	for _, customAdapter := range opts.Adapters {
+		adapters = append(adapters, createCustomAdapter(customAdapter, globalsFor(customAdapter.Class(), nil)))
+	}
+
+	// Done
+	return adapters, nil
+}
+
+// internalErrorHandler returns an error handler that calls the user one, if any, and also logs the error through
+// the targets not belonging to the given class.
+func (lg *Logger) internalErrorHandler(class string, next ErrorHandler) ErrorHandler {
+	return func(message string) {
+		if next != nil {
+			next(message)
+		}
+
+		// Adapters may notify errors while a message is being dispatched and the shared lock is held, so log the
+		// error from another goroutine to avoid taking the lock recursively
+		go lg.logInternalError(class, message)
+	}
+}
+
+// logInternalError logs an error reported by a target, at warning level, through the targets of other classes.
+func (lg *Logger) logInternalError(class string, message string) {
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
+
+	if lg.destroyed {
+		return
+	}
+
+	now := lg.getTimestamp()
+	msg := "Internal error in " + class + " target: " + message
+	for _, adapter := range lg.adapters {
+		if adapter.class() != class {
+			dispatch(adapter, LogLevelWarning, 0, now, msg, false, msg, "")
+		}
+	}
+}
+
+// checkDuplicateSinks returns an error if two targets would write to the same files, or the same custom adapter is
+// added twice, so messages would be written twice and file rotation would be corrupted.
+func checkDuplicateSinks(opts Options) error {
+	sinks := make(map[string]string)
+	addSink := func(key string, class string) error {
+		if other, found := sinks[key]; found {
+			return fmt.Errorf("%v and %v targets write to the same destination [%v]", other, class,
+				key[strings.IndexByte(key, ':')+1:])
+		}
+		sinks[key] = class
+		return nil
+	}
+
+	if opts.File != nil {
+		key, err := fileSinkKey(*opts.File)
+		if err != nil {
+			return err
+		}
+		if err = addSink(key, "file"); err != nil {
+			return err
+		}
+
+		fileOpts := *opts.File
+		if len(fileOpts.LevelFiles) > 0 && len(fileOpts.Prefix) == 0 {
+			fileOpts.Prefix, err = getDefaultAppName()
+			if err != nil {
+				return err
+			}
+		}
+		for _, suffix := range fileOpts.LevelFiles {
+			key, err = fileSinkKey(levelFileOptions(fileOpts, suffix))
+			if err != nil {
+				return err
+			}
+			if err = addSink(key, "file level"); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.SysLog != nil && len(opts.SysLog.FallbackFile) > 0 {
+		path, err := filepath.Abs(opts.SysLog.FallbackFile)
+		if err != nil {
+			return err
+		}
+		if err = addSink("path:"+path, "syslog fallback"); err != nil {
+			return err
+		}
+	}
+	for idx, adapter := range opts.Adapters {
+		if containsAdapter(opts.Adapters[:idx], adapter) {
+			return fmt.Errorf("custom adapter of class %v added twice", adapter.Class())
+		}
+	}
+
+	// Done
+	return nil
+}
+
+func destroyAdapters(adapters []internalLogger) {
+	for _, adapter := range adapters {
+		adapter.destroy()
+	}
+}
+
+func containsAdapter(list []Adapter, adapter Adapter) bool {
+	if !reflect.TypeOf(adapter).Comparable() {
+		return false
+	}
+	for _, item := range list {
+		if reflect.TypeOf(item) == reflect.TypeOf(adapter) && item == adapter {
+			return true
+		}
+	}
+	return false
+}
+
+// getDefaultAppName returns the name set with SetDefaultAppName or, if none, the base name of the executable.
+func getDefaultAppName() (string, error) {
+	defaultAppNameMtx.RLock()
+	name := defaultAppName
+	defaultAppNameMtx.RUnlock()
+	if len(name) > 0 {
+		return name, nil
+	}
+
+	name, err := osExecutable()
+	if err != nil {
+		return "", err
+	}
+	name = filepath.Base(name)
+
+	extLen := len(filepath.Ext(name))
+	if len(name) > extLen {
+		name = name[:(len(name) - extLen)]
+	}
+
+	// Done
+	return name, nil
+}
+
+func (lg *Logger) emit(level LogLevel, debugLevel uint, obj interface{}) {
+	lg.emitFields(nil, level, debugLevel, obj)
+}
+
+// emitFields outputs a message adding the given fields after those of this logger, if any.
+func (lg *Logger) emitFields(fields []field, level LogLevel, debugLevel uint, obj interface{}) {
+	// Derived loggers apply their own level filter before the targets do
+	if lg.parent != nil {
+		if lg.filterAllows(level, debugLevel) {
+			scoped := lg.scopedFields()
+			lg.parent.emitNamed(lg.name, append(scoped[:len(scoped):len(scoped)], fields...), level, debugLevel, obj)
+		}
+		return
+	}
+	lg.emitNamed("", fields, level, debugLevel, obj)
+}
+
+// emitNamed outputs a message sent through the named logger with the given name, if any, adding the fields of the
+// derived logger sending it and of the context, if any. Must be called on the logger owning the targets.
+func (lg *Logger) emitNamed(name string, fields []field, level LogLevel, debugLevel uint, obj interface{}) {
+	// Switch levels if a scheduled range started or ended
+	lg.checkLevelSchedule()
+
+	// Lock access
+	lg.mtx.RLock()
+
+	if lg.destroyed {
+		lg.warnUseAfterDestroy()
+		lg.mtx.RUnlock()
+		return
+	}
+
+	// Skip messages no target is interested in
+	if !lg.isEnabled(level, debugLevel) {
+		lg.mtx.RUnlock()
+		return
+	}
+
+	// Evaluate deferred messages only once we know they are going to be output. The lock is released meanwhile so
+	// the function can log too without blocking against a pending reconfiguration.
+	if fn, isFunc := obj.(func() interface{}); isFunc {
+		lg.mtx.RUnlock()
+		obj = fn()
+		lg.mtx.RLock()
+
+		if lg.destroyed {
+			lg.mtx.RUnlock()
+			return
+		}
+	}
+
+	defer lg.mtx.RUnlock()
+
+	msg, isJSON, ok := lg.parseObj(obj)
+	if !ok || (lg.skipEmpty && isEmptyMessage(msg, isJSON)) {
+		return
+	}
+
+	// Count errors to detect bursts
+	if level == LogLevelError && lg.errorBurst != nil {
+		lg.errorBurst.add()
+	}
+
+	// Protect targets from huge messages
+	if isJSON && lg.maxPayload > 0 && len(msg) > lg.maxPayload {
+		if lg.errorHandler != nil {
+			lg.errorHandler(fmt.Sprintf("JSON message of %v bytes exceeds MaxPayloadBytes and was truncated", len(msg)))
+		}
+		msg = truncateJSONPayload(msg, lg.maxPayload)
+	}
+
+	// Drop repeated messages from the same call site
+	if lg.dedup != nil && level != logLevelAudit {
+		suppressed, summary := lg.dedup.suppress(callerPC(), level, debugLevel, msg)
+		if suppressed {
+			return
+		}
+		if summary != nil {
+			lg.output(summary.level, summary.debugLevel, summary.msg, false, summary.msg, "", "", nil)
+		}
+	}
+
+	// Tag the message with the calling function
+	fn := ""
+	if lg.includeFunc {
+		fn = callerFunction()
+	}
+
+	lg.output(level, debugLevel, msg, isJSON, obj, fn, name, fields)
+}
+
+// isEmptyMessage returns true if a text message only contains white space or a JSON message has no members.
+func isEmptyMessage(msg string, isJSON bool) bool {
+	if isJSON {
+		return msg == "{}"
+	}
+	return len(strings.TrimSpace(msg)) == 0
+}
+
+// emitDedupSummaries outputs the "repeated N times" messages of the call sites whose window closed.
+func (lg *Logger) emitDedupSummaries(summaries []*dedupSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
+
+	if lg.destroyed {
+		return
+	}
+	for _, summary := range summaries {
+		if lg.isEnabled(summary.level, summary.debugLevel) {
+			lg.output(summary.level, summary.debugLevel, summary.msg, false, summary.msg, "", "", nil)
+		}
+	}
+}
+
+// output formats the message and sends it to the adapters. Must be called within a lock.
+func (lg *Logger) output(level LogLevel, debugLevel uint, msg string, isJSON bool, obj interface{}, fn string,
+	name string, fields []field,
+) {
+	now := lg.getTimestamp()
+	if len(fn) > 0 {
+		fields = append(fields[:len(fields):len(fields)], field{
+			key:   "func",
+			value: fn,
+		})
+	}
+	defaultFields := lg.defaultFields
+	if lg.numGoroutine {
+		defaultFields = append(defaultFields[:len(defaultFields):len(defaultFields)], field{
+			key:   "num_goroutine",
+			value: runtime.NumGoroutine(),
+		})
+	}
+	if len(defaultFields) > 0 {
+		jsonMsg := ""
+		if isJSON {
+			jsonMsg = msg
+		}
+		fields = withDefaultFields(defaultFields, fields, jsonMsg)
+	}
+	// Logfmt lines of plain text messages get the sequence number and host name as fields, like JSON messages
+	logfmtMsg := ""
+	needLogfmt := lg.needLogfmt()
+	textMsg := msg
+	textFields := fields
+	if lg.includeSeq {
+		seq := atomic.AddUint64(&lg.seq, 1)
+		if isJSON {
+			fields = append([]field{{key: "seq", value: seq}}, fields...)
+		} else {
+			msg = "#" + strconv.FormatUint(seq, 10) + " " + msg
+			textFields = append([]field{{key: "seq", value: seq}}, textFields...)
+		}
+	}
+	if len(lg.hostname) > 0 {
+		if isJSON {
+			fields = append([]field{{key: "host", value: lg.hostname}}, fields...)
+		} else {
+			msg = "[" + lg.hostname + "] " + msg
+			textFields = append([]field{{key: "host", value: lg.hostname}}, textFields...)
+		}
+	}
+
+	raw := false
+	if isJSON {
+		// The level field must agree with the level the message is dispatched with, for example, with the syslog
+		// severity, so drop any other level field
+		msg = withoutJSONKey(msg, "level")
+		for idx := 0; idx < len(fields); idx++ {
+			if fields[idx].key == "level" {
+				fields = append(fields[:idx:idx], fields[idx+1:]...)
+				idx -= 1
+			}
+		}
+
+		msg = addFieldsToJSON(msg, fields)
+		if lg.headTail != nil {
+			if s, truncated := truncateHeadTail(msg, lg.headTail.Head, lg.headTail.Tail); truncated {
+				// Keep the object valid by storing the truncated one as a string in the message field
+				b, _ := json.Marshal(s)
+				msg = `{"message":` + string(b) + `}`
+			}
+		}
+		msg = addPayloadToJSON(msg, now, lg.jsonTsFormat, encodeJSONLevel(level, lg.jsonLevelEnc))
+		raw = true
+		if needLogfmt {
+			logfmtMsg = jsonToLogfmt(msg)
+		}
+	} else {
+		msg = addFieldsToText(msg, fields)
+		if lg.headTail != nil {
+			msg, _ = truncateHeadTail(msg, lg.headTail.Head, lg.headTail.Tail)
+		}
+		if needLogfmt {
+			if lg.headTail != nil {
+				textMsg, _ = truncateHeadTail(textMsg, lg.headTail.Head, lg.headTail.Tail)
+			}
+			logfmtMsg = formatLogfmt(formatTimestamp(now, lg.jsonTsFormat), logfmtLevel(level, lg.jsonLevelEnc), textMsg,
+				textFields)
+		}
+	}
+
+	// Let the application scrub the final text
+	if lg.transform != nil {
+		msg = lg.transform(level, msg)
+		if needLogfmt {
+			logfmtMsg = lg.transform(level, logfmtMsg)
+		}
+	}
+
+	// Drop messages muted during incidents
+	if len(lg.mutes) > 0 && level != logLevelAudit && lg.isMuted(msg, now) {
+		return
+	}
+	lg.metrics.countMessage(level)
+
+	for _, adapter := range lg.adapters {
+		if lf, ok := adapter.(internalLogfmtLogger); ok && lf.logfmt() {
+			dispatch(adapter, level, debugLevel, now, logfmtMsg, true, obj, name)
+			continue
+		}
+		dispatch(adapter, level, debugLevel, now, msg, raw, obj, name)
+	}
+	for _, capture := range lg.captures {
+		dispatch(capture, level, debugLevel, now, msg, raw, obj, name)
+	}
+	if len(lg.subscribers) > 0 {
+		lg.publish(level, debugLevel, now, msg, raw, name)
+	}
+}
+
+// needLogfmt returns true if an adapter writes logfmt lines. Must be called within a lock.
+func (lg *Logger) needLogfmt() bool {
+	for _, adapter := range lg.adapters {
+		if lf, ok := adapter.(internalLogfmtLogger); ok && lf.logfmt() {
+			return true
+		}
+	}
+	return false
+}
+
+// deliveryIdle returns true if no target has messages waiting to be delivered in the background.
+func (lg *Logger) deliveryIdle() bool {
+	// Lock access
+	lg.mtx.RLock()
+	defer lg.mtx.RUnlock()
+
+	for _, adapter := range lg.adapters {
+		if q, ok := adapter.(internalQueue); ok && !q.idle() {
+			return false
+		}
+	}
+	return true
+}
+
+// warnUseAfterDestroy notifies, only once, that a message was logged after the logger was destroyed. Must be called
+// within a lock.
+func (lg *Logger) warnUseAfterDestroy() {
+	if !lg.warnDestroyed || !atomic.CompareAndSwapInt32(&lg.destroyWarned, 0, 1) {
+		return
+	}
+
+	const msg = "logger used after being destroyed"
+	if lg.errorHandler != nil {
+		lg.errorHandler(msg)
+	} else {
+		_, _ = fmt.Fprintln(consoleStderr, msg)
+	}
+}
+
+// isEnabled returns true if at least one adapter accepts messages of the given level or a capture is active. Must be
+// called within a lock.
+func (lg *Logger) isEnabled(level LogLevel, debugLevel uint) bool {
+	for _, adapter := range lg.adapters {
+		if adapter.enabled(level, debugLevel) {
+			return true
+		}
+	}
+	return len(lg.captures) > 0
+}
+
+func dispatch(adapter internalLogger, level LogLevel, debugLevel uint, now time.Time, msg string, raw bool,
+	obj interface{}, name string,
+) {
+	// Skip disabled targets and those not interested in the message
+	if !adapter.enabled(level, debugLevel) {
+		return
+	}
+
+	// Adapters interested in the original object receive it along with the formatted message
+	if objAdapter, ok := adapter.(internalObjectLogger); ok {
+		objAdapter.logObject(level, debugLevel, now, msg, raw, obj)
+		return
+	}
+
+	// Adapters routing messages by logger name receive it along with the message
+	if len(name) > 0 {
+		if namedAdapter, ok := adapter.(internalNamedLogger); ok {
+			namedAdapter.logNamed(name, level, debugLevel, now, msg, raw)
+			return
+		}
+	}
+
+	switch level {
+	case LogLevelError:
+		adapter.logError(now, msg, raw)
+	case LogLevelWarning:
+		adapter.logWarning(now, msg, raw)
+	case LogLevelInfo:
+		adapter.logInfo(now, msg, raw)
+	case LogLevelDebug:
+		adapter.logDebug(debugLevel, now, msg, raw)
+	case logLevelAudit:
+		adapter.logAudit(now, msg, raw)
+	}
+}
+
+func (m StructTextMode) isValid() bool {
+	switch m {
+	case "", StructTextModeJSON, StructTextModeKeyValue:
+		return true
+	}
+	return false
+}
+
+func (p TimePrecision) isValid() bool {
+	switch p {
+	case "", TimePrecisionSecond, TimePrecisionMilli, TimePrecisionMicro, TimePrecisionNano:
+		return true
+	}
+	return false
+}
+
+// withTimePrecision replaces the milliseconds of the given layout with the fractional digits of the precision.
+func withTimePrecision(layout string, precision TimePrecision) string {
+	switch precision {
+	case TimePrecisionSecond:
+		return strings.Replace(layout, ".000", "", 1)
+	case TimePrecisionMicro:
+		return strings.Replace(layout, ".000", ".000000", 1)
+	case TimePrecisionNano:
+		return strings.Replace(layout, ".000", ".000000000", 1)
+	}
+	return layout
+}
+
+func levelName(level LogLevel) string {
+	switch level {
+	case LogLevelError:
+		return "error"
+	case LogLevelWarning:
+		return "warning"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelDebug:
+		return "debug"
+	case logLevelAudit:
+		return "audit"
+	}
+	return ""
+}
+
 func (logger *Logger) getTimestamp() time.Time {
-	now := time.Now()
+	now := timeNow()
 	if !logger.useLocalTime {
 		now = now.UTC()
 	}
@@ -30,8 +920,31 @@ func (logger *Logger) getTimestamp() time.Time {
 }
 
 func (logger *Logger) parseObj(obj interface{}) (msg string, isJSON bool, ok bool) {
+	// Entries are already formatted
+	if payload, isPayload := obj.(entryPayload); isPayload {
+		return string(payload), true, true
+	}
+	if payload, isPayload := obj.(templatePayload); isPayload {
+		if logger.structText == StructTextModeKeyValue {
+			return payload.text(), false, true
+		}
+		return payload.json(), true, true
+	}
+
 	// Quick check for strings, structs or pointer to strings or structs
 	refObj := reflect.ValueOf(obj)
+
+	// Errors are logged with their unwrap chain
+	if err, isErr := obj.(error); isErr && (refObj.Kind() != reflect.Ptr || !refObj.IsNil()) {
+		b, _ := json.Marshal(errorPayload{
+			Error:      err.Error(),
+			ErrorChain: errorChain(err),
+		})
+		msg, isJSON = logger.formatStruct(b)
+		ok = true
+		return
+	}
+
 	switch refObj.Kind() {
 	case reflect.Ptr:
 		if !refObj.IsNil() {
@@ -42,12 +955,17 @@ func (logger *Logger) parseObj(obj interface{}) (msg string, isJSON bool, ok boo
 
 			case reflect.Struct:
 				// Marshal struct
-				b, err := json.Marshal(obj)
+				b, err := logger.marshalStruct(obj)
 				if err == nil {
-					msg = string(b)
-					isJSON = true
+					msg, isJSON = logger.formatStruct(b)
 					ok = true
 				}
+
+			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32,
+				reflect.Float64:
+				msg, isJSON = logger.formatScalar(refObj.Elem().Interface())
+				ok = true
 			}
 		}
 
@@ -57,14 +975,54 @@ func (logger *Logger) parseObj(obj interface{}) (msg string, isJSON bool, ok boo
 
 	case reflect.Struct:
 		// Marshal struct
-		b, err := json.Marshal(obj)
+		b, err := logger.marshalStruct(obj)
 		if err == nil {
-			msg = string(b)
-			isJSON = true
+			msg, isJSON = logger.formatStruct(b)
 			ok = true
 		}
+
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32,
+		reflect.Float64:
+		msg, isJSON = logger.formatScalar(obj)
+		ok = true
 	}
 
 	// Done
 	return
 }
+
+// marshalStruct marshals a struct, or a pointer to a struct, passed as message.
+func (logger *Logger) marshalStruct(obj interface{}) ([]byte, error) {
+	if logger.friendlyDur && reflect.Indirect(reflect.ValueOf(obj)).Type() != timeType {
+		return marshalFriendly(obj, logger.jsonTsFormat)
+	}
+	return json.Marshal(obj)
+}
+
+// formatStruct returns the message for a marshalled struct. It is the JSON object itself unless key-value text was
+// requested.
+func (logger *Logger) formatStruct(b []byte) (string, bool) {
+	if logger.structText == StructTextModeKeyValue {
+		return jsonToKeyValue(string(b)), false
+	}
+	return string(b), true
+}
+
+// formatScalar returns the message for a boolean or a number. It is a JSON object with the value in the message field
+// unless plain text scalars were requested.
+func (logger *Logger) formatScalar(obj interface{}) (string, bool) {
+	if logger.textScalars {
+		return fmt.Sprint(obj), false
+	}
+	return marshalScalar(obj), true
+}
+
+func marshalScalar(obj interface{}) string {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		// Values like NaN or infinite cannot be represented as JSON numbers
+		b, _ = json.Marshal(fmt.Sprint(obj))
+	}
+	return `{"message":` + string(b) + `}`
+}
@@ -0,0 +1,470 @@
+package go_logger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestJSONTimestampFormat(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "JsonTimestamp", nil)
+	lg.Info(JsonMessage{
+		Message: "This is an information message sample",
+	})
+	lg.Destroy()
+
+	entry := parseTestJSONEntry(t, readTestLogFile(t, dir, "JsonTimestamp"))
+	if _, err := time.Parse(time.RFC3339, entry["timestamp"].(string)); err != nil {
+		t.Errorf("timestamp is not RFC 3339 compliant. [%v]", err)
+	}
+}
+
+func TestJSONTimestampFormatLegacy(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "JsonTimestampLegacy", func(opts *logger.Options) {
+		opts.LegacyJSONTimestamp = true
+	})
+	lg.Info(JsonMessage{
+		Message: "This is an information message sample",
+	})
+	lg.Destroy()
+
+	entry := parseTestJSONEntry(t, readTestLogFile(t, dir, "JsonTimestampLegacy"))
+	if _, err := time.Parse(logger.LegacyJSONTimestampFormat, entry["timestamp"].(string)); err != nil {
+		t.Errorf("timestamp does not match the legacy format. [%v]", err)
+	}
+}
+
+func TestJSONTimestampFormatCustom(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "JsonTimestampCustom", func(opts *logger.Options) {
+		opts.JSONTimestampFormat = time.RFC1123Z
+	})
+	lg.Info(JsonMessage{
+		Message: "This is an information message sample",
+	})
+	lg.Destroy()
+
+	entry := parseTestJSONEntry(t, readTestLogFile(t, dir, "JsonTimestampCustom"))
+	if _, err := time.Parse(time.RFC1123Z, entry["timestamp"].(string)); err != nil {
+		t.Errorf("timestamp does not match the custom format. [%v]", err)
+	}
+}
+
+func TestJSONTimestampFormatUnix(t *testing.T) {
+	now := time.Date(2021, 3, 14, 10, 20, 30, 123456789, time.UTC)
+	restore := logger.SetTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{logger.JSONTimestampUnix, "1615717230"},
+		{logger.JSONTimestampUnixMilli, "1615717230123"},
+		{logger.JSONTimestampUnixNano, "1615717230123456789"},
+	}
+	for _, test := range tests {
+		adapter := &testAdapter{}
+		lg, _ := createTestFileLogger(t, "JsonTimestamp"+test.format, func(opts *logger.Options) {
+			opts.JSONTimestampFormat = test.format
+			opts.Adapters = []logger.Adapter{adapter}
+		})
+		lg.Info(JsonMessage{
+			Message: "This is an information message sample",
+		})
+		lg.Destroy()
+
+		entries := adapter.Entries()
+		if len(entries) != 1 {
+			t.Fatalf("unexpected number of entries [%v]", len(entries))
+		}
+		entry := struct {
+			Timestamp json.RawMessage `json:"timestamp"`
+		}{}
+		if err := json.Unmarshal([]byte(entries[0].msg), &entry); err != nil {
+			t.Fatalf("unable to parse log entry [%v]. [%v]", entries[0].msg, err)
+		}
+		// The timestamp must be a JSON number, not a string
+		if string(entry.Timestamp) != test.expected {
+			t.Errorf("unexpected timestamp [format: %v] [got: %s, expected: %v]", test.format, entry.Timestamp,
+				test.expected)
+		}
+	}
+}
+
+func TestTimePrecision(t *testing.T) {
+	tests := []struct {
+		precision logger.TimePrecision
+		digits    int
+	}{
+		{precision: "", digits: 3},
+		{precision: logger.TimePrecisionSecond, digits: 0},
+		{precision: logger.TimePrecisionMilli, digits: 3},
+		{precision: logger.TimePrecisionMicro, digits: 6},
+		{precision: logger.TimePrecisionNano, digits: 9},
+	}
+	for _, test := range tests {
+		prefix := "TimePrecision" + string(test.precision)
+		lg, dir := createTestFileLogger(t, prefix, func(opts *logger.Options) {
+			opts.TimePrecision = test.precision
+		})
+		lg.Info("This is an information message sample")
+		lg.Info(JsonMessage{
+			Message: "This is an information message sample",
+		})
+		lg.Destroy()
+
+		lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, prefix)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("unexpected number of lines [got: %v, expected: 2]", len(lines))
+		}
+
+		// Text timestamps are followed by the level
+		textTs := lines[0][:strings.Index(lines[0], " [")]
+		if n := fractionalDigits(textTs); n != test.digits {
+			t.Errorf("unexpected text timestamp precision for %q [got: %v, expected: %v]", test.precision, n,
+				test.digits)
+		}
+
+		jsonTs := parseTestJSONEntry(t, lines[1])["timestamp"].(string)
+		if n := fractionalDigits(jsonTs); n != test.digits {
+			t.Errorf("unexpected JSON timestamp precision for %q [got: %v, expected: %v]", test.precision, n,
+				test.digits)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, jsonTs); err != nil {
+			t.Errorf("timestamp is not RFC 3339 compliant. [%v]", err)
+		}
+	}
+
+	_, err := logger.Create(logger.Options{
+		TimePrecision: "centuries",
+	})
+	if err == nil {
+		t.Errorf("invalid time precision was accepted")
+	}
+}
+
+func TestJSONScalars(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "JsonScalars", nil)
+	lg.Info(42)
+	lg.Info(3.5)
+	lg.Info(true)
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "JsonScalars")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 3]", len(lines))
+	}
+	for idx, expected := range []interface{}{float64(42), 3.5, true} {
+		entry := parseTestJSONEntry(t, lines[idx])
+		if entry["message"] != expected || entry["level"] != "info" {
+			t.Errorf("unexpected entry #%v [%v]", idx+1, lines[idx])
+		}
+	}
+}
+
+func TestTextScalars(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "TextScalars", func(opts *logger.Options) {
+		opts.TextScalars = true
+	})
+	lg.Info(42)
+	lg.Warning(3.5)
+	value := true
+	lg.Error(&value)
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "TextScalars")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 3]", len(lines))
+	}
+	for idx, expected := range []string{" [INFO]: 42", " [WARNING]: 3.5", " [ERROR]: true"} {
+		if !strings.HasSuffix(lines[idx], expected) {
+			t.Errorf("unexpected line #%v [%v]", idx+1, lines[idx])
+		}
+	}
+}
+
+func TestStructTextModeKeyValue(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "StructKeyValue", func(opts *logger.Options) {
+		opts.StructTextMode = logger.StructTextModeKeyValue
+	})
+	lg.Info(struct {
+		User  string `json:"user"`
+		Count int    `json:"count"`
+	}{
+		User:  "alice",
+		Count: 2,
+	})
+	lg.Warning(&struct {
+		Action string `json:"action"`
+		Target struct {
+			ID int `json:"id"`
+		} `json:"target"`
+	}{
+		Action: "delete file",
+		Target: struct {
+			ID int `json:"id"`
+		}{
+			ID: 7,
+		},
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "StructKeyValue")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 2]", len(lines))
+	}
+	for idx, expected := range []string{" [INFO]: user=alice count=2", ` [WARNING]: action="delete file" target.id=7`} {
+		if !strings.HasSuffix(lines[idx], expected) {
+			t.Errorf("unexpected line #%v [%v]", idx+1, lines[idx])
+		}
+	}
+
+	// Unknown modes are rejected
+	_, err := logger.Create(logger.Options{
+		StructTextMode: "yaml",
+	})
+	if err == nil {
+		t.Errorf("invalid struct text mode was accepted")
+	}
+}
+
+func TestErrorChain(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "ErrorChain", nil)
+	root := errors.New("connection refused")
+	inner := fmt.Errorf("dial tcp: %w", root)
+	lg.Error(fmt.Errorf("unable to load user: %w", inner))
+	lg.Error(&selfWrappingError{})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "ErrorChain")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 2]", len(lines))
+	}
+	entry := parseTestJSONEntry(t, lines[0])
+	if entry["error"] != "unable to load user: dial tcp: connection refused" {
+		t.Errorf("unexpected error field [%v]", entry["error"])
+	}
+	chain, _ := entry["error_chain"].([]interface{})
+	expected := []interface{}{
+		"unable to load user: dial tcp: connection refused", "dial tcp: connection refused", "connection refused",
+	}
+	if fmt.Sprint(chain) != fmt.Sprint(expected) {
+		t.Errorf("unexpected error chain [got: %v, expected: %v]", chain, expected)
+	}
+
+	// Cycles are cut
+	entry = parseTestJSONEntry(t, lines[1])
+	if chain, _ = entry["error_chain"].([]interface{}); len(chain) == 0 || len(chain) > 16 {
+		t.Errorf("unexpected error chain length [%v]", len(chain))
+	}
+}
+
+func TestJSONLevelEncoders(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoder  logger.JSONLevelEncoder
+		expected []interface{}
+	}{
+		{
+			name:     "Lowercase",
+			encoder:  logger.LowercaseLevelEncoder,
+			expected: []interface{}{"error", "warning", "info", "debug"},
+		},
+		{
+			name:     "Uppercase",
+			encoder:  logger.UppercaseLevelEncoder,
+			expected: []interface{}{"ERROR", "WARNING", "INFO", "DEBUG"},
+		},
+		{
+			name:     "Severity",
+			encoder:  logger.SeverityLevelEncoder,
+			expected: []interface{}{float64(3), float64(4), float64(6), float64(7)},
+		},
+	}
+	for _, test := range tests {
+		prefix := "JsonLevel" + test.name
+		lg, dir := createTestFileLogger(t, prefix, func(opts *logger.Options) {
+			opts.JSONLevelEncoder = test.encoder
+		})
+		lg.Error(JsonMessage{Message: "error"})
+		lg.Warning(JsonMessage{Message: "warning"})
+		lg.Info(JsonMessage{Message: "info"})
+		lg.Debug(1, JsonMessage{Message: "debug"})
+		lg.Audit(JsonMessage{Message: "audit"})
+		lg.Destroy()
+
+		lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, prefix)), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("unexpected number of lines [got: %v, expected: 5]", len(lines))
+		}
+		for idx, expected := range append(test.expected, "audit") {
+			entry := parseTestJSONEntry(t, lines[idx])
+			if entry["level"] != expected {
+				t.Errorf("unexpected %v level #%v [got: %v, expected: %v]", test.name, idx+1, entry["level"], expected)
+			}
+		}
+	}
+}
+
+func TestJSONMaxPayloadBytes(t *testing.T) {
+	var handledErrors []string
+
+	lg, dir := createTestFileLogger(t, "JsonMaxPayload", func(opts *logger.Options) {
+		opts.MaxPayloadBytes = 1024
+		opts.ErrorHandler = func(message string) {
+			handledErrors = append(handledErrors, message)
+		}
+	})
+	lg.Info(struct {
+		Data []byte `json:"data"`
+	}{
+		Data: make([]byte, 1024*1024),
+	})
+	lg.Info(JsonMessage{
+		Message: "This is an information message sample",
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "JsonMaxPayload")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 2]", len(lines))
+	}
+	if len(lines[0]) > 2048 {
+		t.Errorf("message was not truncated [length: %v]", len(lines[0]))
+	}
+	entry := parseTestJSONEntry(t, lines[0])
+	msg, _ := entry["message"].(string)
+	if !strings.HasPrefix(msg, `{"data":"AAAA`) || !strings.Contains(msg, "...[truncated ") {
+		t.Errorf("unexpected truncated message [%v]", msg)
+	}
+	if entry["level"] != "info" {
+		t.Errorf("truncated message has no payload [%v]", lines[0])
+	}
+	if parseTestJSONEntry(t, lines[1])["message"] != "This is an information message sample" {
+		t.Errorf("small message was modified [%v]", lines[1])
+	}
+	if len(handledErrors) != 1 || !strings.Contains(handledErrors[0], "MaxPayloadBytes") {
+		t.Errorf("unexpected errors [%v]", handledErrors)
+	}
+}
+
+func TestJSONFriendlyDurations(t *testing.T) {
+	type Timing struct {
+		Step    string        `json:"step"`
+		Elapsed time.Duration `json:"elapsed"`
+	}
+	type Request struct {
+		Message  string         `json:"message"`
+		Elapsed  time.Duration  `json:"elapsed"`
+		Started  time.Time      `json:"started"`
+		Timeout  *time.Duration `json:"timeout,omitempty"`
+		Steps    []Timing       `json:"steps"`
+		internal time.Duration
+	}
+
+	started := time.Date(2021, 3, 14, 15, 9, 26, 535000000, time.UTC)
+	msg := Request{
+		Message: "request served",
+		Elapsed: 1500 * time.Millisecond,
+		Started: started,
+		Steps: []Timing{
+			{Step: "query", Elapsed: 250 * time.Microsecond},
+		},
+		internal: time.Second,
+	}
+
+	for _, friendly := range []bool{false, true} {
+		prefix := "JsonFriendly"
+		if !friendly {
+			prefix = "JsonNotFriendly"
+		}
+		lg, dir := createTestFileLogger(t, prefix, func(opts *logger.Options) {
+			opts.FriendlyDurations = friendly
+			opts.JSONTimestampFormat = time.RFC1123Z
+		})
+		lg.Info(msg)
+		lg.Info(&msg)
+		lg.Destroy()
+
+		lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, prefix)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("unexpected number of lines [got: %v, expected: 2]", len(lines))
+		}
+		for _, line := range lines {
+			entry := parseTestJSONEntry(t, line)
+			steps, _ := entry["steps"].([]interface{})
+			if len(steps) != 1 {
+				t.Fatalf("unexpected steps [%v]", line)
+			}
+			step, _ := steps[0].(map[string]interface{})
+
+			if friendly {
+				if entry["elapsed"] != "1.5s" || step["elapsed"] != "250µs" ||
+					entry["started"] != started.Format(time.RFC1123Z) {
+					t.Errorf("unexpected friendly values [%v]", line)
+				}
+				if !strings.Contains(line, `"message":"request served","elapsed":"1.5s","started":`) {
+					t.Errorf("field order was not kept [%v]", line)
+				}
+			} else {
+				if entry["elapsed"] != float64(1500000000) || step["elapsed"] != float64(250000) ||
+					entry["started"] != "2021-03-14T15:09:26.535Z" {
+					t.Errorf("unexpected default values [%v]", line)
+				}
+			}
+			if _, ok := entry["timeout"]; ok {
+				t.Errorf("empty field was not omitted [%v]", line)
+			}
+			if _, ok := entry["internal"]; ok {
+				t.Errorf("unexported field was output [%v]", line)
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func fractionalDigits(ts string) int {
+	idx := strings.Index(ts, ".")
+	if idx < 0 {
+		return 0
+	}
+	n := 0
+	for _, ch := range ts[idx+1:] {
+		if ch < '0' || ch > '9' {
+			break
+		}
+		n += 1
+	}
+	return n
+}
+
+func parseTestJSONEntry(t *testing.T, line string) map[string]interface{} {
+	var entry map[string]interface{}
+
+	err := json.Unmarshal([]byte(strings.TrimSpace(line)), &entry)
+	if err != nil {
+		t.Fatalf("unable to parse JSON entry. [%v]", err)
+	}
+	return entry
+}
+
+type selfWrappingError struct{}
+
+func (e *selfWrappingError) Error() string {
+	return "self wrapping error"
+}
+
+func (e *selfWrappingError) Unwrap() error {
+	return e
+}
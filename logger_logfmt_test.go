@@ -0,0 +1,53 @@
+package go_logger_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestLogfmt(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "Logfmt", func(opts *logger.Options) {
+		opts.File.Logfmt = true
+		opts.DefaultFields = map[string]interface{}{
+			"service": "billing",
+			"app":     "api",
+		}
+	})
+
+	alphaLg := lg.With("zeta", 1).With("alpha", "two words")
+	alphaLg.Info("first call")
+	alphaLg.Warning("second call")
+	alphaLg.Error(struct {
+		Message string `json:"message"`
+		Zulu    int    `json:"zulu"`
+		Able    bool   `json:"able"`
+	}{
+		Message: "json call",
+		Zulu:    7,
+		Able:    true,
+	})
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "Logfmt")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected number of lines [%v]", len(lines))
+	}
+
+	// Core fields come first, then the default fields, sorted, and the pushed ones in insertion order
+	ts := regexp.MustCompile(`^ts=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z `)
+	expected := []string{
+		`level=info msg="first call" app=api service=billing zeta=1 alpha="two words"`,
+		`level=warning msg="second call" app=api service=billing zeta=1 alpha="two words"`,
+		`level=error msg="json call" app=api service=billing zeta=1 alpha="two words" zulu=7 able=true`,
+	}
+	for idx, line := range lines {
+		if !ts.MatchString(line) || ts.ReplaceAllString(line, "") != expected[idx] {
+			t.Errorf("unexpected line #%v [got: %v, expected: ts=... %v]", idx+1, line, expected[idx])
+		}
+	}
+}
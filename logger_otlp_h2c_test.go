@@ -0,0 +1,35 @@
+//go:build go1.24
+
+package go_logger_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestOTLPGRPCInsecure(t *testing.T) {
+	srv := &otlpTestServer{
+		accepted: make(chan otlpTestRequest, 16),
+	}
+	srv.Server = httptest.NewUnstartedServer(srv.grpcHandler(t))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	// The default client talks plain text HTTP/2 to the collector
+	lg := createTestOTLPLogger(t, srv, func(opts *logger.Options) {
+		opts.OTLP.Protocol = logger.OTLPProtocolGRPC
+		opts.OTLP.Endpoint = strings.TrimPrefix(srv.URL, "http://")
+	})
+	defer lg.Destroy()
+
+	printTestOTLPMessages(lg)
+	checkTestOTLPRequest(t, srv.next(t))
+}
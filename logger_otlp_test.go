@@ -0,0 +1,469 @@
+package go_logger_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+type otlpTestServer struct {
+	*httptest.Server
+	failures int32
+	accepted chan otlpTestRequest
+}
+
+type otlpTestRequest struct {
+	ResourceLogs []otlpTestResourceLogs `json:"resourceLogs"`
+}
+
+type otlpTestResourceLogs struct {
+	Resource struct {
+		Attributes []otlpTestKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeLogs []otlpTestScopeLogs `json:"scopeLogs"`
+}
+
+type otlpTestScopeLogs struct {
+	LogRecords []otlpTestLogRecord `json:"logRecords"`
+}
+
+type otlpTestLogRecord struct {
+	TimeUnixNano   string             `json:"timeUnixNano"`
+	SeverityNumber int                `json:"severityNumber"`
+	SeverityText   string             `json:"severityText"`
+	Body           otlpTestValue      `json:"body"`
+	Attributes     []otlpTestKeyValue `json:"attributes"`
+}
+
+type otlpTestKeyValue struct {
+	Key   string        `json:"key"`
+	Value otlpTestValue `json:"value"`
+}
+
+type otlpTestValue struct {
+	StringValue *string  `json:"stringValue"`
+	BoolValue   *bool    `json:"boolValue"`
+	IntValue    *string  `json:"intValue"`
+	DoubleValue *float64 `json:"doubleValue"`
+}
+
+type protoTestField struct {
+	num   int
+	value uint64
+	bytes []byte
+}
+
+func TestOTLP(t *testing.T) {
+	srv := startTestOTLPServer(t)
+	defer srv.Close()
+
+	lg := createTestOTLPLogger(t, srv, nil)
+	defer lg.Destroy()
+
+	printTestOTLPMessages(lg)
+	checkTestOTLPRequest(t, srv.next(t))
+}
+
+func TestOTLPGRPC(t *testing.T) {
+	srv := startTestOTLPGRPCServer(t)
+	defer srv.Close()
+
+	lg := createTestOTLPGRPCLogger(t, srv, nil)
+	defer lg.Destroy()
+
+	printTestOTLPMessages(lg)
+	checkTestOTLPRequest(t, srv.next(t))
+}
+
+func TestOTLPExportError(t *testing.T) {
+	srv := startTestOTLPServer(t)
+	defer srv.Close()
+	atomic.StoreInt32(&srv.failures, 1)
+
+	errCh := make(chan string, 4)
+	lg := createTestOTLPLogger(t, srv, func(opts *logger.Options) {
+		opts.ErrorHandler = func(message string) {
+			errCh <- message
+		}
+	})
+	defer lg.Destroy()
+
+	lg.Error("lost message")
+	if err := lg.Sync(); err == nil {
+		t.Errorf("failed export was not reported by Sync")
+	}
+	select {
+	case msg := <-errCh:
+		if !strings.Contains(msg, "OTLP collector") || !strings.Contains(msg, "503") {
+			t.Errorf("unexpected error notification [%v]", msg)
+		}
+	default:
+		t.Errorf("failed export was not notified")
+	}
+
+	// Delivery resumes once the collector recovers
+	lg.Error("delivered message")
+	if err := lg.Sync(); err != nil {
+		t.Errorf("unable to sync. [%v]", err)
+	}
+	srv.next(t)
+}
+
+func TestOTLPGRPCExportError(t *testing.T) {
+	srv := startTestOTLPGRPCServer(t)
+	defer srv.Close()
+	atomic.StoreInt32(&srv.failures, 1)
+
+	errCh := make(chan string, 4)
+	lg := createTestOTLPGRPCLogger(t, srv, func(opts *logger.Options) {
+		opts.ErrorHandler = func(message string) {
+			errCh <- message
+		}
+	})
+	defer lg.Destroy()
+
+	lg.Error("lost message")
+	if err := lg.Sync(); err == nil {
+		t.Errorf("failed export was not reported by Sync")
+	}
+	select {
+	case msg := <-errCh:
+		if !strings.Contains(msg, "gRPC status 14") || !strings.Contains(msg, "collector unavailable") {
+			t.Errorf("unexpected error notification [%v]", msg)
+		}
+	default:
+		t.Errorf("failed export was not notified")
+	}
+
+	// Delivery resumes once the collector recovers
+	lg.Error("delivered message")
+	if err := lg.Sync(); err != nil {
+		t.Errorf("unable to sync. [%v]", err)
+	}
+	srv.next(t)
+}
+
+func printTestOTLPMessages(lg *logger.Logger) {
+	// Messages logged together are exported in a single batch
+	lg.Error("first message")
+	lg.Info(struct {
+		Message string `json:"message"`
+		UserID  int    `json:"userId"`
+		Admin   bool   `json:"admin"`
+		Role    string `json:"role"`
+	}{
+		Message: "second message",
+		UserID:  42,
+		Admin:   true,
+		Role:    "owner",
+	})
+}
+
+func checkTestOTLPRequest(t *testing.T, req otlpTestRequest) {
+	if len(req.ResourceLogs) != 1 || len(req.ResourceLogs[0].ScopeLogs) != 1 {
+		t.Fatalf("unexpected request layout [%+v]", req)
+	}
+	attrs := req.ResourceLogs[0].Resource.Attributes
+	if len(attrs) != 1 || attrs[0].Key != "service.name" || attrs[0].Value.StringValue == nil ||
+		*attrs[0].Value.StringValue != "test-service" {
+		t.Errorf("unexpected resource attributes [%+v]", attrs)
+	}
+
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("unexpected amount of records in batch [%v]", len(records))
+	}
+	if records[0].SeverityNumber != 17 || records[0].SeverityText != "ERROR" ||
+		records[0].Body.StringValue == nil || !strings.HasSuffix(*records[0].Body.StringValue, "first message") {
+		t.Errorf("unexpected first record [%+v]", records[0])
+	}
+	if len(records[0].TimeUnixNano) == 0 {
+		t.Errorf("first record has no timestamp")
+	}
+	if records[1].SeverityNumber != 9 || records[1].Body.StringValue == nil ||
+		*records[1].Body.StringValue != "second message" {
+		t.Errorf("unexpected second record [%+v]", records[1])
+	}
+
+	// Struct fields become attributes
+	values := make(map[string]otlpTestValue)
+	for _, attr := range records[1].Attributes {
+		values[attr.Key] = attr.Value
+	}
+	if v := values["userId"]; v.IntValue == nil || *v.IntValue != "42" {
+		t.Errorf("unexpected userId attribute [%+v]", v)
+	}
+	if v := values["admin"]; v.BoolValue == nil || !*v.BoolValue {
+		t.Errorf("unexpected admin attribute [%+v]", v)
+	}
+	if v := values["role"]; v.StringValue == nil || *v.StringValue != "owner" {
+		t.Errorf("unexpected role attribute [%+v]", v)
+	}
+	if _, found := values["timestamp"]; found {
+		t.Errorf("timestamp was exported as an attribute")
+	}
+}
+
+func startTestOTLPServer(t *testing.T) *otlpTestServer {
+	srv := &otlpTestServer{
+		accepted: make(chan otlpTestRequest, 16),
+	}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("unexpected authorization header [%v]", r.Header.Get("Authorization"))
+		}
+		if atomic.CompareAndSwapInt32(&srv.failures, 1, 0) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		req := otlpTestRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+		srv.accepted <- req
+	}))
+	return srv
+}
+
+// startTestOTLPGRPCServer starts an OTLP/gRPC receiver over HTTP/2 with TLS. Use its client to connect to it.
+func startTestOTLPGRPCServer(t *testing.T) *otlpTestServer {
+	srv := &otlpTestServer{
+		accepted: make(chan otlpTestRequest, 16),
+	}
+	srv.Server = httptest.NewUnstartedServer(srv.grpcHandler(t))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	return srv
+}
+
+func (srv *otlpTestServer) grpcHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.URL.Path != "/opentelemetry.proto.collector.logs.v1.LogsService/Export" ||
+			r.Header.Get("Content-Type") != "application/grpc" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("unexpected authorization header [%v]", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		if atomic.CompareAndSwapInt32(&srv.failures, 1, 0) {
+			w.Header().Set("Grpc-Status", "14")
+			w.Header().Set("Grpc-Message", "collector%20unavailable")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil || len(body) < 5 || body[0] != 0 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			t.Errorf("invalid gRPC request message")
+			w.Header().Set("Grpc-Status", "3")
+			return
+		}
+		req := decodeTestOTLPProto(t, body[5:])
+
+		// Reply with an empty response message
+		_, _ = w.Write([]byte{0, 0, 0, 0, 0})
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		srv.accepted <- req
+	})
+}
+
+func (srv *otlpTestServer) next(t *testing.T) otlpTestRequest {
+	select {
+	case req := <-srv.accepted:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout while waiting for OTLP request")
+	}
+	return otlpTestRequest{}
+}
+
+func createTestOTLPLogger(t *testing.T, srv *otlpTestServer, modifier func(opts *logger.Options)) *logger.Logger {
+	opts := logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		OTLP: &logger.OTLPOptions{
+			Protocol: logger.OTLPProtocolHTTPJSON,
+			Endpoint: strings.TrimPrefix(srv.URL, "http://"),
+			Insecure: true,
+			Headers: map[string]string{
+				"Authorization": "Bearer test-token",
+			},
+			ServiceName: "test-service",
+			MaxLatency:  200 * time.Millisecond,
+		},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	}
+	if modifier != nil {
+		modifier(&opts)
+	}
+
+	lg, err := logger.Create(opts)
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg
+}
+
+func createTestOTLPGRPCLogger(t *testing.T, srv *otlpTestServer, modifier func(opts *logger.Options)) *logger.Logger {
+	return createTestOTLPLogger(t, srv, func(opts *logger.Options) {
+		opts.OTLP.Protocol = logger.OTLPProtocolGRPC
+		opts.OTLP.Endpoint = srv.URL
+		opts.OTLP.Insecure = false
+		opts.OTLP.HttpClient = srv.Client()
+		if modifier != nil {
+			modifier(opts)
+		}
+	})
+}
+
+// decodeTestOTLPProto decodes the protobuf encoding of an ExportLogsServiceRequest message.
+func decodeTestOTLPProto(t *testing.T, b []byte) otlpTestRequest {
+	req := otlpTestRequest{}
+	for _, f := range parseTestProto(t, b) {
+		if f.num != 1 {
+			continue
+		}
+		resourceLogs := otlpTestResourceLogs{}
+		for _, rf := range parseTestProto(t, f.bytes) {
+			switch rf.num {
+			case 1:
+				for _, af := range parseTestProto(t, rf.bytes) {
+					if af.num == 1 {
+						resourceLogs.Resource.Attributes = append(resourceLogs.Resource.Attributes,
+							decodeTestOTLPKeyValue(t, af.bytes))
+					}
+				}
+			case 2:
+				scopeLogs := otlpTestScopeLogs{}
+				for _, sf := range parseTestProto(t, rf.bytes) {
+					if sf.num == 2 {
+						scopeLogs.LogRecords = append(scopeLogs.LogRecords, decodeTestOTLPLogRecord(t, sf.bytes))
+					}
+				}
+				resourceLogs.ScopeLogs = append(resourceLogs.ScopeLogs, scopeLogs)
+			}
+		}
+		req.ResourceLogs = append(req.ResourceLogs, resourceLogs)
+	}
+	return req
+}
+
+func decodeTestOTLPLogRecord(t *testing.T, b []byte) otlpTestLogRecord {
+	record := otlpTestLogRecord{}
+	for _, f := range parseTestProto(t, b) {
+		switch f.num {
+		case 1:
+			if f.value != 0 {
+				record.TimeUnixNano = strconv.FormatUint(f.value, 10)
+			}
+		case 2:
+			record.SeverityNumber = int(f.value)
+		case 3:
+			record.SeverityText = string(f.bytes)
+		case 5:
+			record.Body = decodeTestOTLPValue(t, f.bytes)
+		case 6:
+			record.Attributes = append(record.Attributes, decodeTestOTLPKeyValue(t, f.bytes))
+		}
+	}
+	return record
+}
+
+func decodeTestOTLPKeyValue(t *testing.T, b []byte) otlpTestKeyValue {
+	kv := otlpTestKeyValue{}
+	for _, f := range parseTestProto(t, b) {
+		switch f.num {
+		case 1:
+			kv.Key = string(f.bytes)
+		case 2:
+			kv.Value = decodeTestOTLPValue(t, f.bytes)
+		}
+	}
+	return kv
+}
+
+func decodeTestOTLPValue(t *testing.T, b []byte) otlpTestValue {
+	v := otlpTestValue{}
+	for _, f := range parseTestProto(t, b) {
+		switch f.num {
+		case 1:
+			s := string(f.bytes)
+			v.StringValue = &s
+		case 2:
+			bv := f.value != 0
+			v.BoolValue = &bv
+		case 3:
+			s := strconv.FormatInt(int64(f.value), 10)
+			v.IntValue = &s
+		case 4:
+			d := math.Float64frombits(f.value)
+			v.DoubleValue = &d
+		}
+	}
+	return v
+}
+
+// parseTestProto splits a protobuf message into its fields. Varint and 64-bit fields are stored in value and
+// length-delimited ones in bytes.
+func parseTestProto(t *testing.T, b []byte) []protoTestField {
+	fields := make([]protoTestField, 0)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("invalid protobuf tag")
+		}
+		b = b[n:]
+
+		f := protoTestField{
+			num: int(tag >> 3),
+		}
+		switch tag & 7 {
+		case 0:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("invalid protobuf varint")
+			}
+			b = b[n:]
+		case 1:
+			if len(b) < 8 {
+				t.Fatalf("invalid protobuf fixed64")
+			}
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				t.Fatalf("invalid protobuf length")
+			}
+			f.bytes = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("unexpected protobuf wire type %v", tag&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
@@ -0,0 +1,106 @@
+package go_logger_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestCapturePanics(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "Panic", func(opts *logger.Options) {
+		opts.SwallowPanics = true
+	})
+
+	// Synchronous capture
+	func() {
+		defer lg.CapturePanics()
+
+		panic("synchronous panic sample")
+	}()
+
+	// Capture inside a goroutine launched by the logger
+	lg.Go(func() {
+		panic("goroutine panic sample")
+	})
+
+	// Wait until both panics were logged
+	var content string
+	for retry := 0; retry < 50; retry++ {
+		content = readTestLogFile(t, dir, "Panic")
+		if strings.Contains(content, "goroutine panic sample") {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	lg.Destroy()
+
+	for _, s := range []string{"[ERROR]: panic: synchronous panic sample", "[ERROR]: panic: goroutine panic sample"} {
+		if !strings.Contains(content, s) {
+			t.Errorf("log file does not contain the expected panic entry [%v]", s)
+		}
+	}
+	if !strings.Contains(content, "goroutine ") {
+		t.Errorf("log file does not contain the panic stack trace")
+	}
+}
+
+func TestCapturePanicsRepanic(t *testing.T) {
+	lg, _ := createTestFileLogger(t, "Repanic", nil)
+	defer lg.Destroy()
+
+	defer func() {
+		if r := recover(); r != "panic sample" {
+			t.Errorf("panic was not raised again [got: %v]", r)
+		}
+	}()
+
+	func() {
+		defer lg.CapturePanics()
+
+		panic("panic sample")
+	}()
+}
+
+func TestCapturePanicsStackTraceMaxDepth(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "PanicStack", func(opts *logger.Options) {
+		opts.SwallowPanics = true
+		opts.StackTraceMaxDepth = 2
+	})
+
+	func() {
+		defer lg.CapturePanics()
+
+		testRecursivePanic(5)
+	}()
+	lg.Destroy()
+
+	entry := parseTestJSONEntry(t, readTestLogFile(t, dir, "PanicStack"))
+	if entry["message"] != "panic: recursive panic sample" {
+		t.Errorf("unexpected message [%v]", entry["message"])
+	}
+	stack, ok := entry["stack"].([]interface{})
+	if !ok || len(stack) != 2 {
+		t.Fatalf("unexpected stack [%v]", entry["stack"])
+	}
+	for _, item := range stack {
+		frame, _ := item.(map[string]interface{})
+		fn, _ := frame["func"].(string)
+		file, _ := frame["file"].(string)
+		line, _ := frame["line"].(float64)
+		if !strings.HasSuffix(fn, "_test.testRecursivePanic") || !strings.HasSuffix(file, "logger_panic_test.go") ||
+			line <= 0 {
+			t.Errorf("unexpected stack frame [%v]", frame)
+		}
+	}
+}
+
+func testRecursivePanic(depth int) {
+	if depth == 0 {
+		panic("recursive panic sample")
+	}
+	testRecursivePanic(depth - 1)
+}
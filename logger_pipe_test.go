@@ -0,0 +1,110 @@
+package go_logger_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+const pipeTestMessageCount = 2000
+
+func TestPipeOverflowDrop(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unable to create pipe. [%v]", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	lg := createTestPipeLogger(t, w, logger.PipeOverflowDrop)
+
+	// Nobody is reading so the pipe will become full
+	start := time.Now()
+	for i := 0; i < pipeTestMessageCount; i++ {
+		lg.Info(fmt.Sprintf("This is the information message sample #%v with some extra padding to fill", i))
+	}
+	elapsed := time.Since(start)
+
+	lg.Destroy()
+	_ = w.Close()
+
+	if elapsed > 2*time.Second {
+		t.Errorf("logging to a full pipe took too long [%v]", elapsed)
+	}
+
+	count := countTestPipeLines(t, r)
+	if count == 0 || count >= pipeTestMessageCount {
+		t.Errorf("unexpected number of messages received [%v]", count)
+	}
+}
+
+func TestPipeOverflowBlock(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unable to create pipe. [%v]", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	lg := createTestPipeLogger(t, w, logger.PipeOverflowBlock)
+
+	// Start a slow reader
+	countCh := make(chan int)
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		countCh <- countTestPipeLines(t, r)
+	}()
+
+	for i := 0; i < pipeTestMessageCount; i++ {
+		lg.Info(fmt.Sprintf("This is the information message sample #%v with some extra padding to fill", i))
+	}
+
+	lg.Destroy()
+	_ = w.Close()
+
+	count := <-countCh
+	if count != pipeTestMessageCount {
+		t.Errorf("unexpected number of messages received [got: %v, expected: %v]", count, pipeTestMessageCount)
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func createTestPipeLogger(t *testing.T, w *os.File, policy logger.PipeOverflowPolicy) *logger.Logger {
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Pipe: &logger.PipeOptions{
+			File:           w,
+			OverflowPolicy: policy,
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg
+}
+
+func countTestPipeLines(t *testing.T, r *os.File) int {
+	count := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if !strings.HasSuffix(scanner.Text(), "with some extra padding to fill") {
+			t.Errorf("received a corrupted message [%v]", scanner.Text())
+		}
+		count += 1
+	}
+	return count
+}
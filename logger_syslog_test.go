@@ -1,310 +1,1106 @@
 package go_logger_test
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"encoding/pem"
 	"errors"
 	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/influxdata/go-syslog/v3/rfc3164"
 	logger "github.com/randlabs/go-logger/v2"
+	"github.com/randlabs/go-logger/v2/syslogtest"
 )
 
 //------------------------------------------------------------------------------
 
+const (
+	sysLogTestMessageCount = 8
+	sysLogTestTimeout      = 5 * time.Second
+)
+
+// countingSessionCache tracks the sessions stored in a TLS client session cache.
+type countingSessionCache struct {
+	tls.ClientSessionCache
+	puts int32
+}
+
+// shortWriteConn writes at most a few bytes per call without returning an error, like a congested stream.
+type shortWriteConn struct {
+	net.Conn
+}
+
+//------------------------------------------------------------------------------
+
 func TestSysLogUDP(t *testing.T) {
-	var serverErr error
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
 
-	wg := sync.WaitGroup{}
+	lg := createTestSysLogLogger(t, srv, nil)
+	printTestMessages(lg)
+	lg.Destroy()
 
-	ctx, cancelCtx := context.WithCancel(context.Background())
-	wg.Add(1)
-	go func () {
-		defer wg.Done()
+	checkTestSysLogMessages(t, srv, sysLogTestMessageCount)
+}
 
-		serverErr = runMockSysLogUdpServer(ctx, t)
-	}()
+func TestSysLogTCP(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+	})
+	printTestMessages(lg)
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, sysLogTestMessageCount)
+}
+
+func TestSysLogTLS(t *testing.T) {
+	serverTlsConfig, rootCAs, err := syslogtest.NewSelfSignedTLSConfig("127.0.0.1")
+	if err != nil {
+		t.Fatalf("unable to create certificate. [%v]", err)
+	}
+
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		TlsConfig: serverTlsConfig,
+	})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.AppName = "TestApp"
+		opts.UseTcp = true
+		opts.UseTls = true
+		opts.UseRFC5424 = true
+		opts.TlsConfig = &tls.Config{
+			RootCAs:    rootCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+	})
+	printTestMessages(lg)
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, sysLogTestMessageCount)
+	for _, entry := range srv.Entries() {
+		if !entry.RFC5424 || entry.AppName != "TestApp" {
+			t.Errorf("unexpected message [%+v]", entry)
+		}
+	}
+}
+
+func TestSysLogRFC5424Timestamp(t *testing.T) {
+	restore := logger.SetTimeNow(func() time.Time {
+		return time.Date(2021, 3, 14, 23, 59, 59, 0, time.UTC)
+	})
+	defer restore()
+
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseRFC5424 = true
+	})
+	lg.Info("This is an information message sample")
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 1)
+	if entry := srv.Entries()[0]; !strings.Contains(entry.Raw, " 2021-03-14T23:59:59Z ") {
+		t.Errorf("unexpected timestamp [%v]", entry.Raw)
+	}
+}
+
+func TestSysLogTLSRootCAs(t *testing.T) {
+	serverTlsConfig, _, err := syslogtest.NewSelfSignedTLSConfig("127.0.0.1")
+	if err != nil {
+		t.Fatalf("unable to create certificate. [%v]", err)
+	}
+
+	// Store the server certificate in a PEM file to use it as an additional root CA
+	caFile, err := filepath.Abs(filepath.FromSlash("./testdata/logs/syslogrootcas/ca.pem"))
+	if err != nil {
+		t.Fatalf("unable to get CA file path. [%v]", err)
+	}
+	_ = os.RemoveAll(filepath.Dir(caFile))
+	_ = os.MkdirAll(filepath.Dir(caFile), 0755)
+	pemCert := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: serverTlsConfig.Certificates[0].Certificate[0],
+	})
+	err = os.WriteFile(caFile, pemCert, 0644)
+	if err != nil {
+		t.Fatalf("unable to write CA file. [%v]", err)
+	}
+
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		TlsConfig: serverTlsConfig,
+	})
+	defer srv.Close()
+
+	// A partial TLS configuration must still get a root CAs pool
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+		opts.UseTls = true
+		opts.TlsConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		opts.CAFile = caFile
+	})
+	if tlsConfig := logger.SysLogTLSConfig(lg); tlsConfig == nil || tlsConfig.RootCAs == nil {
+		t.Fatalf("root CAs pool was not set")
+	}
+	printTestMessages(lg)
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, sysLogTestMessageCount)
+}
+
+func TestSysLogTLSSessionResumption(t *testing.T) {
+	serverTlsConfig, rootCAs, err := syslogtest.NewSelfSignedTLSConfig("127.0.0.1")
+	if err != nil {
+		t.Fatalf("unable to create certificate. [%v]", err)
+	}
+
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		TlsConfig: serverTlsConfig,
+	})
+	defer srv.Close()
+
+	sessionCache := &countingSessionCache{
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+	}
+	modifier := func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+		opts.UseTls = true
+		opts.TlsConfig = &tls.Config{
+			RootCAs:    rootCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+		opts.TLSSessionCache = sessionCache
+		opts.KeepAlive = 30 * time.Second
+	}
+
+	// The first connection does a full handshake and stores the session
+	lg1 := createTestSysLogLogger(t, srv, modifier)
+	defer lg1.Destroy()
+	lg1.Info("This is an information message sample")
+	checkTestSysLogMessages(t, srv, 1)
+	deadline := time.Now().Add(sysLogTestTimeout)
+	for atomic.LoadInt32(&sessionCache.puts) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the TLS session was not cached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The second connection must resume it
+	lg2 := createTestSysLogLogger(t, srv, modifier)
+	defer lg2.Destroy()
+	lg2.Info("This is another information message sample")
+	checkTestSysLogMessages(t, srv, 2)
+
+	if srv.Connections() != 2 || srv.ResumedSessions() != 1 {
+		t.Errorf("the TLS session was not resumed [connections: %v, resumed: %v]",
+			srv.Connections(), srv.ResumedSessions())
+	}
+}
+
+func TestSysLogHealth(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+	})
+	defer lg.Destroy()
+
+	lg.Info("This is an information message sample")
+	checkTestSysLogMessages(t, srv, 1)
+	health, ok := lg.SysLogHealth()
+	if !ok || health.State != logger.SysLogConnected || health.Sent != 1 || !health.LastErrorTime.IsZero() {
+		t.Fatalf("unexpected health status [%+v]", health)
+	}
+
+	// Stop the server and keep logging until delivery fails. The first write after the peer closed the
+	// connection might still succeed.
+	srv.Close()
+	start := time.Now()
+	deadline := start.Add(sysLogTestTimeout)
+	for {
+		lg.Info("This is another information message sample")
+		time.Sleep(10 * time.Millisecond)
+
+		health, _ = lg.SysLogHealth()
+		if health.State == logger.SysLogReconnecting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery error was not reported [%+v]", health)
+		}
+	}
+	if health.LastErrorTime.Before(start) || time.Since(health.LastErrorTime) > sysLogTestTimeout {
+		t.Errorf("unexpected last error time [%v]", health.LastErrorTime)
+	}
+	if health.Dropped == 0 {
+		t.Errorf("dropped messages were not counted [%+v]", health)
+	}
+}
+
+func TestSysLogFallbackFile(t *testing.T) {
+	fallbackFile, err := filepath.Abs(filepath.FromSlash("./testdata/logs/syslogfallback/fallback.log"))
+	if err != nil {
+		t.Fatalf("unable to get fallback file path. [%v]", err)
+	}
+	_ = os.RemoveAll(filepath.Dir(fallbackFile))
+
+	// Start a server and stop it to get a port where nobody is listening
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+		opts.FallbackFile = fallbackFile
+	})
+	lg.Error("This is an error message sample")
+	lg.Warning("This is a warning message sample")
+	lg.Info("This is an information message sample")
+	lg.Destroy()
+
+	content, err := os.ReadFile(fallbackFile)
+	if err != nil {
+		t.Fatalf("unable to read fallback file. [%v]", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected number of lines [got: %v, expected: 3]", len(lines))
+	}
+	for idx, s := range []string{"error message", "warning message", "information message"} {
+		if !strings.Contains(lines[idx], s) {
+			t.Errorf("unexpected line #%v [%v]", idx+1, lines[idx])
+		}
+	}
+}
+
+func TestSysLogSpoolDir(t *testing.T) {
+	spoolDir, err := filepath.Abs(filepath.FromSlash("./testdata/logs/syslogspool"))
+	if err != nil {
+		t.Fatalf("unable to get spool directory path. [%v]", err)
+	}
+	_ = os.RemoveAll(spoolDir)
+
+	// Start a server and stop it to get a port where nobody is listening
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+		opts.SpoolDir = spoolDir
+	})
+	lg.Error("This is an error message sample")
+	lg.Warning("This is a warning message sample")
+	lg.Destroy()
+
+	// Restart the server on the same port and check the spooled messages are sent by the next run
+	srv = startTestSysLogServer(t, syslogtest.MockServerOptions{
+		Address: srv.Addr().String(),
+		UseTcp:  true,
+	})
+	defer srv.Close()
+
+	lg = createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+		opts.SpoolDir = spoolDir
+	})
+	lg.Info("This is an information message sample")
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 3)
+	messages := srv.Messages()
+	for idx, s := range []string{"error message", "warning message", "information message"} {
+		if !strings.Contains(messages[idx], s) {
+			t.Errorf("unexpected message #%v [%v]", idx+1, messages[idx])
+		}
+	}
+}
 
+func TestSysLogConnFactory(t *testing.T) {
+	var dials int32
+
+	lines := make(chan string, 16)
 	lg, err := logger.Create(logger.Options{
 		Console: logger.ConsoleOptions{
 			Disable: true,
 		},
 		SysLog: &logger.SysLogOptions{
-			Host: "127.0.0.1",
-			Port: 514,
+			ConnFactory: func() (net.Conn, error) {
+				client, server := net.Pipe()
+				atomic.AddInt32(&dials, 1)
+				go func() {
+					scanner := bufio.NewScanner(server)
+					for scanner.Scan() {
+						lines <- scanner.Text()
+					}
+					_ = server.Close()
+				}()
+				return client, nil
+			},
 		},
-		Level:        logger.LogLevelDebug,
-		DebugLevel:   1,
-		UseLocalTime: false,
+		Level: logger.LogLevelInfo,
 	})
 	if err != nil {
-		t.Errorf("unable to initialize. [%v]", err)
-		cancelCtx()
-		wg.Wait()
-		return
+		t.Fatalf("unable to initialize. [%v]", err)
 	}
+	lg.Error("This is an error message sample")
+	lg.Warning("This is a warning message sample")
+	lg.Info("This is an information message sample")
+	lg.Destroy()
 
-	printTestMessages(lg)
+	for idx, s := range []string{"error message", "warning message", "information message"} {
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, s) {
+				t.Errorf("unexpected message #%v [%v]", idx+1, line)
+			}
+		case <-time.After(sysLogTestTimeout):
+			t.Fatalf("message #%v was not received", idx+1)
+		}
+	}
+	if atomic.LoadInt32(&dials) != 1 {
+		t.Errorf("unexpected number of connections [got: %v, expected: 1]", dials)
+	}
+}
 
+func TestSysLogPartialWrites(t *testing.T) {
+	lines := make(chan string, 16)
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		SysLog: &logger.SysLogOptions{
+			ConnFactory: func() (net.Conn, error) {
+				client, server := net.Pipe()
+				go func() {
+					scanner := bufio.NewScanner(server)
+					for scanner.Scan() {
+						lines <- scanner.Text()
+					}
+					_ = server.Close()
+				}()
+				return &shortWriteConn{
+					Conn: client,
+				}, nil
+			},
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	msg := strings.Repeat("0123456789", 200)
+	lg.Info(msg)
+	lg.Info("This is an information message sample")
 	lg.Destroy()
-	time.Sleep(3 * time.Second) // Let's give some time to process all
-	cancelCtx()
-	wg.Wait()
 
-	if serverErr != nil {
-		t.Errorf("server error. [%v]", serverErr)
+	for idx, s := range []string{msg, "This is an information message sample"} {
+		select {
+		case line := <-lines:
+			if !strings.HasSuffix(line, " "+s) {
+				t.Errorf("message #%v was not delivered intact [%v]", idx+1, line)
+			}
+		case <-time.After(sysLogTestTimeout):
+			t.Fatalf("message #%v was not received", idx+1)
+		}
 	}
 }
 
-func TestSysLogTCP(t *testing.T) {
-	var serverErr error
-
-	wg := sync.WaitGroup{}
+func TestSysLogIdleReconnect(t *testing.T) {
+	const serverIdleTimeout = 100 * time.Millisecond
 
-	ctx, cancelCtx := context.WithCancel(context.Background())
-	wg.Add(1)
-	go func () {
-		defer wg.Done()
+	var accepted int32
 
-		serverErr = runMockSysLogTcpServer(ctx, t)
+	// Start a server that closes connections idle for a while, like a NAT device would forget them
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to start server. [%v]", err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+	lines := make(chan string, 16)
+	go func() {
+		for {
+			conn, err2 := l.Accept()
+			if err2 != nil {
+				return
+			}
+			atomic.AddInt32(&accepted, 1)
+			go func() {
+				r := bufio.NewReader(conn)
+				for {
+					_ = conn.SetReadDeadline(time.Now().Add(serverIdleTimeout))
+					line, err3 := r.ReadString('\n')
+					if err3 != nil {
+						_ = conn.Close()
+						return
+					}
+					lines <- line
+				}
+			}()
+		}
 	}()
 
+	addr := l.Addr().(*net.TCPAddr)
 	lg, err := logger.Create(logger.Options{
 		Console: logger.ConsoleOptions{
 			Disable: true,
 		},
 		SysLog: &logger.SysLogOptions{
-			Host:   "127.0.0.1",
-			Port:   1468,
-			UseTcp: true,
+			Host:          addr.IP.String(),
+			Port:          uint16(addr.Port),
+			UseTcp:        true,
+			IdleReconnect: serverIdleTimeout / 2,
 		},
-		Level:        logger.LogLevelDebug,
-		DebugLevel:   1,
-		UseLocalTime: false,
+		Level: logger.LogLevelInfo,
 	})
 	if err != nil {
-		t.Errorf("unable to initialize. [%v]", err)
-		cancelCtx()
-		wg.Wait()
-		return
+		t.Fatalf("unable to initialize. [%v]", err)
 	}
+	defer lg.Destroy()
 
-	printTestMessages(lg)
+	for idx, s := range []string{"before idle", "after idle"} {
+		if idx > 0 {
+			// Stay idle until the server drops the connection
+			time.Sleep(3 * serverIdleTimeout)
+		}
+		lg.Info("This message is sent " + s)
+
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, s) {
+				t.Errorf("unexpected message #%v [%v]", idx+1, line)
+			}
+		case <-time.After(sysLogTestTimeout):
+			t.Fatalf("message #%v was not received", idx+1)
+		}
+	}
+	if atomic.LoadInt32(&accepted) != 2 {
+		t.Errorf("unexpected number of connections [got: %v, expected: 2]", accepted)
+	}
+}
+
+func TestSysLogCEF(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
 
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.AppName = "Gate"
+		opts.CEF = &logger.CEFOptions{
+			Vendor:  "Ac|me",
+			Version: "1.0",
+		}
+	})
+	lg.Error(struct {
+		Message string `json:"message"`
+		User    string `json:"user"`
+		Query   string `json:"query"`
+		Port    int    `json:"port"`
+		Extra   *int   `json:"extra"`
+	}{
+		Message: "Login failed",
+		User:    "jdoe|admin",
+		Query:   "a=b\\c\nd",
+		Port:    22,
+	})
+	lg.Info("Service started")
 	lg.Destroy()
-	time.Sleep(3 * time.Second) // Let's give some time to process all
-	cancelCtx()
-	wg.Wait()
 
-	if serverErr != nil {
-		t.Errorf("server error. [%v]", serverErr)
+	checkTestSysLogMessages(t, srv, 2)
+	entries := srv.Entries()
+
+	expected := regexp.MustCompile(`^CEF:0\|Ac\\\|me\|Gate\|1\.0\|error\|Login failed\|7\|rt=\d+ ` +
+		`user=jdoe\|admin query=a\\=b\\\\c\\nd port=22$`)
+	if !expected.MatchString(entries[0].Text) {
+		t.Errorf("unexpected CEF event [%v]", entries[0].Text)
+	}
+	expected = regexp.MustCompile(`^CEF:0\|Ac\\\|me\|Gate\|1\.0\|info\|Service started\|3\|rt=\d+$`)
+	if !expected.MatchString(entries[1].Text) {
+		t.Errorf("unexpected CEF event [%v]", entries[1].Text)
 	}
 }
 
-//------------------------------------------------------------------------------
-// Private methods
+func TestSysLogWaitForDelivery(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+	})
+	defer lg.Destroy()
+
+	printTestMessages(lg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), sysLogTestTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := lg.WaitForDelivery(ctx); err != nil {
+		t.Fatalf("unable to wait for delivery. [%v]", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wait took too long [%v]", elapsed)
+	}
+	if health, _ := lg.SysLogHealth(); health.Sent != sysLogTestMessageCount || health.QueueLength != 0 {
+		t.Errorf("messages still pending after wait [%+v]", health)
+	}
 
-func runMockSysLogUdpServer(ctx context.Context, t *testing.T) error {
-	var conn *net.UDPConn
+	checkTestSysLogMessages(t, srv, sysLogTestMessageCount)
+}
 
-	// Create UDP listener
-	udpAddr, err := net.ResolveUDPAddr("udp", "127.0.0.1:514")
+func TestSysLogWaitForDeliveryTimeout(t *testing.T) {
+	// Nobody reads the other end of the pipe, so writes block
+	client, server := net.Pipe()
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		SysLog: &logger.SysLogOptions{
+			ConnFactory: func() (net.Conn, error) {
+				return client, nil
+			},
+		},
+		Level: logger.LogLevelInfo,
+	})
 	if err != nil {
-		return err
+		t.Fatalf("unable to initialize. [%v]", err)
 	}
 
-	conn, err = net.ListenUDP("udp", udpAddr)
+	lg.Info("This is an information message sample")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err = lg.WaitForDelivery(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected wait result [%v]", err)
+	}
+
+	// Unblock the pending write
+	_ = server.Close()
+	lg.Destroy()
+}
+
+func TestSysLogStopTimeout(t *testing.T) {
+	// The server never reads, so writes block once the socket buffers are full
+	port, stop := startTestStalledServer(t)
+	defer stop()
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		SysLog: &logger.SysLogOptions{
+			Host:        "127.0.0.1",
+			Port:        port,
+			UseTcp:      true,
+			StopTimeout: 200 * time.Millisecond,
+		},
+		Level: logger.LogLevelInfo,
+	})
 	if err != nil {
-		return err
+		t.Fatalf("unable to initialize. [%v]", err)
 	}
 
-	// Set read buffer size
-	err = conn.SetReadBuffer(1024)
+	payload := strings.Repeat("x", 64*1024)
+	for i := 0; i < 256; i++ {
+		lg.Info(payload)
+	}
+
+	start := time.Now()
+	lg.Destroy()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("destroy took too long [%v]", elapsed)
+	}
+}
+
+func TestSysLogStopTimeoutTLSHandshake(t *testing.T) {
+	// The server never answers, so the TLS handshake does not complete
+	port, stop := startTestStalledServer(t)
+	defer stop()
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		SysLog: &logger.SysLogOptions{
+			Host:   "127.0.0.1",
+			Port:   port,
+			UseTcp: true,
+			UseTls: true,
+			TlsConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+			StopTimeout: 200 * time.Millisecond,
+		},
+		Level: logger.LogLevelInfo,
+	})
 	if err != nil {
-		_ = conn.Close()
-		return err
+		t.Fatalf("unable to initialize. [%v]", err)
 	}
 
-	// Launch connection loop
-	wg := sync.WaitGroup{}
-	errCh := make(chan error, 1)
+	lg.Info("This is an information message sample")
+	time.Sleep(50 * time.Millisecond)
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	start := time.Now()
+	lg.Destroy()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("destroy took too long [%v]", elapsed)
+	}
+}
 
-		buf := make([]byte, 1024)
-		for {
-			// Read message
-			n, _, err2 := conn.ReadFrom(buf)
-			if err2 == nil {
-				if n == 0 {
-					// Graceful shutdown
-					return
-				}
-				// Ignore trailing control characters and NULs
-				for ; n > 0 && buf[n-1] < 32; n-- {
-				}
-				if n > 0 {
-					// Process message if any
-					err2 = processMessage(t, buf[:n])
-					if err2 != nil {
-						errCh <- err2
-						return
-					}
-				}
-			} else {
-				// On error, check if it is a network one
-				var opError *net.OpError
+func TestSysLogLevelMatchesSeverity(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
 
-				if errors.Is(err2, net.ErrClosed) {
-					return
-				}
-				if errors.As(err2, &opError) && !opError.Temporary() && !opError.Timeout() {
-					errCh <- err2
-					return
-				}
-				time.Sleep(10 * time.Millisecond)
-			}
-		}
-	}()
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		SysLog: &logger.SysLogOptions{
+			Host: "127.0.0.1",
+			Port: srv.Port(),
+		},
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	pushed := lg.With("level", "debug")
+	pushed.Warning(struct {
+		Message string `json:"message"`
+		Level   string `json:"level"`
+	}{
+		Message: "This is a warning message sample",
+		Level:   "info",
+	})
+	lg.Destroy()
 
-	// Wait until shutdown if requested or some error happens
-	select {
-		case <-ctx.Done():
-			err = nil
-		case err = <-errCh:
+	checkTestSysLogMessages(t, srv, 1)
+	entry := srv.Entries()[0]
+	if entry.Severity != uint8(logger.SeverityWarning) {
+		t.Errorf("unexpected severity [got: %v, expected: %v]", entry.Severity, logger.SeverityWarning)
 	}
+	if strings.Count(entry.Text, `"level"`) != 1 || parseTestJSONEntry(t, entry.Text)["level"] != "warning" {
+		t.Errorf("level field does not match the severity [%v]", entry.Text)
+	}
+}
 
-	// Shut down
-	_ = conn.Close()
-	wg.Wait()
+func TestSysLogSeverityFromField(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
 
-	// Done
-	return err
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.SeverityFromField = "severity"
+	})
+	lg.Info(struct {
+		Message  string `json:"message"`
+		Severity int    `json:"severity"`
+	}{
+		Message:  "This is a forwarded error message sample",
+		Severity: 3,
+	})
+	lg.Info(struct {
+		Message  string `json:"message"`
+		Severity string `json:"severity"`
+	}{
+		Message:  "This is a forwarded critical message sample",
+		Severity: "crit",
+	})
+	lg.Info(struct {
+		Message  string `json:"message"`
+		Severity int    `json:"severity"`
+	}{
+		Message:  "This is a message sample with an invalid severity",
+		Severity: 12,
+	})
+	lg.Warning("This is a warning message sample")
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 4)
+	expected := []logger.Severity{
+		logger.SeverityError, logger.SeverityCritical, logger.SeverityInformational, logger.SeverityWarning,
+	}
+	for idx, entry := range srv.Entries() {
+		if entry.Severity != uint8(expected[idx]) {
+			t.Errorf("unexpected severity of message #%v [got: %v, expected: %v]", idx+1, entry.Severity,
+				expected[idx])
+		}
+	}
 }
 
-func runMockSysLogTcpServer(ctx context.Context, t *testing.T) error {
-	var listener *net.TCPListener
+func TestSysLogInternalErrorsLogged(t *testing.T) {
+	// Start a server and stop it to get a port where nobody is listening
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	srv.Close()
+
+	lg, dir := createTestFileLogger(t, "SysLogInternalErrors", func(opts *logger.Options) {
+		opts.SysLog = &logger.SysLogOptions{
+			Host:   "127.0.0.1",
+			Port:   srv.Port(),
+			UseTcp: true,
+		}
+		opts.LogInternalErrors = true
+	})
+	defer lg.Destroy()
 
-	// Start TCP listener
-	tcpAddr, err := net.ResolveTCPAddr("tcp", "127.0.0.1:1468")
-	if err != nil {
-		return err
+	lg.Info("This is an information message sample")
+
+	// The delivery failure is logged from another goroutine
+	deadline := time.Now().Add(sysLogTestTimeout)
+	for {
+		content := readTestLogFile(t, dir, "SysLogInternalErrors")
+		if strings.Contains(content, "[WARNING]: Internal error in syslog target: Unable to deliver") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the syslog failure was not logged to the file [%v]", content)
+		}
+		time.Sleep(10 * time.Millisecond)
 	}
+}
+
+func TestSysLogErrorNotifyInterval(t *testing.T) {
+	var notifications int32
+
+	// Start a server and stop it to get a port where nobody is listening
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	srv.Close()
 
-	listener, err = net.ListenTCP("tcp", tcpAddr)
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		SysLog: &logger.SysLogOptions{
+			Host:   "127.0.0.1",
+			Port:   srv.Port(),
+			UseTcp: true,
+		},
+		Level:               logger.LogLevelInfo,
+		ErrorNotifyInterval: 50 * time.Millisecond,
+		ErrorHandler: func(message string) {
+			if strings.Contains(message, "Unable to deliver") {
+				atomic.AddInt32(&notifications, 1)
+			}
+		},
+	})
 	if err != nil {
-		return err
+		t.Fatalf("unable to initialize. [%v]", err)
 	}
+	defer lg.Destroy()
 
-	// Launch listener loop
-	wg := sync.WaitGroup{}
-	errCh := make(chan error)
+	// Keep failing for several intervals
+	deadline := time.Now().Add(sysLogTestTimeout)
+	for atomic.LoadInt32(&notifications) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the persistent failure was not notified again [got: %v notifications]", notifications)
+		}
+		lg.Info("This is an information message sample")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSysLogUDPSplitDatagrams(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.MaxDatagramSize = 512
+		opts.SplitDatagrams = true
+	})
 
-	wg.Add(1)
+	// Send a message that does not fit in a single datagram
+	lg.Info(strings.Repeat("0123456789", 120))
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 3)
+	messages := srv.Messages()
+	if !strings.HasSuffix(messages[0], "...") || strings.HasSuffix(messages[2], "...") {
+		t.Errorf("unexpected continuation markers [%v]", messages)
+	}
+}
+
+func TestSysLogUDPSplitDatagramsInvalidUTF8(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.MaxDatagramSize = 100
+		opts.SplitDatagrams = true
+	})
+
+	// A long run of continuation bytes has no place to split between characters
+	doneCh := make(chan struct{})
 	go func() {
-		defer wg.Done()
+		lg.Info(strings.Repeat("\x80", 300))
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(sysLogTestTimeout):
+		t.Fatalf("the message was not split")
+	}
+	lg.Destroy()
 
-		for {
-			conn, _ := listener.Accept()
-			if conn != nil {
-				// Launch connection loop
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-
-					onDivider := false
-					buf := make([]byte, 1024)
-					msg := make([]byte, 0)
-					for {
-						n, err2 := conn.Read(buf)
-						if err2 == nil {
-							if n == 0 {
-								return
-							}
-							ofs := 0
-							for ofs < n {
-								if onDivider {
-									for ; ofs < n && (buf[ofs] == '\r' || buf[ofs] == '\n'); ofs++ {
-									}
-									if ofs < n {
-										onDivider = false
-									}
-								} else {
-									startOfs := ofs
-									for ; ofs < n && buf[ofs] != '\r' && buf[ofs] != '\n'; ofs++ {
-									}
-									if startOfs < ofs {
-										msg = append(msg, buf[startOfs:ofs]...)
-									}
-									if ofs < n {
-										onDivider = true
-										if len(msg) > 0 {
-											err2 = processMessage(t, msg)
-											if err2 != nil {
-												errCh <- err2
-												return
-											}
-											msg = msg[:0]
-										}
-									}
-								}
-							}
-						} else {
-							// On error, check if it is a network one
-							var opError *net.OpError
-
-							if errors.Is(err2, net.ErrClosed) {
-								return
-							}
-							if errors.As(err2, &opError) && !opError.Temporary() && !opError.Timeout() {
-								errCh <- err2
-								return
-							}
-							return
-						}
-					}
-				}()
-			} else {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
-			}
+	if !srv.WaitForMessages(5, sysLogTestTimeout) {
+		t.Fatalf("unexpected number of datagrams received [%v]", len(srv.Messages()))
+	}
+	count := 0
+	for _, entry := range srv.Entries() {
+		if len(entry.Raw) > 100 {
+			t.Errorf("datagram too long [%v]", len(entry.Raw))
 		}
-	}()
+		count += strings.Count(entry.Raw, "\x80")
+	}
+	if count != 300 {
+		t.Errorf("unexpected amount of bytes received [got: %v, expected: 300]", count)
+	}
+}
 
-	// Wait until shutdown if requested or some error happens
-	select {
-	case <-ctx.Done():
-		err = nil
-	case err = <-errCh:
+func TestSysLogHostnameFunc(t *testing.T) {
+	var hostname atomic.Value
+
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
+
+	hostname.Store("first-host")
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.HostnameFunc = func() (string, error) {
+			return hostname.Load().(string), nil
+		}
+	})
+	lg.Info("This is an information message sample")
+	hostname.Store("second-host")
+	lg.Info("This is another information message sample")
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 2)
+	entries := srv.Entries()
+	if entries[0].Hostname != "first-host" || entries[1].Hostname != "second-host" {
+		t.Errorf("unexpected host names received [%v, %v]", entries[0].Hostname, entries[1].Hostname)
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func TestSysLogFacilityByName(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.FacilityByName = map[string]logger.Facility{
+			"auth":    logger.FacilityLocal1,
+			"billing": logger.FacilityLocal2,
+		}
+	})
+	lg.Named("auth").Info("This is an auth message sample")
+	lg.Named("billing").Warning("This is a billing message sample")
+	lg.Named("other").Info("This is an unnamed message sample")
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 3)
+	expected := map[string]logger.Facility{
+		"This is an auth message sample":    logger.FacilityLocal1,
+		"This is a billing message sample":  logger.FacilityLocal2,
+		"This is an unnamed message sample": logger.FacilityUser,
+	}
+	for _, entry := range srv.Entries() {
+		facility, found := expected[entry.Text]
+		if !found {
+			t.Errorf("unexpected message [%v]", entry.Text)
+			continue
+		}
+		if entry.Facility != uint8(facility) || entry.Priority != uint8(facility)*8+entry.Severity {
+			t.Errorf("unexpected priority of message [%v] [got: %v, expected facility: %v]", entry.Text,
+				entry.Priority, facility)
+		}
+	}
+}
+
+func TestSysLogSetEnabled(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	defer srv.Close()
+
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+	})
+	lg.Info("first")
+	checkTestSysLogMessages(t, srv, 1)
+
+	lg.SetEnabled("syslog", false)
+	lg.Info("second")
+	lg.Audit("audit")
+	lg.SetEnabled("syslog", true)
+	lg.Info("third")
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 2)
+	entries := srv.Entries()
+	if len(entries) != 2 || entries[0].Text != "first" || entries[1].Text != "third" {
+		t.Errorf("unexpected messages received [%+v]", entries)
 	}
+	if srv.Connections() != 1 {
+		t.Errorf("the connection was not kept [connections: %v]", srv.Connections())
+	}
+}
 
-	// Shut down
-	_ = listener.Close()
-	wg.Wait()
+func TestSysLogQueueWhileDisabled(t *testing.T) {
+	srv := startTestSysLogServer(t, syslogtest.MockServerOptions{
+		UseTcp: true,
+	})
+	defer srv.Close()
 
-	// Done
-	return err
+	lg := createTestSysLogLogger(t, srv, func(opts *logger.SysLogOptions) {
+		opts.UseTcp = true
+		opts.QueueWhileDisabled = true
+	})
+	lg.Info("first")
+	checkTestSysLogMessages(t, srv, 1)
+
+	lg.SetEnabled("syslog", false)
+	lg.Info("second")
+	lg.Debug(2, "filtered")
+	lg.Audit("audit")
+	if err := lg.Sync(); err != nil {
+		t.Errorf("unable to sync. [%v]", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if health, _ := lg.SysLogHealth(); health.QueueLength != 2 || len(srv.Messages()) != 1 {
+		t.Errorf("the message was not kept in the queue [queued: %v, received: %v]", health.QueueLength,
+			len(srv.Messages()))
+	}
+
+	lg.SetEnabled("syslog", true)
+	lg.Info("third")
+	lg.Destroy()
+
+	checkTestSysLogMessages(t, srv, 4)
+	entries := srv.Entries()
+	if len(entries) != 4 || entries[0].Text != "first" || entries[1].Text != "second" ||
+		entries[2].Text != "audit" || entries[3].Text != "third" {
+		t.Errorf("unexpected messages received [%+v]", entries)
+	}
+	if srv.Connections() != 1 {
+		t.Errorf("the connection was not kept [connections: %v]", srv.Connections())
+	}
 }
 
-func processMessage(t *testing.T, msg []byte) error {
-	// Parse the syslog message
-	p := rfc3164.NewParser()
-	_m, err := p.Parse(msg)
+func startTestSysLogServer(t *testing.T, opts syslogtest.MockServerOptions) *syslogtest.MockServer {
+	srv, err := syslogtest.StartMockServer(opts)
 	if err != nil {
-		return err
+		t.Fatalf("unable to start mock server. [%v]", err)
 	}
+	return srv
+}
 
-	m := _m.(*rfc3164.SyslogMessage)
-	if m.Message != nil {
-		t.Logf("MockSysLogServer received message: %v", *m.Message)
+func createTestSysLogLogger(
+	t *testing.T, srv *syslogtest.MockServer, modifier func(opts *logger.SysLogOptions),
+) *logger.Logger {
+	sysLogOpts := logger.SysLogOptions{
+		Host: "127.0.0.1",
+		Port: srv.Port(),
+	}
+	if modifier != nil {
+		modifier(&sysLogOpts)
 	}
 
-	return nil
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		SysLog:     &sysLogOpts,
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	return lg
+}
+
+func checkTestSysLogMessages(t *testing.T, srv *syslogtest.MockServer, count int) {
+	if !srv.WaitForMessages(count, sysLogTestTimeout) {
+		t.Fatalf("unexpected number of messages received [got: %v, expected: %v]", len(srv.Messages()), count)
+	}
+	if errs := srv.Errors(); len(errs) > 0 {
+		t.Fatalf("server error. [%v]", errs[0])
+	}
+	for _, msg := range srv.Messages() {
+		t.Logf("MockSysLogServer received message: %v", msg)
+	}
+}
+
+func (c *countingSessionCache) Put(sessionKey string, cs *tls.ClientSessionState) {
+	if cs != nil {
+		atomic.AddInt32(&c.puts, 1)
+	}
+	c.ClientSessionCache.Put(sessionKey, cs)
+}
+
+func (c *shortWriteConn) Write(b []byte) (int, error) {
+	if len(b) > 7 {
+		b = b[:7]
+	}
+	return c.Conn.Write(b)
+}
+
+// startTestStalledServer starts a TCP server accepting connections but never reading from or writing to them. It
+// returns the port and a function to stop the server.
+func startTestStalledServer(t *testing.T) (uint16, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen. [%v]", err)
+	}
+
+	mtx := sync.Mutex{}
+	conns := make([]net.Conn, 0)
+	go func() {
+		for {
+			conn, err2 := listener.Accept()
+			if err2 != nil {
+				return
+			}
+			mtx.Lock()
+			conns = append(conns, conn)
+			mtx.Unlock()
+		}
+	}()
+
+	return uint16(listener.Addr().(*net.TCPAddr).Port), func() {
+		_ = listener.Close()
+		mtx.Lock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+		mtx.Unlock()
+	}
 }
@@ -1,7 +1,18 @@
 package go_logger_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	logger "github.com/randlabs/go-logger/v2"
 )
@@ -30,6 +41,924 @@ func TestLevelOverride(t *testing.T) {
 	printTestMessages(lg)
 }
 
+func TestReconfigure(t *testing.T) {
+	dir, err := filepath.Abs(filepath.FromSlash("./testdata/logs/reconfigure"))
+	if err != nil {
+		t.Fatalf("unable to get log directory. [%v]", err)
+	}
+	_ = os.RemoveAll(dir)
+
+	lg, err := logger.Create(logger.Options{
+		Level: logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	defer lg.Destroy()
+
+	lg.Info("This message goes only to the console")
+
+	// Add file output
+	opts := logger.Options{
+		File: &logger.FileOptions{
+			Prefix:    "Reconfigure",
+			Directory: dir,
+		},
+		Level: logger.LogLevelInfo,
+	}
+	err = lg.Reconfigure(opts)
+	if err != nil {
+		t.Fatalf("unable to reconfigure. [%v]", err)
+	}
+
+	lg.Info("This message goes to the console and the file")
+
+	// A failing configuration must keep the current one
+	err = lg.Reconfigure(logger.Options{
+		Pipe: &logger.PipeOptions{},
+	})
+	if err == nil {
+		t.Fatalf("invalid configuration was accepted")
+	}
+
+	lg.Info("This message also goes to the console and the file")
+
+	content := readTestLogFile(t, dir, "Reconfigure")
+	if strings.Contains(content, "only to the console") {
+		t.Errorf("message logged before reconfiguring was written to the file")
+	}
+	if !strings.Contains(content, "goes to the console and the file") ||
+		!strings.Contains(content, "also goes to the console and the file") {
+		t.Errorf("messages logged after reconfiguring were not written to the file")
+	}
+}
+
+func TestDebugSuppressedHint(t *testing.T) {
+	for _, debugLevel := range []uint{0, 1} {
+		var hints []string
+
+		lg, err := logger.Create(logger.Options{
+			Console: logger.ConsoleOptions{
+				Disable: true,
+			},
+			Level:      logger.LogLevelDebug,
+			DebugLevel: debugLevel,
+			ErrorHandler: func(message string) {
+				hints = append(hints, message)
+			},
+		})
+		if err != nil {
+			t.Fatalf("unable to initialize. [%v]", err)
+		}
+		lg.Debug(1, "This is a debug message sample")
+		lg.Debug(1, "This is another debug message sample")
+		lg.Destroy()
+
+		expected := 0
+		if debugLevel == 0 {
+			expected = 1
+		}
+		if len(hints) != expected {
+			t.Errorf("unexpected number of hints with debug level %v [got: %v, expected: %v]",
+				debugLevel, len(hints), expected)
+		}
+		if len(hints) > 0 && !strings.Contains(hints[0], "DebugLevel is 0") {
+			t.Errorf("unexpected hint [%v]", hints[0])
+		}
+	}
+}
+
+func TestDefaultsHint(t *testing.T) {
+	for _, tc := range []struct {
+		syslog   logger.SysLogOptions
+		suppress bool
+		expected int
+	}{
+		{expected: 1},
+		{suppress: true},
+		{syslog: logger.SysLogOptions{Host: "127.0.0.1"}},
+	} {
+		var hints []string
+
+		sysLogOpts := tc.syslog
+		lg, err := logger.Create(logger.Options{
+			Console: logger.ConsoleOptions{
+				Disable: true,
+			},
+			SysLog:               &sysLogOpts,
+			SuppressDefaultsHint: tc.suppress,
+			ErrorHandler: func(message string) {
+				hints = append(hints, message)
+			},
+		})
+		if err != nil {
+			t.Fatalf("unable to initialize. [%v]", err)
+		}
+		lg.Destroy()
+
+		if len(hints) != tc.expected {
+			t.Errorf("unexpected number of hints [got: %v, expected: %v, hints: %v]", len(hints), tc.expected, hints)
+		}
+		if len(hints) > 0 && !strings.Contains(hints[0], "syslog options are empty") {
+			t.Errorf("unexpected hint [%v]", hints[0])
+		}
+	}
+}
+
+func TestIncludeSequence(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters:        []logger.Adapter{adapter},
+		Level:           logger.LogLevelInfo,
+		IncludeSequence: true,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	lg.Info("first")
+	lg.Debug(1, "filtered messages must not consume sequence numbers")
+	lg.Info(JsonMessage{
+		Message: "second",
+	})
+	lg.Warning("third")
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("unexpected number of entries [got: %v, expected: 3]", len(entries))
+	}
+	if entries[0].msg != "#1 first" || entries[2].msg != "#3 third" {
+		t.Errorf("unexpected text sequence numbers [%v, %v]", entries[0].msg, entries[2].msg)
+	}
+	if !strings.Contains(entries[1].msg, `"seq":2,`) {
+		t.Errorf("unexpected JSON sequence number [%v]", entries[1].msg)
+	}
+}
+
+func TestWarnOnUseAfterDestroy(t *testing.T) {
+	var warnings []string
+
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Level:                 logger.LogLevelInfo,
+		WarnOnUseAfterDestroy: true,
+		ErrorHandler: func(message string) {
+			warnings = append(warnings, message)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Destroy()
+
+	lg.Info("This is an information message sample")
+	lg.Error("This is an error message sample")
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "destroyed") {
+		t.Errorf("unexpected warnings [%v]", warnings)
+	}
+
+	// Without an error handler, the warning goes to the standard error
+	stderr := &bytes.Buffer{}
+	restore := logger.SetConsoleWriters(io.Discard, stderr)
+	defer restore()
+
+	lg, err = logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		WarnOnUseAfterDestroy: true,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	lg.Destroy()
+
+	lg.Info("This is an information message sample")
+	if !strings.Contains(stderr.String(), "destroyed") {
+		t.Errorf("the warning was not written to the standard error")
+	}
+}
+
+func TestDedupByCallSite(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters:        []logger.Adapter{adapter},
+		Level:           logger.LogLevelInfo,
+		DedupByCallSite: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	// The same message from two call sites is deduplicated independently
+	for i := 0; i < 3; i++ {
+		lg.Info("This is a repeated message sample")
+		lg.Info("This is a repeated message sample")
+	}
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("unexpected number of entries [got: %v, expected: 4]", len(entries))
+	}
+	for idx := 0; idx < 2; idx++ {
+		if entries[idx].msg != "This is a repeated message sample" {
+			t.Errorf("unexpected entry #%v [%v]", idx+1, entries[idx].msg)
+		}
+	}
+	sites := make(map[string]struct{})
+	for idx := 2; idx < 4; idx++ {
+		msg := entries[idx].msg
+		if !strings.HasPrefix(msg, "message from logger_test.go:") || !strings.HasSuffix(msg, " repeated 2 times") {
+			t.Errorf("unexpected summary #%v [%v]", idx-1, msg)
+		}
+		sites[msg] = struct{}{}
+	}
+	if len(sites) != 2 {
+		t.Errorf("summaries do not come from different call sites")
+	}
+}
+
+func TestDeferredMessages(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters: []logger.Adapter{adapter},
+		Level:    logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	calls := 0
+	lg.Debug(1, func() interface{} {
+		calls += 1
+		return "This is an expensive debug message sample"
+	})
+	if calls != 0 {
+		t.Errorf("deferred message of a disabled level was evaluated")
+	}
+	lg.Info(func() interface{} {
+		calls += 1
+		return "This is an expensive information message sample"
+	})
+	lg.Info(func() interface{} {
+		calls += 1
+		return JsonMessage{
+			Message: "This is an expensive information message sample",
+		}
+	})
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if calls != 2 || len(entries) != 2 {
+		t.Fatalf("unexpected number of evaluations and entries [got: %v/%v, expected: 2/2]", calls, len(entries))
+	}
+	if entries[0].raw || entries[0].msg != "This is an expensive information message sample" {
+		t.Errorf("unexpected text entry [%+v]", entries[0])
+	}
+	if !entries[1].raw || !strings.Contains(entries[1].msg, `"message":"This is an expensive information`) {
+		t.Errorf("unexpected JSON entry [%+v]", entries[1])
+	}
+}
+
+func TestDeferredMessageLogs(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters: []logger.Adapter{adapter},
+		Level:    logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	// The deferred message logs while a reconfiguration is waiting for the lock
+	doneCh := make(chan struct{})
+	go func() {
+		lg.Info(func() interface{} {
+			setCh := make(chan struct{})
+			go func() {
+				lg.SetLevel(logger.LogLevelDebug, 1, "")
+				close(setCh)
+			}()
+			select {
+			case <-setCh:
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			lg.Warning("This is a warning message sample")
+			return "This is an expensive information message sample"
+		})
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("deferred message deadlocked")
+	}
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if len(entries) != 2 || entries[0].level != logger.LogLevelWarning || entries[1].level != logger.LogLevelInfo {
+		t.Fatalf("unexpected entries [%+v]", entries)
+	}
+}
+
+func TestFormattedMessages(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters:   []logger.Adapter{adapter},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	arg := &countingStringer{}
+	lg.Debugf(2, "This debug message is discarded [%v]", arg)
+	if arg.calls != 0 {
+		t.Errorf("arguments of a discarded message were formatted")
+	}
+	lg.Errorf("This is an error message sample #%d", 1)
+	lg.Warningf("This is a warning message sample #%d", 2)
+	lg.Infof("This is an information message sample #%d [%v]", 3, map[string]int{"a": 1})
+	lg.Debugf(1, "This is a debug message sample #%d", 4)
+	lg.Destroy()
+
+	expected := []struct {
+		level logger.LogLevel
+		msg   string
+	}{
+		{logger.LogLevelError, "This is an error message sample #1"},
+		{logger.LogLevelWarning, "This is a warning message sample #2"},
+		{logger.LogLevelInfo, "This is an information message sample #3 [map[a:1]]"},
+		{logger.LogLevelDebug, "This is a debug message sample #4"},
+	}
+	entries := adapter.Entries()
+	if len(entries) != len(expected) {
+		t.Fatalf("unexpected number of entries [got: %v, expected: %v]", len(entries), len(expected))
+	}
+	for idx, entry := range entries {
+		// Formatted messages are always plain text
+		if entry.raw || entry.level != expected[idx].level || entry.msg != expected[idx].msg {
+			t.Errorf("unexpected entry #%v [%+v]", idx+1, entry)
+		}
+	}
+}
+
+func TestDebugCategories(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters:               []logger.Adapter{adapter},
+		Level:                  logger.LogLevelDebug,
+		EnabledDebugCategories: []string{"sql", "http"},
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+
+	lg.DebugCat("sql", "This is a sql debug message sample")
+	lg.DebugCat("cache", "This message should NOT be printed")
+	lg.DebugCat("http", "This is a http debug message sample")
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("unexpected number of entries [got: %v, expected: 2]", len(entries))
+	}
+	for idx, s := range []string{"sql debug message", "http debug message"} {
+		if entries[idx].level != logger.LogLevelDebug || !strings.Contains(entries[idx].msg, s) {
+			t.Errorf("unexpected entry #%v [%+v]", idx+1, entries[idx])
+		}
+	}
+}
+
+func TestStartCapture(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "Capture", func(opts *logger.Options) {
+		opts.Level = logger.LogLevelInfo
+	})
+	captureFile := filepath.Join(dir, "troubleshooting", "capture.log")
+
+	stop, err := lg.StartCapture(captureFile)
+	if err != nil {
+		t.Fatalf("unable to start capture. [%v]", err)
+	}
+
+	// Log from several goroutines while the capture is active
+	wg := sync.WaitGroup{}
+	for idx := 1; idx <= 4; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			lg.Info(fmt.Sprintf("This is information message sample #%v", idx))
+			lg.Debug(5, fmt.Sprintf("This is debug message sample #%v", idx))
+		}(idx)
+	}
+	wg.Wait()
+	stop()
+	stop()
+	lg.Debug(5, "This message should NOT be printed")
+	lg.Info("This is an information message sample after the capture")
+	lg.Destroy()
+
+	content, err := os.ReadFile(captureFile)
+	if err != nil {
+		t.Fatalf("unable to read capture file. [%v]", err)
+	}
+	capture := string(content)
+	if strings.Count(capture, "\n") != 8 || strings.Count(capture, "[DEBUG]: This is debug message sample") != 4 {
+		t.Errorf("unexpected capture content [%v]", capture)
+	}
+	if strings.Contains(capture, "after the capture") || strings.Contains(capture, "NOT be printed") {
+		t.Errorf("messages were captured after stopping [%v]", capture)
+	}
+
+	// The file target kept its level
+	logContent := readTestLogFile(t, dir, "Capture")
+	if strings.Count(logContent, "\n") != 5 || strings.Contains(logContent, "[DEBUG]") {
+		t.Errorf("unexpected log file content [%v]", logContent)
+	}
+}
+
+func TestSkipEmpty(t *testing.T) {
+	type Optional struct {
+		Name string `json:"name,omitempty"`
+	}
+
+	for _, skipEmpty := range []bool{false, true} {
+		adapter := &testAdapter{}
+		lg, err := logger.Create(logger.Options{
+			Console: logger.ConsoleOptions{
+				Disable: true,
+			},
+			Adapters:  []logger.Adapter{adapter},
+			Level:     logger.LogLevelInfo,
+			SkipEmpty: skipEmpty,
+		})
+		if err != nil {
+			t.Fatalf("unable to initialize. [%v]", err)
+		}
+		lg.Info("")
+		lg.Info(" \t\n")
+		lg.Info(struct{}{})
+		lg.Info(&Optional{})
+		lg.Info(Optional{
+			Name: "jdoe",
+		})
+		lg.Info("This is an information message sample")
+		lg.Destroy()
+
+		entries := adapter.Entries()
+		if !skipEmpty {
+			if len(entries) != 6 {
+				t.Errorf("empty messages were skipped [%+v]", entries)
+			}
+			continue
+		}
+		if len(entries) != 2 || !strings.Contains(entries[0].msg, `"name":"jdoe"`) ||
+			entries[1].msg != "This is an information message sample" {
+			t.Errorf("unexpected entries [%+v]", entries)
+		}
+	}
+}
+
+func TestDuplicateSinks(t *testing.T) {
+	dir, err := filepath.Abs(filepath.FromSlash("./testdata/logs/duplicatesinks"))
+	if err != nil {
+		t.Fatalf("unable to get log directory. [%v]", err)
+	}
+	_ = os.RemoveAll(dir)
+
+	adapter := &testAdapter{}
+	tests := []struct {
+		name string
+		opts logger.Options
+	}{
+		{
+			name: "SharedFile",
+			opts: logger.Options{
+				File: &logger.FileOptions{
+					Directory: dir,
+					FixedName: "app.log",
+				},
+				SysLog: &logger.SysLogOptions{
+					Port:         1,
+					UseTcp:       true,
+					FallbackFile: filepath.Join(dir, "app.log"),
+				},
+			},
+		},
+		{
+			name: "SharedAdapter",
+			opts: logger.Options{
+				Adapters: []logger.Adapter{adapter, adapter},
+			},
+		},
+	}
+	for _, test := range tests {
+		test.opts.Console.Disable = true
+
+		lg, err := logger.Create(test.opts)
+		if err == nil || (!strings.Contains(err.Error(), "same destination") && !strings.Contains(err.Error(), "twice")) {
+			t.Errorf("duplicate %v sink was not detected [%v]", test.name, err)
+		}
+		if lg != nil {
+			lg.Destroy()
+		}
+
+		test.opts.AllowDuplicateSinks = true
+		lg, err = logger.Create(test.opts)
+		if err != nil {
+			t.Errorf("unable to initialize %v with duplicate sinks allowed. [%v]", test.name, err)
+		} else {
+			lg.Destroy()
+		}
+	}
+}
+
+func TestErrorBurst(t *testing.T) {
+	const window = 200 * time.Millisecond
+
+	burstCh := make(chan int, 4)
+	endCh := make(chan int, 4)
+	lg, dir := createTestFileLogger(t, "ErrorBurst", func(opts *logger.Options) {
+		opts.ErrorBurst = &logger.ErrorBurstOptions{
+			Threshold: 10,
+			Window:    window,
+			OnErrorBurst: func(count int, w time.Duration) {
+				if w != window {
+					t.Errorf("unexpected window [%v]", w)
+				}
+				burstCh <- count
+			},
+			OnErrorBurstEnd: func(count int, _ time.Duration) {
+				endCh <- count
+			},
+		}
+	})
+	defer lg.Destroy()
+
+	// Warnings and a few errors do not start a burst
+	for i := 0; i < 20; i++ {
+		lg.Warning("This is a warning message sample")
+	}
+	for i := 0; i < 9; i++ {
+		lg.Error("This is an error message sample")
+	}
+	time.Sleep(2 * window)
+
+	// A storm of errors is signaled once
+	for i := 0; i < 50; i++ {
+		lg.Error("This is an error message sample")
+	}
+	select {
+	case count := <-burstCh:
+		if count != 10 {
+			t.Errorf("unexpected burst count [got: %v, expected: 10]", count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("burst was not signaled")
+	}
+
+	// And once more when it subsides
+	select {
+	case count := <-endCh:
+		if count != 50 {
+			t.Errorf("unexpected amount of errors in the burst [got: %v, expected: 50]", count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("end of burst was not signaled")
+	}
+	if len(burstCh) != 0 {
+		t.Errorf("burst was signaled more than once")
+	}
+
+	// Every message is still logged
+	content := readTestLogFile(t, dir, "ErrorBurst")
+	if n := strings.Count(content, "[ERROR]"); n != 59 {
+		t.Errorf("unexpected amount of logged errors [got: %v, expected: 59]", n)
+	}
+}
+
+func TestTruncateHeadTail(t *testing.T) {
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters: []logger.Adapter{adapter},
+		Level:    logger.LogLevelInfo,
+		TruncateHeadTail: &logger.HeadTailOptions{
+			Head: 24,
+			Tail: 16,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	long := "BEGIN " + strings.Repeat("é", 500) + " exit code=42"
+	lg.Info(long)
+	lg.Info(JsonMessage{
+		Message: long,
+	})
+	lg.Info("This is an information message sample")
+	lg.Destroy()
+
+	entries := adapter.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("unexpected number of entries [got: %v, expected: 3]", len(entries))
+	}
+
+	text := entries[0].msg
+	if !strings.HasPrefix(text, "BEGIN é") || !strings.HasSuffix(text, "é exit code=42") ||
+		!strings.Contains(text, "é...é") || len(text) > 24+16+3 || !utf8.ValidString(text) {
+		t.Errorf("unexpected truncated text message [%v]", text)
+	}
+
+	var entry map[string]interface{}
+	if err = json.Unmarshal([]byte(entries[1].msg), &entry); err != nil {
+		t.Fatalf("truncated JSON message is not valid. [%v]", err)
+	}
+	msg, _ := entry["message"].(string)
+	if entry["level"] != "info" || !strings.HasPrefix(msg, `{"message":"BEGIN`) ||
+		!strings.HasSuffix(msg, `exit code=42"}`) || !strings.Contains(msg, "...") {
+		t.Errorf("unexpected truncated JSON message [%v]", entries[1].msg)
+	}
+
+	if entries[2].msg != "This is an information message sample" {
+		t.Errorf("short message was modified [%v]", entries[2].msg)
+	}
+}
+
+func TestLevelSchedule(t *testing.T) {
+	now := time.Date(2021, 3, 14, 21, 59, 59, 0, time.UTC)
+	restore := logger.SetTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters:   []logger.Adapter{adapter},
+		Level:      logger.LogLevelDebug,
+		DebugLevel: 1,
+		LevelSchedule: []logger.ScheduleEntry{
+			{From: "22:00", To: "07:00", Level: logger.LogLevelError},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	defer lg.Destroy()
+
+	lg.Info("before the range")
+	now = now.Add(time.Second)
+	lg.Info("within the range")
+	lg.Error("error within the range")
+	if level, _, _ := lg.GetLevel(""); level != logger.LogLevelError {
+		t.Errorf("unexpected level within the range [%v]", level)
+	}
+
+	// The range spans midnight
+	now = now.Add(8*time.Hour + 59*time.Minute + 59*time.Second)
+	lg.Debug(1, "still within the range")
+	now = now.Add(time.Second)
+	lg.Debug(1, "after the range")
+
+	var msgs []string
+	for _, entry := range adapter.Entries() {
+		msgs = append(msgs, entry.msg)
+	}
+	expected := []string{"before the range", "error within the range", "after the range"}
+	if strings.Join(msgs, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected messages [got: %v, expected: %v]", msgs, expected)
+	}
+
+	// Invalid times are rejected
+	_, err = logger.Create(logger.Options{
+		LevelSchedule: []logger.ScheduleEntry{
+			{From: "24:00", To: "07:00", Level: logger.LogLevelError},
+		},
+	})
+	if err == nil {
+		t.Errorf("invalid schedule time was accepted")
+	}
+}
+
+func TestMute(t *testing.T) {
+	now := time.Date(2021, 3, 14, 12, 0, 0, 0, time.UTC)
+	restore := logger.SetTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+
+	adapter := &testAdapter{}
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters: []logger.Adapter{adapter},
+		Level:    logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	defer lg.Destroy()
+
+	if err = lg.Mute("connection (reset|refused)", time.Minute); err != nil {
+		t.Fatalf("unable to mute. [%v]", err)
+	}
+	if err = lg.Mute("unbalanced (", time.Minute); err == nil {
+		t.Errorf("invalid pattern was accepted")
+	}
+	lg.Error("connection reset by peer")
+	lg.Info("request served")
+	lg.Warning("connection refused")
+
+	// The pattern is unmuted automatically once the duration elapses
+	now = now.Add(time.Minute)
+	lg.Error("connection reset again")
+
+	if err = lg.Mute("request", time.Hour); err != nil {
+		t.Fatalf("unable to mute. [%v]", err)
+	}
+	lg.Info("request dropped")
+	lg.Unmute("request")
+	lg.Info("request served again")
+
+	var msgs []string
+	for _, entry := range adapter.Entries() {
+		msgs = append(msgs, entry.msg)
+	}
+	expected := []string{"request served", "connection reset again", "request served again"}
+	if strings.Join(msgs, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected messages [got: %v, expected: %v]", msgs, expected)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	cardNumber := regexp.MustCompile(`\b\d{4}-\d{4}-\d{4}-\d{4}\b`)
+	adapter := &testAdapter{}
+	lg, dir := createTestFileLogger(t, "Transform", func(opts *logger.Options) {
+		opts.Adapters = []logger.Adapter{adapter}
+		opts.Transform = func(level logger.LogLevel, msg string) string {
+			return cardNumber.ReplaceAllString(msg, "****-****-****-****")
+		}
+	})
+	lg.Info("payment with card 4111-1111-1111-1111 accepted")
+	lg.Error(struct {
+		Message string `json:"message"`
+		Card    string `json:"card"`
+	}{
+		Message: "payment rejected",
+		Card:    "5500-0000-0000-0004",
+	})
+	lg.Destroy()
+
+	content := readTestLogFile(t, dir, "Transform")
+	for _, entry := range adapter.Entries() {
+		content += entry.msg + "\n"
+	}
+	if cardNumber.MatchString(content) || strings.Count(content, "****-****-****-****") != 4 {
+		t.Errorf("messages were not scrubbed in all targets [%v]", content)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		if strings.HasPrefix(line, "{") {
+			_ = parseTestJSONEntry(t, line)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	dropNotes := make(chan string, 4)
+	lg, _ := createTestFileLogger(t, "Subscribe", func(opts *logger.Options) {
+		opts.ErrorHandler = func(message string) {
+			dropNotes <- message
+		}
+	})
+	defer lg.Destroy()
+
+	events, unsubscribe := lg.Subscribe()
+	lg.Info("This is an information message sample")
+	lg.Named("auth").Error(JsonMessage{
+		Message: "This is an error message sample",
+	})
+	lg.Debug(2, "This message is discarded by the level")
+
+	ev := receiveTestEvent(t, events)
+	if ev.Level != logger.LogLevelInfo || ev.Raw || ev.Name != "" ||
+		!strings.HasSuffix(ev.Message, "This is an information message sample") {
+		t.Errorf("unexpected first event [%+v]", ev)
+	}
+	ev = receiveTestEvent(t, events)
+	if ev.Level != logger.LogLevelError || !ev.Raw || ev.Name != "auth" ||
+		parseTestJSONEntry(t, ev.Message)["message"] != "This is an error message sample" {
+		t.Errorf("unexpected second event [%+v]", ev)
+	}
+	select {
+	case ev = <-events:
+		t.Errorf("unexpected event [%+v]", ev)
+	default:
+	}
+
+	// After unsubscribing the channel is closed and no further messages are delivered
+	unsubscribe()
+	unsubscribe()
+	lg.Info("This message is not delivered")
+	if ev, ok := <-events; ok {
+		t.Errorf("unexpected event after unsubscribing [%+v]", ev)
+	}
+
+	// Slow subscribers lose messages instead of blocking the logger
+	slowEvents, unsubscribeSlow := lg.Subscribe()
+	defer unsubscribeSlow()
+	for i := 0; i < 300; i++ {
+		lg.Info("This is an information message sample")
+	}
+	if len(slowEvents) != cap(slowEvents) {
+		t.Errorf("unexpected amount of buffered events [%v]", len(slowEvents))
+	}
+	select {
+	case msg := <-dropNotes:
+		if !strings.Contains(msg, "not keeping up") {
+			t.Errorf("unexpected drop notification [%v]", msg)
+		}
+	default:
+		t.Errorf("dropped events were not notified")
+	}
+}
+
+func TestSubscribeDestroy(t *testing.T) {
+	lg, _ := createTestFileLogger(t, "SubscribeDestroy", nil)
+	events, unsubscribe := lg.Subscribe()
+	lg.Destroy()
+
+	// Destroying the logger closes the channel
+	if _, ok := <-events; ok {
+		t.Errorf("channel was not closed")
+	}
+	unsubscribe()
+}
+
+func TestWithLevel(t *testing.T) {
+	lg, dir := createTestFileLogger(t, "WithLevel", nil)
+	child := lg.WithLevel(logger.LogLevelWarning, 0)
+
+	lg.Debug(1, "parent debug")
+	child.Debug(1, "child debug")
+	child.Info("child info")
+	child.Warning("child warning")
+	child.Error(JsonMessage{
+		Message: "child error",
+	})
+
+	// Destroying the derived logger leaves the shared targets in place
+	child.Destroy()
+	child.Error("child error after destroy")
+	lg.Info("parent info")
+	lg.Destroy()
+
+	lines := strings.Split(strings.TrimSpace(readTestLogFile(t, dir, "WithLevel")), "\n")
+	expected := []string{
+		"[DEBUG]: parent debug",
+		"[WARNING]: child warning",
+		`"message":"child error"`,
+		"[INFO]: parent info",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected number of lines [got: %v, expected: %v]", len(lines), len(expected))
+	}
+	for idx, s := range expected {
+		if !strings.Contains(lines[idx], s) {
+			t.Errorf("unexpected line #%v [got: %v, expected: %v]", idx+1, lines[idx], s)
+		}
+	}
+}
+
 //------------------------------------------------------------------------------
 // Private methods
 
@@ -60,3 +989,25 @@ func printTestMessages(l *logger.Logger) {
 		Message: "This is a debug message sample at level 2 which should NOT be printed",
 	})
 }
+
+func receiveTestEvent(t *testing.T, events <-chan logger.Event) logger.Event {
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatalf("channel closed while waiting for event")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout while waiting for event")
+	}
+	return logger.Event{}
+}
+
+type countingStringer struct {
+	calls int
+}
+
+func (s *countingStringer) String() string {
+	s.calls += 1
+	return "formatted"
+}
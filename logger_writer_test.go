@@ -0,0 +1,51 @@
+package go_logger_test
+
+import (
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestLevelInferringWriter(t *testing.T) {
+	adapter := &testAdapter{}
+	lg := createTestAdapterLogger(t, adapter)
+
+	w := lg.LevelInferringWriter()
+	chunks := []string{
+		"ERROR: disk is fu", "ll\nWARN: low memory\r\n",
+		"INFO:started\n\nDEBUG: cache miss\nplain line\nwarning: deprecated option\n",
+		"pending line",
+	}
+	for _, chunk := range chunks {
+		n, err := w.Write([]byte(chunk))
+		if err != nil || n != len(chunk) {
+			t.Fatalf("unexpected write result [n: %v, err: %v]", n, err)
+		}
+	}
+	if len(adapter.Entries()) != 6 {
+		t.Fatalf("incomplete line was logged")
+	}
+	w.Flush()
+	lg.Destroy()
+
+	expected := []testAdapterEntry{
+		{level: logger.LogLevelError, msg: "disk is full"},
+		{level: logger.LogLevelWarning, msg: "low memory"},
+		{level: logger.LogLevelInfo, msg: "started"},
+		{level: logger.LogLevelDebug, msg: "cache miss"},
+		{level: logger.LogLevelInfo, msg: "plain line"},
+		{level: logger.LogLevelWarning, msg: "deprecated option"},
+		{level: logger.LogLevelInfo, msg: "pending line"},
+	}
+	entries := adapter.Entries()
+	if len(entries) != len(expected) {
+		t.Fatalf("unexpected number of entries [got: %v, expected: %v]", len(entries), len(expected))
+	}
+	for idx, e := range expected {
+		if entries[idx] != e {
+			t.Errorf("unexpected entry #%v [got: %+v, expected: %+v]", idx+1, entries[idx], e)
+		}
+	}
+}
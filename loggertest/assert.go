@@ -0,0 +1,73 @@
+package loggertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+// AssertLogged reports a test error if no message of the given level containing substr was captured. It returns
+// true if the assertion holds.
+func AssertLogged(t testing.TB, mem *Memory, level logger.LogLevel, substr string) bool {
+	t.Helper()
+
+	entries := mem.Entries()
+	for _, entry := range entries {
+		if entry.Level == level && strings.Contains(entry.Message, substr) {
+			return true
+		}
+	}
+	t.Errorf("no %v message containing %q was logged%v", levelName(level), substr, describeEntries(entries))
+	return false
+}
+
+// AssertNoErrors reports a test error if any error message was captured. It returns true if the assertion holds.
+func AssertNoErrors(t testing.TB, mem *Memory) bool {
+	t.Helper()
+
+	errors := make([]Entry, 0)
+	for _, entry := range mem.Entries() {
+		if entry.Level == logger.LogLevelError {
+			errors = append(errors, entry)
+		}
+	}
+	if len(errors) > 0 {
+		t.Errorf("%v error messages were logged%v", len(errors), describeEntries(errors))
+		return false
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func describeEntries(entries []Entry) string {
+	if len(entries) == 0 {
+		return " (no messages captured)"
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(":")
+	for _, entry := range entries {
+		sb.WriteString(fmt.Sprintf("\n\t[%v] %v", levelName(entry.Level), entry.Message))
+	}
+	return sb.String()
+}
+
+func levelName(level logger.LogLevel) string {
+	switch level {
+	case logger.LogLevelError:
+		return "error"
+	case logger.LogLevelWarning:
+		return "warning"
+	case logger.LogLevelInfo:
+		return "info"
+	case logger.LogLevelDebug:
+		return "debug"
+	}
+	return fmt.Sprintf("level %v", uint(level))
+}
@@ -0,0 +1,101 @@
+package loggertest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	logger "github.com/randlabs/go-logger/v2"
+	"github.com/randlabs/go-logger/v2/loggertest"
+)
+
+//------------------------------------------------------------------------------
+
+// recordingT captures the errors reported by the assertions instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+//------------------------------------------------------------------------------
+
+func TestAssertLogged(t *testing.T) {
+	mem := createTestLogger(t, func(lg *logger.Logger) {
+		lg.Warning("disk almost full")
+		lg.Info(struct {
+			Message string `json:"message"`
+		}{
+			Message: "request served",
+		})
+	})
+
+	// Passing assertions
+	rt := &recordingT{TB: t}
+	if !loggertest.AssertLogged(rt, mem, logger.LogLevelWarning, "almost full") ||
+		!loggertest.AssertLogged(rt, mem, logger.LogLevelInfo, `"message":"request served"`) {
+		t.Errorf("assertion unexpectedly failed [%v]", rt.errors)
+	}
+	if len(rt.errors) != 0 {
+		t.Errorf("unexpected errors reported [%v]", rt.errors)
+	}
+
+	// Failing assertions: wrong level and missing text
+	rt = &recordingT{TB: t}
+	if loggertest.AssertLogged(rt, mem, logger.LogLevelError, "almost full") ||
+		loggertest.AssertLogged(rt, mem, logger.LogLevelWarning, "disk failure") {
+		t.Errorf("assertion unexpectedly succeeded")
+	}
+	if len(rt.errors) != 2 || !strings.Contains(rt.errors[0], `no error message containing "almost full"`) ||
+		!strings.Contains(rt.errors[0], "[warning] disk almost full") {
+		t.Errorf("unexpected errors reported [%v]", rt.errors)
+	}
+}
+
+func TestAssertNoErrors(t *testing.T) {
+	mem := createTestLogger(t, func(lg *logger.Logger) {
+		lg.Warning("disk almost full")
+	})
+
+	// Passing assertion
+	rt := &recordingT{TB: t}
+	if !loggertest.AssertNoErrors(rt, mem) || len(rt.errors) != 0 {
+		t.Errorf("assertion unexpectedly failed [%v]", rt.errors)
+	}
+
+	// Failing assertion
+	mem = createTestLogger(t, func(lg *logger.Logger) {
+		lg.Error("disk failure")
+	})
+	rt = &recordingT{TB: t}
+	if loggertest.AssertNoErrors(rt, mem) {
+		t.Errorf("assertion unexpectedly succeeded")
+	}
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "1 error messages were logged") ||
+		!strings.Contains(rt.errors[0], "[error] disk failure") {
+		t.Errorf("unexpected errors reported [%v]", rt.errors)
+	}
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func createTestLogger(t *testing.T, fn func(lg *logger.Logger)) *loggertest.Memory {
+	mem := loggertest.NewMemory()
+	lg, err := logger.Create(logger.Options{
+		Console: logger.ConsoleOptions{
+			Disable: true,
+		},
+		Adapters: []logger.Adapter{mem},
+		Level:    logger.LogLevelInfo,
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize. [%v]", err)
+	}
+	fn(lg)
+	lg.Destroy()
+	return mem
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
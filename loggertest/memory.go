@@ -0,0 +1,75 @@
+// Package loggertest provides an in-memory log target and assertion helpers to test the logging behavior of
+// applications.
+package loggertest
+
+import (
+	"sync"
+	"time"
+
+	logger "github.com/randlabs/go-logger/v2"
+)
+
+//------------------------------------------------------------------------------
+
+// Entry is a message captured by the memory adapter.
+type Entry struct {
+	Level logger.LogLevel
+	Time  time.Time
+
+	// Message is the formatted message. If Raw is true, it is a JSON object.
+	Message string
+	Raw     bool
+}
+
+// Memory is a custom log target that keeps the received messages in memory. Add it to Options.Adapters.
+type Memory struct {
+	mtx     sync.Mutex
+	entries []Entry
+}
+
+//------------------------------------------------------------------------------
+
+// NewMemory creates a new in-memory log target.
+func NewMemory() *Memory {
+	return &Memory{
+		entries: make([]Entry, 0),
+	}
+}
+
+// Class returns the adapter class name used by SetLevel.
+func (m *Memory) Class() string {
+	return "memory"
+}
+
+// Log stores the message.
+func (m *Memory) Log(level logger.LogLevel, now time.Time, msg string, raw bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.entries = append(m.entries, Entry{
+		Level:   level,
+		Time:    now,
+		Message: msg,
+		Raw:     raw,
+	})
+}
+
+// Destroy does nothing. Captured messages remain available after the logger is destroyed.
+func (m *Memory) Destroy() {
+}
+
+// Entries returns a copy of the captured messages.
+func (m *Memory) Entries() []Entry {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return append([]Entry(nil), m.entries...)
+}
+
+// Reset discards the captured messages.
+func (m *Memory) Reset() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.entries = m.entries[:0]
+}
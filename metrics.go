@@ -0,0 +1,160 @@
+package go_logger
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//------------------------------------------------------------------------------
+
+// loggerMetrics contains the counters exposed by Logger.MetricsHandler. They survive Reconfigure.
+type loggerMetrics struct {
+	messages [5]uint64 // Keep first for atomic access alignment
+	mtx      sync.Mutex
+	errors   map[string]*uint64
+}
+
+//------------------------------------------------------------------------------
+
+// metricLevels contains the levels of the logger_messages_total metric in the order of the messages counters.
+var metricLevels = [5]LogLevel{LogLevelError, LogLevelWarning, LogLevelInfo, LogLevelDebug, logLevelAudit}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+//------------------------------------------------------------------------------
+
+// MetricsHandler returns a handler serving the logger counters in the Prometheus text exposition format, to scrape
+// the logging health along with the application metrics. It exposes:
+//
+//	logger_messages_total{level="..."}        Messages written to the targets, by level.
+//	logger_adapter_errors_total{class="..."}  Internal errors notified by the targets, by class.
+//	logger_syslog_queue_length                Messages waiting to be sent to the syslog server.
+//	logger_syslog_sent_total                  Messages sent to the syslog server.
+//	logger_syslog_dropped_total               Messages discarded by the syslog target.
+//
+// Syslog metrics are only present if a syslog target is configured. Errors are counted when they are notified, so
+// repeated errors of a target failing continuously are counted once per notification.
+func (lg *Logger) MetricsHandler() http.Handler {
+	if lg.parent != nil {
+		return lg.parent.MetricsHandler()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		b := lg.appendMetrics(make([]byte, 0, 1024))
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(b)
+	})
+}
+
+//------------------------------------------------------------------------------
+
+func newLoggerMetrics() *loggerMetrics {
+	return &loggerMetrics{
+		errors: make(map[string]*uint64),
+	}
+}
+
+// countMessage accounts a message written to the targets.
+func (m *loggerMetrics) countMessage(level LogLevel) {
+	idx := int(level) - 1
+	if level == logLevelAudit {
+		idx = 4
+	}
+	if idx >= 0 && idx < len(m.messages) {
+		atomic.AddUint64(&m.messages[idx], 1)
+	}
+}
+
+// errorCounter returns an error handler that accounts the errors notified by a target of the given class and then
+// calls the next one, if any.
+func (m *loggerMetrics) errorCounter(class string, next ErrorHandler) ErrorHandler {
+	// Lock access
+	m.mtx.Lock()
+	counter, ok := m.errors[class]
+	if !ok {
+		counter = new(uint64)
+		m.errors[class] = counter
+	}
+	m.mtx.Unlock()
+
+	return func(message string) {
+		atomic.AddUint64(counter, 1)
+		if next != nil {
+			next(message)
+		}
+	}
+}
+
+func (lg *Logger) appendMetrics(b []byte) []byte {
+	m := lg.metrics
+
+	b = appendMetricHeader(b, "logger_messages_total", "Messages written to the targets, by level.", "counter")
+	for idx, level := range metricLevels {
+		b = appendMetric(b, "logger_messages_total", "level", levelName(level), atomic.LoadUint64(&m.messages[idx]))
+	}
+
+	// Lock access
+	m.mtx.Lock()
+	classes := make([]string, 0, len(m.errors))
+	for class := range m.errors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	b = appendMetricHeader(b, "logger_adapter_errors_total", "Internal errors notified by the targets, by class.",
+		"counter")
+	for _, class := range classes {
+		b = appendMetric(b, "logger_adapter_errors_total", "class", class, atomic.LoadUint64(m.errors[class]))
+	}
+	m.mtx.Unlock()
+
+	if health, ok := lg.SysLogHealth(); ok {
+		b = appendMetricHeader(b, "logger_syslog_queue_length", "Messages waiting to be sent to the syslog server.",
+			"gauge")
+		b = appendMetric(b, "logger_syslog_queue_length", "", "", uint64(health.QueueLength))
+		b = appendMetricHeader(b, "logger_syslog_sent_total", "Messages sent to the syslog server.", "counter")
+		b = appendMetric(b, "logger_syslog_sent_total", "", "", health.Sent)
+		b = appendMetricHeader(b, "logger_syslog_dropped_total", "Messages discarded by the syslog target.",
+			"counter")
+		b = appendMetric(b, "logger_syslog_dropped_total", "", "", health.Dropped)
+	}
+
+	// Done
+	return b
+}
+
+func appendMetricHeader(b []byte, name string, help string, kind string) []byte {
+	b = append(b, "# HELP "...)
+	b = append(b, name...)
+	b = append(b, ' ')
+	b = append(b, help...)
+	b = append(b, "\n# TYPE "...)
+	b = append(b, name...)
+	b = append(b, ' ')
+	b = append(b, kind...)
+	return append(b, '\n')
+}
+
+// appendMetric appends a sample with an optional label.
+func appendMetric(b []byte, name string, label string, labelValue string, value uint64) []byte {
+	b = append(b, name...)
+	if len(label) > 0 {
+		b = append(b, '{')
+		b = append(b, label...)
+		b = append(b, `="`...)
+		b = append(b, metricLabelEscaper.Replace(labelValue)...)
+		b = append(b, `"}`...)
+	}
+	b = append(b, ' ')
+	b = strconv.AppendUint(b, value, 10)
+	return append(b, '\n')
+}
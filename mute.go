@@ -0,0 +1,81 @@
+package go_logger
+
+import (
+	"regexp"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+type muteRule struct {
+	re    *regexp.Regexp
+	until time.Time
+}
+
+//------------------------------------------------------------------------------
+
+// Mute drops the messages matching the given regular expression during the given duration, for example, to silence
+// a known noisy error during a deploy without changing levels. The expression is matched against the formatted
+// message, plain text or JSON, including its fields. Audit messages are never dropped. Muting the same pattern again
+// replaces its duration.
+func (lg *Logger) Mute(pattern string, d time.Duration) error {
+	if lg.parent != nil {
+		return lg.parent.Mute(pattern, d)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	// Lock access
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	now := lg.getTimestamp()
+	lg.removeExpiredMutes(now)
+	if lg.mutes == nil {
+		lg.mutes = make(map[string]muteRule)
+	}
+	lg.mutes[pattern] = muteRule{
+		re:    re,
+		until: now.Add(d),
+	}
+	return nil
+}
+
+// Unmute stops dropping the messages matching the given pattern before its duration elapses.
+func (lg *Logger) Unmute(pattern string) {
+	if lg.parent != nil {
+		lg.parent.Unmute(pattern)
+		return
+	}
+
+	// Lock access
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	delete(lg.mutes, pattern)
+	lg.removeExpiredMutes(lg.getTimestamp())
+}
+
+//------------------------------------------------------------------------------
+
+// isMuted returns true if the formatted message matches an active mute pattern. Must be called within a lock.
+func (lg *Logger) isMuted(msg string, now time.Time) bool {
+	for _, rule := range lg.mutes {
+		if now.Before(rule.until) && rule.re.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeExpiredMutes deletes the patterns whose duration elapsed. Must be called within an exclusive lock.
+func (lg *Logger) removeExpiredMutes(now time.Time) {
+	for pattern, rule := range lg.mutes {
+		if !now.Before(rule.until) {
+			delete(lg.mutes, pattern)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package go_logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	otlpDefaultMaxBatch = 512
+	otlpDefaultPath     = "/v1/logs"
+	otlpGRPCPath        = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+	otlpScopeName       = "github.com/randlabs/go-logger"
+
+	// Severity numbers defined by the OpenTelemetry logs data model
+	otlpSeverityDebug = 5
+	otlpSeverityInfo  = 9
+	otlpSeverityWarn  = 13
+	otlpSeverityError = 17
+)
+
+// OTLPProtocol is the transport used to export logs to an OpenTelemetry collector.
+type OTLPProtocol string
+
+const (
+	// OTLPProtocolGRPC exports using OTLP/gRPC with the protobuf encoding.
+	OTLPProtocolGRPC OTLPProtocol = "grpc"
+
+	// OTLPProtocolHTTPJSON exports using OTLP/HTTP with the JSON encoding.
+	OTLPProtocolHTTPJSON OTLPProtocol = "http/json"
+)
+
+// OTLPOptions specifies the OpenTelemetry collector settings to use when it is created.
+type OTLPOptions struct {
+	// Transport used to export logs. Defaults to OTLPProtocolGRPC.
+	Protocol OTLPProtocol `json:"protocol,omitempty"`
+
+	// Collector address, like collector:4317, or its URL. Defaults to localhost:4317 when using gRPC and to
+	// localhost:4318 when using HTTP. If no path is given when using HTTP, /v1/logs is used.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Extra headers, or gRPC metadata, to send with each request, like authentication tokens.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Use a plain text connection instead of TLS if the endpoint has no scheme. Plain text gRPC requires Go 1.24
+	// or later.
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Value of the service.name resource attribute. Defaults to the default application name.
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// Amount of queued messages that triggers sending a batch. Defaults to 512.
+	MaxBatch int `json:"maxBatch,omitempty"`
+
+	// Maximum time a message waits in the queue before being sent. Defaults to 5 seconds.
+	MaxLatency time.Duration `json:"maxLatency,omitempty"`
+
+	// Optional HTTP client to use. It must support HTTP/2 when using gRPC. Defaults to a client with a 30 seconds
+	// timeout.
+	HttpClient *http.Client `json:"-"`
+
+	// Set the initial logging level to use.
+	Level *LogLevel `json:"level,omitempty"`
+
+	// Set the initial logging level for debug output to use.
+	DebugLevel *uint `json:"debugLevel,omitempty"`
+}
+
+type otlpAdapter struct {
+	nextErrNotify int64 // Keep first for atomic access alignment
+	mtx           sync.Mutex
+	queue         []otlpLogRecord
+	sendMtx       sync.Mutex
+	batcher       *batcher
+	lastWasError  int32
+	useGRPC       bool
+	endpoint      string
+	headers       map[string]string
+	serviceName   string
+	maxBatch      int
+	client        *http.Client
+	globals       globalOptions
+}
+
+// The types below follow the JSON encoding of the OTLP protobuf messages.
+
+type otlpExportRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+func createOTLPAdapter(opts OTLPOptions, glbOpts globalOptions) (internalLogger, error) {
+	// Create OTLP adapter
+	lg := &otlpAdapter{
+		headers:     opts.Headers,
+		serviceName: opts.ServiceName,
+		maxBatch:    opts.MaxBatch,
+		client:      opts.HttpClient,
+		globals:     glbOpts,
+	}
+
+	// Set output level based on globals or overrides
+	if opts.Level != nil {
+		lg.globals.Level = *opts.Level
+		lg.globals.DebugLevel = 1
+	}
+	if opts.DebugLevel != nil {
+		lg.globals.DebugLevel = *opts.DebugLevel
+	}
+
+	switch opts.Protocol {
+	case "", OTLPProtocolGRPC:
+		lg.useGRPC = true
+	case OTLPProtocolHTTPJSON:
+	default:
+		return nil, errors.New("unsupported OTLP protocol")
+	}
+
+	// Set the endpoint
+	endpoint := opts.Endpoint
+	if len(endpoint) == 0 {
+		if lg.useGRPC {
+			endpoint = "localhost:4317"
+		} else {
+			endpoint = "localhost:4318"
+		}
+	}
+	if !strings.Contains(endpoint, "://") {
+		if opts.Insecure {
+			endpoint = "http://" + endpoint
+		} else {
+			endpoint = "https://" + endpoint
+		}
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || len(u.Host) == 0 {
+		return nil, errors.New("invalid OTLP endpoint")
+	}
+	if lg.useGRPC {
+		u.Path = strings.TrimSuffix(u.Path, "/") + otlpGRPCPath
+	} else if len(u.Path) == 0 || u.Path == "/" {
+		u.Path = otlpDefaultPath
+	}
+	lg.endpoint = u.String()
+
+	if len(lg.serviceName) == 0 {
+		lg.serviceName, err = getDefaultAppName()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if lg.maxBatch <= 0 {
+		lg.maxBatch = otlpDefaultMaxBatch
+	}
+	if lg.client == nil {
+		lg.client = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+		if lg.useGRPC {
+			lg.client.Transport, err = newOTLPGRPCTransport(u.Scheme == "http")
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Start background worker
+	lg.batcher = newBatcher(lg.maxBatch, opts.MaxLatency, func() {
+		_ = lg.sync()
+	})
+
+	// Done
+	return lg, nil
+}
+
+func (lg *otlpAdapter) class() string {
+	return "otlp"
+}
+
+func (lg *otlpAdapter) destroy() {
+	// Stop worker and wait until exited
+	lg.batcher.stop()
+
+	// Send queued messages
+	_ = lg.sync()
+}
+
+func (lg *otlpAdapter) setLevel(level LogLevel, debugLevel uint) {
+	lg.globals.Level = level
+	lg.globals.DebugLevel = debugLevel
+}
+
+func (lg *otlpAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *otlpAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *otlpAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+}
+
+// sync sends the queued messages synchronously and returns an error if some of them could not be delivered.
+func (lg *otlpAdapter) sync() error {
+	lg.sendMtx.Lock()
+	defer lg.sendMtx.Unlock()
+
+	var lastErr error
+	for {
+		batch := lg.dequeueBatch()
+		if len(batch) == 0 {
+			return lastErr
+		}
+		if err := lg.export(batch); err != nil {
+			lastErr = err
+		}
+	}
+}
+
+func (lg *otlpAdapter) logError(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelError {
+		lg.queueMessage(now, otlpSeverityError, "ERROR", msg, raw)
+	}
+}
+
+func (lg *otlpAdapter) logWarning(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelWarning {
+		lg.queueMessage(now, otlpSeverityWarn, "WARN", msg, raw)
+	}
+}
+
+func (lg *otlpAdapter) logInfo(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelInfo {
+		lg.queueMessage(now, otlpSeverityInfo, "INFO", msg, raw)
+	}
+}
+
+func (lg *otlpAdapter) logDebug(level uint, now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= level {
+		lg.queueMessage(now, otlpSeverityDebug, "DEBUG", msg, raw)
+	}
+}
+
+func (lg *otlpAdapter) logAudit(now time.Time, msg string, raw bool) {
+	lg.queueMessage(now, otlpSeverityInfo, "AUDIT", msg, raw)
+}
+
+func (lg *otlpAdapter) idle() bool {
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	return len(lg.queue) == 0 && !lg.batcher.busy()
+}
+
+func (lg *otlpAdapter) queueMessage(now time.Time, severity int, severityText string, msg string, raw bool) {
+	record := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(now.UnixNano(), 10),
+		SeverityNumber: severity,
+		SeverityText:   severityText,
+		Body:           otlpStringValue(msg),
+	}
+	if raw {
+		// The message field becomes the body and the rest of the members, the attributes
+		if fields, ok := parseJSONFields(msg); ok {
+			record.Body = otlpStringValue("")
+			for _, f := range fields {
+				switch f.key {
+				case "timestamp", "level":
+				case "message":
+					record.Body = otlpStringValue(f.value)
+				default:
+					record.Attributes = append(record.Attributes, otlpKeyValue{
+						Key:   f.key,
+						Value: otlpJSONValue(f),
+					})
+				}
+			}
+		}
+	}
+
+	lg.mtx.Lock()
+	lg.queue = append(lg.queue, record)
+	count := len(lg.queue)
+	lg.mtx.Unlock()
+
+	lg.batcher.queued(count)
+}
+
+// dequeueBatch removes from the queue up to a batch of records.
+func (lg *otlpAdapter) dequeueBatch() []otlpLogRecord {
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	count := len(lg.queue)
+	if count > lg.maxBatch {
+		count = lg.maxBatch
+	}
+	if count == 0 {
+		return nil
+	}
+
+	batch := make([]otlpLogRecord, count)
+	copy(batch, lg.queue)
+	lg.queue = append(lg.queue[:0], lg.queue[count:]...)
+	return batch
+}
+
+// export sends a batch of records. Must be called within the send lock.
+func (lg *otlpAdapter) export(batch []otlpLogRecord) error {
+	err := lg.exportOnce(batch)
+
+	// Handle error
+	if lg.globals.shouldNotifyError(err, &lg.lastWasError, &lg.nextErrNotify) && lg.globals.ErrorHandler != nil {
+		lg.globals.ErrorHandler(fmt.Sprintf("Unable to deliver notification to OTLP collector [%v]", err))
+	}
+	return err
+}
+
+func (lg *otlpAdapter) exportOnce(batch []otlpLogRecord) error {
+	req := otlpExportRequest{
+		ResourceLogs: []otlpResourceLogs{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{
+						{
+							Key:   "service.name",
+							Value: otlpStringValue(lg.serviceName),
+						},
+					},
+				},
+				ScopeLogs: []otlpScopeLogs{
+					{
+						Scope: otlpScope{
+							Name: otlpScopeName,
+						},
+						LogRecords: batch,
+					},
+				},
+			},
+		},
+	}
+	if lg.useGRPC {
+		return lg.exportGRPC(req)
+	}
+	return lg.exportHTTP(req)
+}
+
+func (lg *otlpAdapter) exportHTTP(exportReq otlpExportRequest) error {
+	body, err := json.Marshal(exportReq)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, lg.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range lg.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := lg.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 65536))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// exportGRPC calls the Export method of the OTLP logs service. The request is sent as a single, uncompressed,
+// length-prefixed message.
+func (lg *otlpAdapter) exportGRPC(exportReq otlpExportRequest) error {
+	msg := exportReq.appendProto(nil)
+	body := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+	body = append(body, msg...)
+
+	req, err := http.NewRequest(http.MethodPost, lg.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, value := range lg.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := lg.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	// The status is sent in the trailers, which are available once the body was read
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 65536))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if len(status) == 0 {
+		// Responses without a message carry the status in the headers
+		status = resp.Header.Get("Grpc-Status")
+		message = resp.Header.Get("Grpc-Message")
+	}
+	if status != "0" {
+		if len(status) == 0 {
+			return errors.New("missing gRPC status")
+		}
+		if msgText, err := url.PathUnescape(message); err == nil {
+			message = msgText
+		}
+		return fmt.Errorf("gRPC status %v [%v]", status, message)
+	}
+	return nil
+}
+
+func otlpStringValue(s string) otlpAnyValue {
+	return otlpAnyValue{
+		StringValue: &s,
+	}
+}
+
+// otlpJSONValue converts a JSON member into an attribute value. Objects and arrays are stored as compact JSON strings.
+func otlpJSONValue(f jsonField) otlpAnyValue {
+	switch {
+	case f.raw[0] == '"':
+		return otlpStringValue(f.value)
+	case f.raw == "true" || f.raw == "false":
+		b := f.raw == "true"
+		return otlpAnyValue{
+			BoolValue: &b,
+		}
+	case f.raw[0] == '{' || f.raw[0] == '[':
+		return otlpStringValue(f.raw)
+	}
+	if _, err := strconv.ParseInt(f.raw, 10, 64); err == nil {
+		return otlpAnyValue{
+			IntValue: &f.raw,
+		}
+	}
+	if d, err := strconv.ParseFloat(f.raw, 64); err == nil {
+		return otlpAnyValue{
+			DoubleValue: &d,
+		}
+	}
+	return otlpStringValue(f.raw)
+}
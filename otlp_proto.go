@@ -0,0 +1,116 @@
+package go_logger
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+//------------------------------------------------------------------------------
+
+// Protobuf wire types
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+)
+
+//------------------------------------------------------------------------------
+
+// appendProto appends the protobuf encoding of the request, an ExportLogsServiceRequest message.
+func (r otlpExportRequest) appendProto(b []byte) []byte {
+	for _, resourceLogs := range r.ResourceLogs {
+		b = appendProtoBytes(b, 1, resourceLogs.appendProto(nil))
+	}
+	return b
+}
+
+func (r otlpResourceLogs) appendProto(b []byte) []byte {
+	resource := make([]byte, 0, 64)
+	for _, attr := range r.Resource.Attributes {
+		resource = appendProtoBytes(resource, 1, attr.appendProto(nil))
+	}
+	b = appendProtoBytes(b, 1, resource)
+	for _, scopeLogs := range r.ScopeLogs {
+		b = appendProtoBytes(b, 2, scopeLogs.appendProto(nil))
+	}
+	return b
+}
+
+func (s otlpScopeLogs) appendProto(b []byte) []byte {
+	b = appendProtoBytes(b, 1, appendProtoString(nil, 1, s.Scope.Name))
+	for _, record := range s.LogRecords {
+		b = appendProtoBytes(b, 2, record.appendProto(nil))
+	}
+	return b
+}
+
+func (r otlpLogRecord) appendProto(b []byte) []byte {
+	ts, _ := strconv.ParseUint(r.TimeUnixNano, 10, 64)
+	b = appendProtoFixed64(b, 1, ts)
+	b = appendProtoVarint(b, 2, uint64(r.SeverityNumber))
+	b = appendProtoString(b, 3, r.SeverityText)
+	b = appendProtoBytes(b, 5, r.Body.appendProto(nil))
+	for _, attr := range r.Attributes {
+		b = appendProtoBytes(b, 6, attr.appendProto(nil))
+	}
+	return b
+}
+
+func (kv otlpKeyValue) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, kv.Key)
+	return appendProtoBytes(b, 2, kv.Value.appendProto(nil))
+}
+
+func (v otlpAnyValue) appendProto(b []byte) []byte {
+	switch {
+	case v.StringValue != nil:
+		return appendProtoString(b, 1, *v.StringValue)
+	case v.BoolValue != nil:
+		n := uint64(0)
+		if *v.BoolValue {
+			n = 1
+		}
+		return appendProtoVarint(b, 2, n)
+	case v.IntValue != nil:
+		n, _ := strconv.ParseInt(*v.IntValue, 10, 64)
+		return appendProtoVarint(b, 3, uint64(n))
+	case v.DoubleValue != nil:
+		return appendProtoFixed64(b, 4, math.Float64bits(*v.DoubleValue))
+	}
+	return b
+}
+
+func appendProtoTag(b []byte, num int, wireType int) []byte {
+	return appendUvarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+func appendProtoVarint(b []byte, num int, v uint64) []byte {
+	b = appendProtoTag(b, num, protoWireVarint)
+	return appendUvarint(b, v)
+}
+
+func appendProtoFixed64(b []byte, num int, v uint64) []byte {
+	b = appendProtoTag(b, num, protoWireFixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendProtoBytes(b []byte, num int, v []byte) []byte {
+	b = appendProtoTag(b, num, protoWireBytes)
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendProtoString(b []byte, num int, v string) []byte {
+	b = appendProtoTag(b, num, protoWireBytes)
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
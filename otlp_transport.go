@@ -0,0 +1,21 @@
+//go:build go1.24
+
+package go_logger
+
+import (
+	"net/http"
+)
+
+//------------------------------------------------------------------------------
+
+// newOTLPGRPCTransport returns a transport sending requests over HTTP/2, using a plain text connection if insecure
+// is true.
+func newOTLPGRPCTransport(insecure bool) (http.RoundTripper, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = true
+	if insecure {
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return t, nil
+}
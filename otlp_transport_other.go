@@ -0,0 +1,21 @@
+//go:build !go1.24
+
+package go_logger
+
+import (
+	"errors"
+	"net/http"
+)
+
+//------------------------------------------------------------------------------
+
+// newOTLPGRPCTransport returns a transport sending requests over HTTP/2. Plain text HTTP/2 is not supported by
+// the standard library before Go 1.24.
+func newOTLPGRPCTransport(insecure bool) (http.RoundTripper, error) {
+	if insecure {
+		return nil, errors.New("plain text OTLP gRPC export requires Go 1.24 or later")
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = true
+	return t, nil
+}
@@ -0,0 +1,44 @@
+package go_logger
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+//------------------------------------------------------------------------------
+
+// CapturePanics logs the value and stack trace of a panic at error level through all the configured targets.
+// It must be called using defer. Unless Options.SwallowPanics is set, the panic is raised again after logging.
+func (lg *Logger) CapturePanics() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if depth := lg.root().stackDepth; depth > 0 {
+		lg.Error(struct {
+			Message string       `json:"message"`
+			Stack   []stackFrame `json:"stack"`
+		}{
+			Message: fmt.Sprintf("panic: %v", r),
+			Stack:   captureStack(depth),
+		})
+	} else {
+		lg.Error(fmt.Sprintf("panic: %v\n\n%s", r, debug.Stack()))
+	}
+
+	if !lg.root().swallowPanics {
+		panic(r)
+	}
+}
+
+// Go runs the given function in a new goroutine and logs any panic raised by it.
+// NOTE: Only goroutines launched with this method are covered. Panics in other goroutines are still printed by the
+// Go runtime to the standard error output.
+func (lg *Logger) Go(fn func()) {
+	go func() {
+		defer lg.CapturePanics()
+
+		fn()
+	}()
+}
@@ -1,14 +1,69 @@
 package go_logger
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 //------------------------------------------------------------------------------
 
-func addPayloadToJSON(s string, now time.Time, level string) string {
-	payload := fmt.Sprintf(`"timestamp":"%v","level":"%v"`, now.Format("2006-01-02 15:04:05.000"), level)
+const (
+	maxErrorChainDepth = 16
+)
+
+//------------------------------------------------------------------------------
+
+// JSONLevelEncoder returns the value of the level field of JSON messages. Values consisting only of digits are
+// written as JSON numbers, any other value is written as a string.
+type JSONLevelEncoder func(level LogLevel) string
+
+// errorPayload is the message logged for errors.
+type errorPayload struct {
+	Error      string   `json:"error"`
+	ErrorChain []string `json:"error_chain"`
+}
+
+//------------------------------------------------------------------------------
+
+// LowercaseLevelEncoder encodes levels as lowercase words like `error` or `info`. This is the default.
+func LowercaseLevelEncoder(level LogLevel) string {
+	return levelName(level)
+}
+
+// UppercaseLevelEncoder encodes levels as uppercase words like `ERROR` or `INFO`.
+func UppercaseLevelEncoder(level LogLevel) string {
+	return strings.ToUpper(levelName(level))
+}
+
+// SeverityLevelEncoder encodes levels as numeric syslog severities like 3 for errors or 6 for information.
+func SeverityLevelEncoder(level LogLevel) string {
+	switch level {
+	case LogLevelError:
+		return strconv.Itoa(int(SeverityError))
+	case LogLevelWarning:
+		return strconv.Itoa(int(SeverityWarning))
+	case LogLevelInfo:
+		return strconv.Itoa(int(SeverityInformational))
+	case LogLevelDebug:
+		return strconv.Itoa(int(SeverityDebug))
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+func addPayloadToJSON(s string, now time.Time, tsFormat string, level string) string {
+	ts := `"` + now.Format(tsFormat) + `"`
+	if epoch, ok := epochTimestamp(now, tsFormat); ok {
+		ts = strconv.FormatInt(epoch, 10)
+	}
+	payload := fmt.Sprintf(`"timestamp":%v,"level":%v`, ts, level)
 
 	// Embed additional payload
 	sep := ""
@@ -19,3 +74,179 @@ func addPayloadToJSON(s string, now time.Time, level string) string {
 	// Return modified string
 	return s[:1] + payload + sep + s[1:]
 }
+
+// epochTimestamp returns the time as a number if the layout is one of the Unix epoch special values.
+func epochTimestamp(t time.Time, layout string) (int64, bool) {
+	switch layout {
+	case JSONTimestampUnix:
+		return t.Unix(), true
+	case JSONTimestampUnixMilli:
+		return t.UnixMilli(), true
+	case JSONTimestampUnixNano:
+		return t.UnixNano(), true
+	}
+	return 0, false
+}
+
+// formatTimestamp formats the time with the given layout or, if it is a Unix epoch special value, as a number.
+func formatTimestamp(t time.Time, layout string) string {
+	if epoch, ok := epochTimestamp(t, layout); ok {
+		return strconv.FormatInt(epoch, 10)
+	}
+	return t.Format(layout)
+}
+
+// encodeJSONLevel returns the JSON value of the level field. Audit messages are always tagged as `audit`.
+func encodeJSONLevel(level LogLevel, encoder JSONLevelEncoder) string {
+	if level == logLevelAudit || encoder == nil {
+		encoder = LowercaseLevelEncoder
+	}
+	s := encoder(level)
+	if len(s) > 0 && strings.Trim(s, "0123456789") == "" {
+		return s
+	}
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// truncateJSONPayload replaces a JSON object larger than maxBytes with one holding its first maxBytes bytes, as a
+// string, in the message field.
+func truncateJSONPayload(s string, maxBytes int) string {
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut -= 1
+	}
+	b, _ := json.Marshal(s[:cut] + fmt.Sprintf("...[truncated %v bytes]", len(s)-cut))
+	return `{"message":` + string(b) + `}`
+}
+
+// withoutJSONKey removes the top-level members with the given key from a JSON object.
+func withoutJSONKey(s string, key string) string {
+	quotedKey, _ := json.Marshal(key)
+	if !strings.Contains(s, string(quotedKey)) {
+		return s
+	}
+
+	dec := json.NewDecoder(strings.NewReader(s))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return s
+	}
+
+	sb := strings.Builder{}
+	sb.WriteByte('{')
+	for dec.More() {
+		var value json.RawMessage
+
+		tok, err := dec.Token()
+		if err != nil {
+			return s
+		}
+		k, _ := tok.(string)
+		if err = dec.Decode(&value); err != nil {
+			return s
+		}
+		if k == key {
+			continue
+		}
+
+		if sb.Len() > 1 {
+			sb.WriteByte(',')
+		}
+		b, _ := json.Marshal(k)
+		sb.Write(b)
+		sb.WriteByte(':')
+		sb.Write(value)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// truncateHeadTail keeps the first head and last tail bytes of s, joined by "...", if s is longer than head+tail bytes.
+// Cuts are moved to not split UTF-8 sequences.
+func truncateHeadTail(s string, head int, tail int) (string, bool) {
+	if head < 0 {
+		head = 0
+	}
+	if tail < 0 {
+		tail = 0
+	}
+	if len(s) <= head+tail {
+		return s, false
+	}
+
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head -= 1
+	}
+	tailStart := len(s) - tail
+	for tailStart < len(s) && !utf8.RuneStart(s[tailStart]) {
+		tailStart += 1
+	}
+	return s[:head] + "..." + s[tailStart:], true
+}
+
+// jsonField is a top-level member of a JSON object.
+type jsonField struct {
+	key string
+
+	// String values are unquoted, others are kept as compact JSON.
+	value string
+
+	// The value as compact JSON.
+	raw string
+}
+
+// errorChain returns the messages of the error and of the ones it wraps, found with errors.Unwrap, outermost first.
+// The depth is capped in case an error wraps itself.
+func errorChain(err error) []string {
+	chain := make([]string, 0, 4)
+	for err != nil && len(chain) < maxErrorChainDepth {
+		chain = append(chain, err.Error())
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
+
+// parseJSONFields returns the top-level members of a JSON object in order. Null values are skipped.
+func parseJSONFields(s string) ([]jsonField, bool) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	fields := make([]jsonField, 0)
+	for dec.More() {
+		var value json.RawMessage
+
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := tok.(string)
+		if err = dec.Decode(&value); err != nil {
+			return nil, false
+		}
+
+		f := jsonField{
+			key: key,
+		}
+		buf := bytes.Buffer{}
+		if json.Compact(&buf, value) == nil {
+			f.raw = buf.String()
+		} else {
+			f.raw = string(value)
+		}
+		switch {
+		case f.raw == "null":
+			continue
+		case f.raw[0] == '"':
+			_ = json.Unmarshal(value, &f.value)
+		default:
+			f.value = f.raw
+		}
+		fields = append(fields, f)
+	}
+	return fields, true
+}
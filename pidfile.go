@@ -0,0 +1,61 @@
+package go_logger
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// pidFiles counts the file targets using each PID file, so the file is kept while a logger is reconfigured.
+var pidFiles = struct {
+	mtx  sync.Mutex
+	refs map[string]int
+}{
+	refs: make(map[string]int),
+}
+
+//------------------------------------------------------------------------------
+
+// acquirePIDFile writes the id of the current process to the given file, unless it was already written by another
+// target. A file left by a crashed run is replaced but one belonging to a running process is an error.
+func acquirePIDFile(filename string) error {
+	pidFiles.mtx.Lock()
+	defer pidFiles.mtx.Unlock()
+
+	if pidFiles.refs[filename] > 0 {
+		pidFiles.refs[filename] += 1
+		return nil
+	}
+
+	pid := os.Getpid()
+	content, err := os.ReadFile(filename)
+	if err == nil {
+		other, parseErr := strconv.Atoi(strings.TrimSpace(string(content)))
+		if parseErr == nil && other != pid && processExists(other) {
+			return fmt.Errorf("PID file %v belongs to the running process %v", filename, other)
+		}
+	}
+
+	err = os.WriteFile(filename, []byte(strconv.Itoa(pid)+newLine), 0644)
+	if err != nil {
+		return err
+	}
+	pidFiles.refs[filename] = 1
+	return nil
+}
+
+// releasePIDFile deletes the given PID file once no target uses it.
+func releasePIDFile(filename string) {
+	pidFiles.mtx.Lock()
+	defer pidFiles.mtx.Unlock()
+
+	pidFiles.refs[filename] -= 1
+	if pidFiles.refs[filename] <= 0 {
+		delete(pidFiles.refs, filename)
+		_ = os.Remove(filename)
+	}
+}
@@ -0,0 +1,205 @@
+package go_logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// PipeOverflowPolicy establishes what to do when a pipe is full.
+type PipeOverflowPolicy uint
+
+const (
+	// PipeOverflowBlock waits until the reader consumes enough data to write the message.
+	PipeOverflowBlock PipeOverflowPolicy = 0
+
+	// PipeOverflowDrop discards the message if it cannot be written immediately.
+	PipeOverflowDrop PipeOverflowPolicy = 1
+)
+
+// PipeOptions specifies the pipe logger settings to use when it is created.
+type PipeOptions struct {
+	// The write end of the pipe. The caller retains ownership, so it is not closed when the logger is destroyed.
+	File *os.File `json:"-"`
+
+	// What to do when the pipe is full. Defaults to block.
+	OverflowPolicy PipeOverflowPolicy `json:"overflowPolicy,omitempty"`
+
+	// Set the initial logging level to use.
+	Level *LogLevel `json:"level,omitempty"`
+
+	// Set the initial logging level for debug output to use.
+	DebugLevel *uint `json:"debugLevel,omitempty"`
+}
+
+type pipeAdapter struct {
+	nextErrNotify  int64 // Keep first for atomic access alignment
+	mtx            sync.Mutex
+	fd             *os.File
+	overflowPolicy PipeOverflowPolicy
+	dropped        uint64
+	lastWasError   int32
+	globals        globalOptions
+}
+
+//------------------------------------------------------------------------------
+
+func createPipeAdapter(opts PipeOptions, glbOpts globalOptions) (internalLogger, error) {
+	if opts.File == nil {
+		return nil, errors.New("pipe file not specified")
+	}
+
+	// Create pipe adapter
+	lg := &pipeAdapter{
+		fd:             opts.File,
+		overflowPolicy: opts.OverflowPolicy,
+		globals:        glbOpts,
+	}
+
+	// Set output level based on globals or overrides
+	if opts.Level != nil {
+		lg.globals.Level = *opts.Level
+		lg.globals.DebugLevel = 1
+	}
+	if opts.DebugLevel != nil {
+		lg.globals.DebugLevel = *opts.DebugLevel
+	}
+
+	// Done
+	return lg, nil
+}
+
+func (lg *pipeAdapter) class() string {
+	return "pipe"
+}
+
+func (lg *pipeAdapter) destroy() {
+	lg.mtx.Lock()
+	lg.fd = nil
+	lg.mtx.Unlock()
+}
+
+func (lg *pipeAdapter) setLevel(level LogLevel, debugLevel uint) {
+	lg.globals.Level = level
+	lg.globals.DebugLevel = debugLevel
+}
+
+func (lg *pipeAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *pipeAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *pipeAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+}
+
+func (lg *pipeAdapter) sync() error {
+	// Pipes cannot be synced
+	return nil
+}
+
+func (lg *pipeAdapter) logError(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelError {
+		if !raw {
+			lg.write(now.Format(lg.globals.TimestampFormat) + " [ERROR]: " + msg + newLine)
+		} else {
+			lg.write(msg + newLine)
+		}
+	}
+}
+
+func (lg *pipeAdapter) logWarning(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelWarning {
+		if !raw {
+			lg.write(now.Format(lg.globals.TimestampFormat) + " [WARNING]: " + msg + newLine)
+		} else {
+			lg.write(msg + newLine)
+		}
+	}
+}
+
+func (lg *pipeAdapter) logInfo(now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelInfo {
+		if !raw {
+			lg.write(now.Format(lg.globals.TimestampFormat) + " [INFO]: " + msg + newLine)
+		} else {
+			lg.write(msg + newLine)
+		}
+	}
+}
+
+func (lg *pipeAdapter) logDebug(level uint, now time.Time, msg string, raw bool) {
+	if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= level {
+		if !raw {
+			lg.write(now.Format(lg.globals.TimestampFormat) + " [DEBUG]: " + msg + newLine)
+		} else {
+			lg.write(msg + newLine)
+		}
+	}
+}
+
+func (lg *pipeAdapter) logAudit(now time.Time, msg string, raw bool) {
+	// Audit messages are never dropped
+	if !raw {
+		lg.writeWithPolicy(now.Format(lg.globals.TimestampFormat)+" [AUDIT]: "+msg+newLine, PipeOverflowBlock)
+	} else {
+		lg.writeWithPolicy(msg+newLine, PipeOverflowBlock)
+	}
+}
+
+func (lg *pipeAdapter) write(line string) {
+	lg.writeWithPolicy(line, lg.overflowPolicy)
+}
+
+func (lg *pipeAdapter) writeWithPolicy(line string, overflowPolicy PipeOverflowPolicy) {
+	var err error
+
+	// Lock access
+	lg.mtx.Lock()
+
+	if lg.fd != nil {
+		if overflowPolicy == PipeOverflowDrop {
+			err = lg.writeNonBlocking(line)
+		} else {
+			_, err = lg.fd.WriteString(line)
+		}
+	}
+
+	// Unlock access
+	lg.mtx.Unlock()
+
+	// Handle error
+	lg.handleLoggingError(err)
+}
+
+func (lg *pipeAdapter) writeNonBlocking(line string) error {
+	n, err := tryWritePipe(lg.fd, line)
+	if err != nil && isPipeFullError(err) {
+		if n == 0 {
+			// Nothing was written so we can safely discard the message
+			atomic.AddUint64(&lg.dropped, 1)
+			return errors.New("pipe is full")
+		}
+
+		// Partially written, complete the message to avoid corrupting the stream
+		_, err = lg.fd.WriteString(line[n:])
+	}
+
+	// Done
+	return err
+}
+
+func (lg *pipeAdapter) handleLoggingError(err error) {
+	// Handle error
+	if lg.globals.shouldNotifyError(err, &lg.lastWasError, &lg.nextErrNotify) && lg.globals.ErrorHandler != nil {
+		lg.globals.ErrorHandler(fmt.Sprintf("Unable to write notification to pipe [%v]", err))
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !windows && !plan9
+
+package go_logger
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+//------------------------------------------------------------------------------
+
+// tryWritePipe writes to the pipe without waiting for it to become writable. If the pipe is full, it returns
+// syscall.EAGAIN.
+func tryWritePipe(f *os.File, s string) (int, error) {
+	var n int
+	var writeErr error
+
+	rawConn, err := f.SyscallConn()
+	if err != nil {
+		return f.WriteString(s)
+	}
+
+	err = rawConn.Write(func(fd uintptr) bool {
+		n, writeErr = syscall.Write(int(fd), []byte(s))
+		return true // Do not wait for the pipe to become writable
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if n < 0 {
+		n = 0
+	}
+	return n, err
+}
+
+// isPipeFullError returns true if the error returned by tryWritePipe indicates the pipe is full.
+func isPipeFullError(err error) bool {
+	return errors.Is(err, syscall.EAGAIN)
+}
@@ -0,0 +1,19 @@
+package go_logger
+
+import (
+	"os"
+)
+
+//------------------------------------------------------------------------------
+
+// tryWritePipe writes to the pipe. Anonymous pipes on Windows do not support non-blocking writes so it waits
+// until the pipe becomes writable.
+func tryWritePipe(f *os.File, s string) (int, error) {
+	return f.WriteString(s)
+}
+
+// isPipeFullError returns true if the error returned by tryWritePipe indicates the pipe is full. Writes wait for
+// the pipe to become writable so it never is.
+func isPipeFullError(_ error) bool {
+	return false
+}
@@ -0,0 +1,36 @@
+package go_logger
+
+import (
+	"fmt"
+)
+
+//------------------------------------------------------------------------------
+
+// Errorf emits an error message formatted like fmt.Sprintf does. The output is always plain text.
+func (lg *Logger) Errorf(format string, args ...interface{}) {
+	lg.emit(LogLevelError, 0, formattedMessage(format, args))
+}
+
+// Warningf emits a warning message formatted like fmt.Sprintf does. See Errorf.
+func (lg *Logger) Warningf(format string, args ...interface{}) {
+	lg.emit(LogLevelWarning, 0, formattedMessage(format, args))
+}
+
+// Infof emits an information message formatted like fmt.Sprintf does. See Errorf.
+func (lg *Logger) Infof(format string, args ...interface{}) {
+	lg.emit(LogLevelInfo, 0, formattedMessage(format, args))
+}
+
+// Debugf emits a debug message formatted like fmt.Sprintf does. See Errorf.
+func (lg *Logger) Debugf(level uint, format string, args ...interface{}) {
+	lg.Debug(level, formattedMessage(format, args))
+}
+
+//------------------------------------------------------------------------------
+
+// formattedMessage returns a deferred message so the arguments are only formatted if the message is output.
+func formattedMessage(format string, args []interface{}) func() interface{} {
+	return func() interface{} {
+		return fmt.Sprintf(format, args...)
+	}
+}
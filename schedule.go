@@ -0,0 +1,175 @@
+package go_logger
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// ScheduleEntry sets the logging level of all the targets during a daily time range, like "22:00" to "07:00".
+type ScheduleEntry struct {
+	// Start of the range, in 24-hour HH:MM format, inclusive.
+	From string `json:"from"`
+
+	// End of the range, in 24-hour HH:MM format, exclusive. Ranges ending before they start span midnight and
+	// ranges ending when they start span the whole day.
+	To string `json:"to"`
+
+	// Logging level to use within the range.
+	Level LogLevel `json:"level"`
+
+	// Logging level for debug output to use within the range.
+	DebugLevel uint `json:"debugLevel,omitempty"`
+}
+
+type levelScheduleEntry struct {
+	from       int // Minutes since midnight
+	to         int
+	level      LogLevel
+	debugLevel uint
+}
+
+type savedLevel struct {
+	level      LogLevel
+	debugLevel uint
+}
+
+//------------------------------------------------------------------------------
+
+func parseLevelSchedule(entries []ScheduleEntry) ([]levelScheduleEntry, error) {
+	schedule := make([]levelScheduleEntry, 0, len(entries))
+	for _, entry := range entries {
+		from, err := parseTimeOfDay(entry.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseTimeOfDay(entry.To)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, levelScheduleEntry{
+			from:       from,
+			to:         to,
+			level:      entry.Level,
+			debugLevel: entry.DebugLevel,
+		})
+	}
+	return schedule, nil
+}
+
+// parseTimeOfDay converts an HH:MM time into minutes since midnight.
+func parseTimeOfDay(s string) (int, error) {
+	if len(s) != 5 || s[2] != ':' || !isDigit(s[0]) || !isDigit(s[1]) || !isDigit(s[3]) || !isDigit(s[4]) {
+		return 0, fmt.Errorf("invalid schedule time [%v]", s)
+	}
+	hour := int(s[0]-'0')*10 + int(s[1]-'0')
+	minute := int(s[3]-'0')*10 + int(s[4]-'0')
+	if hour > 23 || minute > 59 {
+		return 0, fmt.Errorf("invalid schedule time [%v]", s)
+	}
+	return hour*60 + minute, nil
+}
+
+func (e *levelScheduleEntry) contains(minute int) bool {
+	if e.from < e.to {
+		return minute >= e.from && minute < e.to
+	}
+	if e.from > e.to {
+		return minute >= e.from || minute < e.to
+	}
+	return true
+}
+
+// setLevelSchedule replaces the schedule. Must be called within an exclusive lock or during creation.
+func (lg *Logger) setLevelSchedule(schedule []levelScheduleEntry) {
+	lg.schedule = schedule
+	lg.scheduleActive = -1
+	lg.scheduleSaved = nil
+	if len(schedule) > 0 {
+		// Evaluate it on the next message
+		atomic.StoreInt64(&lg.scheduleUntil, 0)
+	} else {
+		atomic.StoreInt64(&lg.scheduleUntil, math.MaxInt64)
+	}
+}
+
+// checkLevelSchedule applies the level of the schedule entry the current time is in, if it changed. Only the time of
+// the next boundary is checked for most messages. Must be called without holding the lock.
+func (lg *Logger) checkLevelSchedule() {
+	until := atomic.LoadInt64(&lg.scheduleUntil)
+	if until == math.MaxInt64 {
+		return // No schedule
+	}
+	now := lg.getTimestamp()
+	if now.UnixNano() < until {
+		return
+	}
+
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	// Another goroutine may have applied it while waiting for the lock
+	if lg.destroyed || len(lg.schedule) == 0 || now.UnixNano() < atomic.LoadInt64(&lg.scheduleUntil) {
+		return
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	active := -1
+	for idx := range lg.schedule {
+		if lg.schedule[idx].contains(minute) {
+			active = idx
+			break
+		}
+	}
+
+	if active != lg.scheduleActive {
+		if active >= 0 {
+			// Keep the levels in use before entering the first range to restore them later
+			if lg.scheduleSaved == nil {
+				lg.scheduleSaved = make([]savedLevel, len(lg.adapters))
+				for idx, adapter := range lg.adapters {
+					lg.scheduleSaved[idx].level, lg.scheduleSaved[idx].debugLevel = adapter.getLevel()
+				}
+			}
+			for _, adapter := range lg.adapters {
+				adapter.setLevel(lg.schedule[active].level, lg.schedule[active].debugLevel)
+			}
+		} else {
+			for idx, adapter := range lg.adapters {
+				if idx < len(lg.scheduleSaved) {
+					adapter.setLevel(lg.scheduleSaved[idx].level, lg.scheduleSaved[idx].debugLevel)
+				}
+			}
+			lg.scheduleSaved = nil
+		}
+		lg.scheduleActive = active
+	}
+
+	atomic.StoreInt64(&lg.scheduleUntil, lg.nextScheduleBoundary(now).UnixNano())
+}
+
+// nextScheduleBoundary returns the time the next range starts or ends. Must be called within a lock.
+func (lg *Logger) nextScheduleBoundary(now time.Time) time.Time {
+	var next time.Time
+
+	year, month, day := now.Date()
+	for _, entry := range lg.schedule {
+		for _, minute := range []int{entry.from, entry.to} {
+			t := time.Date(year, month, day, minute/60, minute%60, 0, 0, now.Location())
+			if !t.After(now) {
+				t = time.Date(year, month, day+1, minute/60, minute%60, 0, 0, now.Location())
+			}
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+	}
+	return next
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,44 @@
+package go_logger
+
+import (
+	"runtime"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+type stackFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+//------------------------------------------------------------------------------
+
+// captureStack returns up to maxDepth frames of the call stack of the current goroutine. The frames at the top that
+// belong to the Go runtime or to this module, like the panic machinery or CapturePanics itself, are skipped.
+func captureStack(maxDepth int) []stackFrame {
+	pcs := make([]uintptr, maxDepth+32)
+
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]stackFrame, 0, maxDepth)
+	for len(stack) < maxDepth {
+		frame, more := frames.Next()
+		if len(stack) > 0 || !(isModuleFunction(frame.Function) || isRuntimeFunction(frame.Function)) {
+			stack = append(stack, stackFrame{
+				Func: frame.Function,
+				File: frame.File,
+				Line: frame.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+func isRuntimeFunction(name string) bool {
+	return strings.HasPrefix(name, "runtime.")
+}
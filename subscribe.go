@@ -0,0 +1,144 @@
+package go_logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	subscriberBufferSize = 256
+
+	subscriberDropNotifyInterval = time.Minute
+)
+
+//------------------------------------------------------------------------------
+
+// Event is a message delivered to the channel returned by Logger.Subscribe.
+type Event struct {
+	// Level of the message. Audit messages are delivered as information messages, like to custom adapters.
+	Level LogLevel
+
+	// Debug level of the message. Zero if it is not a debug message.
+	DebugLevel uint
+
+	Time time.Time
+
+	// Message is the formatted message. If Raw is true, it is a JSON object.
+	Message string
+	Raw     bool
+
+	// Name of the logger returned by Logger.Named that emitted the message, if any.
+	Name string
+}
+
+type subscriber struct {
+	dropped  uint64 // Keep first for atomic access alignment
+	nextNote int64
+	ch       chan Event
+}
+
+//------------------------------------------------------------------------------
+
+// Subscribe returns a channel receiving the messages written to at least one target, for example, to stream them
+// to a live log viewer, and a function to stop receiving them and close the channel. Messages are never waited for:
+// if the subscriber does not keep up, they are dropped and the error handler is notified periodically. The channel
+// is also closed when the logger is destroyed.
+func (lg *Logger) Subscribe() (<-chan Event, func()) {
+	if lg.parent != nil {
+		return lg.parent.Subscribe()
+	}
+
+	sub := &subscriber{
+		ch: make(chan Event, subscriberBufferSize),
+	}
+
+	// Lock access
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	if lg.destroyed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+
+	// Replace the list so messages being dispatched keep using the previous one
+	subscribers := make([]*subscriber, 0, len(lg.subscribers)+1)
+	lg.subscribers = append(append(subscribers, lg.subscribers...), sub)
+
+	once := sync.Once{}
+	return sub.ch, func() {
+		once.Do(func() {
+			lg.unsubscribe(sub)
+		})
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (lg *Logger) unsubscribe(sub *subscriber) {
+	// Lock access
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	subscribers := make([]*subscriber, 0, len(lg.subscribers))
+	for _, s := range lg.subscribers {
+		if s != sub {
+			subscribers = append(subscribers, s)
+		}
+	}
+	if len(subscribers) == len(lg.subscribers) {
+		return // Already closed by Destroy
+	}
+	lg.subscribers = subscribers
+
+	// No message is being published while the lock is held
+	close(sub.ch)
+}
+
+// publish delivers the message to the subscribers without waiting. Must be called within a lock.
+func (lg *Logger) publish(level LogLevel, debugLevel uint, now time.Time, msg string, raw bool, name string) {
+	if level == logLevelAudit {
+		level = LogLevelInfo
+	}
+	ev := Event{
+		Level:      level,
+		DebugLevel: debugLevel,
+		Time:       now,
+		Message:    msg,
+		Raw:        raw,
+		Name:       name,
+	}
+	for _, sub := range lg.subscribers {
+		select {
+		case sub.ch <- ev:
+		default:
+			lg.notifySubscriberDrop(sub, atomic.AddUint64(&sub.dropped, 1))
+		}
+	}
+}
+
+func (lg *Logger) notifySubscriberDrop(sub *subscriber, dropped uint64) {
+	if lg.errorHandler == nil {
+		return
+	}
+
+	// Notify at most once per interval
+	now := time.Now().UnixNano()
+	nextNote := atomic.LoadInt64(&sub.nextNote)
+	if now >= nextNote &&
+		atomic.CompareAndSwapInt64(&sub.nextNote, nextNote, now+int64(subscriberDropNotifyInterval)) {
+		lg.errorHandler(fmt.Sprintf("Subscriber is not keeping up. %v messages were dropped so far", dropped))
+	}
+}
+
+// closeSubscribers closes the channels of all the subscribers. Must be called within a lock.
+func (lg *Logger) closeSubscribers() {
+	for _, sub := range lg.subscribers {
+		close(sub.ch)
+	}
+	lg.subscribers = nil
+}
@@ -2,8 +2,12 @@ package go_logger
 
 import (
 	"container/list"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
@@ -12,28 +16,70 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 //------------------------------------------------------------------------------
 
+// Severity is a syslog message severity as defined in RFC 5424.
+type Severity uint8
+
 const (
-	severityError         = 3
-	severityWarning       = 4
-	severityInformational = 6
-	severityDebug         = 7
+	SeverityEmergency     Severity = 0
+	SeverityAlert         Severity = 1
+	SeverityCritical      Severity = 2
+	SeverityError         Severity = 3
+	SeverityWarning       Severity = 4
+	SeverityNotice        Severity = 5
+	SeverityInformational Severity = 6
+	SeverityDebug         Severity = 7
+)
+
+// Facility is a syslog message facility as defined in RFC 5424.
+type Facility uint8
 
-	facilityUser = 1
+const (
+	FacilityKernel      Facility = 0
+	FacilityUser        Facility = 1
+	FacilityMail        Facility = 2
+	FacilityDaemon      Facility = 3
+	FacilityAuth        Facility = 4
+	FacilitySysLog      Facility = 5
+	FacilityLinePrinter Facility = 6
+	FacilityNews        Facility = 7
+	FacilityUUCP        Facility = 8
+	FacilityCron        Facility = 9
+	FacilityAuthPriv    Facility = 10
+	FacilityFTP         Facility = 11
+	FacilityNTP         Facility = 12
+	FacilityLogAudit    Facility = 13
+	FacilityLogAlert    Facility = 14
+	FacilityClock       Facility = 15
+	FacilityLocal0      Facility = 16
+	FacilityLocal1      Facility = 17
+	FacilityLocal2      Facility = 18
+	FacilityLocal3      Facility = 19
+	FacilityLocal4      Facility = 20
+	FacilityLocal5      Facility = 21
+	FacilityLocal6      Facility = 22
+	FacilityLocal7      Facility = 23
+)
 
+//------------------------------------------------------------------------------
+
+const (
 	defaultMaxMessageQueueSize = 1024
 
 	flushTimeout = 5 * time.Second
+
+	datagramContinuationMarker = "..."
 )
 
 //------------------------------------------------------------------------------
 
 // SysLogOptions specifies the syslog settings to use when it is created.
 type SysLogOptions struct {
-	// Application name to use. Defaults to the binary name.
+	// Application name to use. Defaults to the default application name.
 	AppName string `json:"appName,omitempty"`
 
 	// Syslog server host name.
@@ -48,12 +94,52 @@ type SysLogOptions struct {
 	// Uses a secure connection. Implies TCP.
 	UseTls bool `json:"useTls,omitempty"`
 
+	// Facility to use in the messages. Defaults to FacilityUser.
+	Facility *Facility `json:"facility,omitempty"`
+
 	// Send messages in the new RFC 5424 format instead of the original RFC 3164 specification.
 	UseRFC5424 bool `json:"useRFC5424,omitempty"`
 
 	// Set the maximum amount of messages to keep in memory if connection to the server is lost.
 	MaxMessageQueueSize uint `json:"queueSize,omitempty"`
 
+	// Keep queuing messages while the target is disabled with Logger.SetEnabled, instead of dropping them, and send
+	// them once it is enabled again. The queue is bounded by MaxMessageQueueSize.
+	QueueWhileDisabled bool `json:"queueWhileDisabled,omitempty"`
+
+	// Set the maximum size of a UDP datagram, including the syslog header. Zero means no limit.
+	MaxDatagramSize uint `json:"maxDatagramSize,omitempty"`
+
+	// Split messages exceeding MaxDatagramSize into several datagrams instead of just notifying the error.
+	SplitDatagrams bool `json:"splitDatagrams,omitempty"`
+
+	// Periodically read again the client host name. Zero means the host name is read only once.
+	RefreshHostname time.Duration `json:"refreshHostname,omitempty"`
+
+	// Optional function to get the client host name instead of os.Hostname. If RefreshHostname is zero, it is called
+	// for every message.
+	HostnameFunc func() (string, error) `json:"-"`
+
+	// Name of a field of JSON messages holding the severity to send instead of the one matching the logging level,
+	// useful when forwarding events from other systems. It can be a number, from 0 to 7, or a name like "err" or
+	// "warning". Messages without the field or with an invalid value use the one matching the logging level.
+	SeverityFromField string `json:"severityFromField,omitempty"`
+
+	// Optionally send messages in the Common Event Format used by SIEM systems like ArcSight.
+	CEF *CEFOptions `json:"cef,omitempty"`
+
+	// Optional function to create the connection to the server instead of dialing it, for custom transports or
+	// tests. It is called again to reconnect after a failure. Messages are sent separated by new lines, like over
+	// TCP. If set, Host, Port, UseTcp, UseTls and the TLS settings are ignored.
+	ConnFactory func() (net.Conn, error) `json:"-"`
+
+	// Facility to use in the messages of the loggers returned by Logger.Named, by name, like FacilityLocal1 for
+	// "auth". Messages of other loggers use Facility.
+	FacilityByName map[string]Facility `json:"facilityByName,omitempty"`
+
+	// Optional callback to call instead of Options.ErrorHandler if an internal error of this target must be notified.
+	ErrorHandler ErrorHandler `json:"-"`
+
 	// Set the initial logging level to use.
 	Level *LogLevel `json:"level,omitempty"`
 
@@ -62,24 +148,122 @@ type SysLogOptions struct {
 
 	// TLSConfig optionally provides a TLS configuration for use.
 	TlsConfig *tls.Config
+
+	// Use the system root CAs to verify the server certificate if TlsConfig does not provide a RootCAs pool.
+	// Defaults to true.
+	UseSystemRootCAs *bool `json:"useSystemRootCAs,omitempty"`
+
+	// Optional PEM file containing additional root CAs to verify the server certificate. They are added on top of
+	// the system ones unless UseSystemRootCAs is false. Ignored if TlsConfig provides a RootCAs pool.
+	CAFile string `json:"caFile,omitempty"`
+
+	// Optional cache of TLS sessions to resume them when reconnecting instead of doing a full handshake. It can be
+	// shared by several loggers sending messages to the same server.
+	TLSSessionCache tls.ClientSessionCache `json:"-"`
+
+	// Interval between TCP keep-alive probes. Zero uses the Go default and a negative value disables them.
+	KeepAlive time.Duration `json:"keepAlive,omitempty"`
+
+	// Reconnect before sending a message if the TCP connection was idle for at least this time, because firewalls
+	// and NAT devices may have silently dropped it and the message would be lost. Zero disables it.
+	IdleReconnect time.Duration `json:"idleReconnect,omitempty"`
+
+	// Optional file where messages that cannot be delivered, or are discarded because the queue is full, are
+	// appended.
+	FallbackFile string `json:"fallbackFile,omitempty"`
+
+	// Maximum time Destroy waits for the messages being sent and the queued ones to be delivered. Once elapsed, the
+	// connection is closed, even if a write is blocked by a server not reading, connecting or the TLS handshake in
+	// progress is canceled, and the undelivered messages are handled like failed ones. Connections being created by
+	// ConnFactory cannot be canceled. Defaults to 5 seconds.
+	StopTimeout time.Duration `json:"stopTimeout,omitempty"`
+
+	// Optional directory where messages that cannot be delivered, or are discarded because the queue is full, are
+	// persisted. They are sent again once the server is reachable, even after the application is restarted.
+	SpoolDir string `json:"spoolDir,omitempty"`
+
+	// Maximum size of the spool file. Messages are dropped, or written to FallbackFile, once it is full. Defaults
+	// to 16 MiB.
+	SpoolMaxBytes int64 `json:"spoolMaxBytes,omitempty"`
+}
+
+// SysLogConnectionState indicates the state of the connection to the syslog server.
+type SysLogConnectionState int
+
+const (
+	SysLogDisconnected SysLogConnectionState = 0
+	SysLogConnected    SysLogConnectionState = 1
+	SysLogReconnecting SysLogConnectionState = 2
+)
+
+// SysLogHealth contains the delivery statistics of the syslog target.
+type SysLogHealth struct {
+	// State of the connection. SysLogReconnecting means the last delivery failed.
+	State SysLogConnectionState
+
+	// Time of the last delivery error. Zero if no error happened.
+	LastErrorTime time.Time
+
+	// Amount of messages waiting to be sent.
+	QueueLength int
+
+	// Amount of messages successfully sent.
+	Sent uint64
+
+	// Amount of messages discarded because of delivery errors or a full queue.
+	Dropped uint64
 }
 
 type syslogAdapter struct {
+	sentCount     uint64 // Keep 64-bit counters first for atomic access alignment
+	droppedCount  uint64
+	lastErrorTime int64
+	nextErrNotify int64
 	conn          net.Conn
+	connected     int32
+	connMtx       sync.Mutex
 	lastWasError  int32
 	appName       string
 	serverAddress string
 	useTcp        bool
 	tlsConfig     *tls.Config
+	dialer        *net.Dialer
+	idleReconnect time.Duration
+	lastWrite     time.Time
+	connFactory   func() (net.Conn, error)
 	useRFC5424    bool
-	hostname      string
+	cef           *CEFOptions
+	severityField string
+	facility      Facility
+	facilityNames map[string]Facility
+	hostname      atomic.Value
+	hostnameFunc  func() (string, error)
+	refreshHost   time.Duration
+	userHostFunc  bool
+	nextHostRead  int64
 	pid           int
 	mtx           sync.Mutex
 	queue         *list.List
 	notEmptyCond  *sync.Cond
 	maxQueueSize  uint
+	queueDisabled bool
+	maxDgramSize  uint
+	splitDgrams   bool
 	shutdown      int32
+	sending       int32
 	workerDoneCh  chan struct{}
+	stopTimeout   time.Duration
+	abortMtx      sync.Mutex
+	liveConn      net.Conn
+	aborted       bool
+	abortCtx      context.Context
+	abortCancel   context.CancelFunc
+	fallbackFile  string
+	fallbackMtx   sync.Mutex
+	fallbackFd    *os.File
+	fallbackErr   bool
+	spool         *syslogSpool
+	spoolErr      int32
 	globals       globalOptions
 }
 
@@ -89,17 +273,11 @@ func createSysLogAdapter(opts SysLogOptions, glbOpts globalOptions) (internalLog
 	if len(opts.AppName) == 0 {
 		var err error
 
-		// If no application name was given, use the base name of the executable.
-		opts.AppName, err = os.Executable()
+		// If no application name was given, use the default one.
+		opts.AppName, err = getDefaultAppName()
 		if err != nil {
 			return nil, err
 		}
-		opts.AppName = filepath.Base(opts.AppName)
-
-		extLen := len(filepath.Ext(opts.AppName))
-		if len(opts.AppName) > extLen {
-			opts.AppName = opts.AppName[:(len(opts.AppName) - extLen)]
-		}
 	}
 
 	// Create Syslog adapter
@@ -107,14 +285,31 @@ func createSysLogAdapter(opts SysLogOptions, glbOpts globalOptions) (internalLog
 		appName:      opts.AppName,
 		useTcp:       opts.UseTcp,
 		useRFC5424:   opts.UseRFC5424,
+		facility:     FacilityUser,
+		hostnameFunc: opts.HostnameFunc,
+		connFactory:  opts.ConnFactory,
+		refreshHost:  opts.RefreshHostname,
 		pid:          os.Getpid(),
 		mtx:          sync.Mutex{},
 		queue:        list.New(),
 		maxQueueSize: opts.MaxMessageQueueSize,
+		maxDgramSize: opts.MaxDatagramSize,
+		splitDgrams:  opts.SplitDatagrams,
 		workerDoneCh: make(chan struct{}),
+		stopTimeout:  opts.StopTimeout,
+		fallbackFile: opts.FallbackFile,
 		globals:      glbOpts,
 	}
 	lg.notEmptyCond = sync.NewCond(&lg.mtx)
+	lg.abortCtx, lg.abortCancel = context.WithCancel(context.Background())
+	lg.dialer = &net.Dialer{
+		KeepAlive: opts.KeepAlive,
+	}
+	lg.idleReconnect = opts.IdleReconnect
+	if lg.stopTimeout <= 0 {
+		lg.stopTimeout = flushTimeout
+	}
+	lg.severityField = opts.SeverityFromField
 
 	// Set output level based on globals or overrides
 	if opts.Level != nil {
@@ -125,11 +320,30 @@ func createSysLogAdapter(opts SysLogOptions, glbOpts globalOptions) (internalLog
 		lg.globals.DebugLevel = *opts.DebugLevel
 	}
 
+	if opts.Facility != nil {
+		lg.facility = *opts.Facility
+	}
+	lg.facilityNames = opts.FacilityByName
+
+	if opts.CEF != nil {
+		cef := *opts.CEF
+		if len(cef.Product) == 0 {
+			cef.Product = opts.AppName
+		}
+		lg.cef = &cef
+	}
+
 	if opts.MaxMessageQueueSize == 0 {
 		lg.maxQueueSize = defaultMaxMessageQueueSize
 	}
+	lg.queueDisabled = opts.QueueWhileDisabled
+
+	// Custom connections are streams
+	if lg.connFactory != nil {
+		lg.useTcp = true
+	}
 
-	if opts.UseTls {
+	if opts.UseTls && lg.connFactory == nil {
 		if opts.TlsConfig != nil {
 			lg.tlsConfig = opts.TlsConfig.Clone()
 		} else {
@@ -137,6 +351,17 @@ func createSysLogAdapter(opts SysLogOptions, glbOpts globalOptions) (internalLog
 				MinVersion: 2,
 			}
 		}
+		if opts.TLSSessionCache != nil {
+			lg.tlsConfig.ClientSessionCache = opts.TLSSessionCache
+		}
+		if lg.tlsConfig.RootCAs == nil {
+			var err error
+
+			lg.tlsConfig.RootCAs, err = loadRootCAs(opts.UseSystemRootCAs == nil || *opts.UseSystemRootCAs, opts.CAFile)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Set the server host
@@ -162,7 +387,25 @@ func createSysLogAdapter(opts SysLogOptions, glbOpts globalOptions) (internalLog
 	lg.serverAddress += ":" + strconv.Itoa(int(port))
 
 	// Set the client host name
-	lg.hostname, _ = os.Hostname()
+	if lg.hostnameFunc != nil {
+		lg.userHostFunc = true
+	} else {
+		lg.hostnameFunc = os.Hostname
+	}
+	hostname, _ := lg.hostnameFunc()
+	lg.hostname.Store(hostname)
+	atomic.StoreInt64(&lg.nextHostRead, time.Now().Add(lg.refreshHost).UnixNano())
+
+	// Open the spool and queue the messages left by a previous run
+	if len(opts.SpoolDir) > 0 {
+		var err error
+
+		lg.spool, err = openSysLogSpool(opts.SpoolDir, opts.AppName, opts.SpoolMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		lg.replaySpool()
+	}
 
 	// Create a background messenger worker
 	go lg.messengerWorker()
@@ -171,24 +414,90 @@ func createSysLogAdapter(opts SysLogOptions, glbOpts globalOptions) (internalLog
 	return lg, nil
 }
 
+// loadRootCAs creates the pool of root CAs to verify the server certificate.
+func loadRootCAs(useSystem bool, caFile string) (*x509.CertPool, error) {
+	var pool *x509.CertPool
+
+	if useSystem {
+		pool, _ = x509.SystemCertPool()
+	}
+	if pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if len(caFile) > 0 {
+		pemCerts, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pemCerts) {
+			return nil, fmt.Errorf("no valid certificates found in %v", caFile)
+		}
+	}
+
+	// Done
+	return pool, nil
+}
+
 func (lg *syslogAdapter) class() string {
 	return "syslog"
 }
 
 func (lg *syslogAdapter) destroy() {
+	// Close the connection if the messages cannot be delivered in time, so a write blocked by a server not reading
+	// does not stall the shutdown
+	deadline := time.Now().Add(lg.stopTimeout)
+	abortTimer := time.AfterFunc(lg.stopTimeout, lg.abortWrites)
+
 	// Stop worker
 	atomic.StoreInt32(&lg.shutdown, 1)
 	lg.notEmptyCond.Broadcast()
 
 	// Wait until exited
 	<-lg.workerDoneCh
-	close(lg.workerDoneCh)
 
 	// Flush queued messages
-	lg.flushQueue()
+	lg.flushQueue(deadline)
+	abortTimer.Stop()
+	lg.abortCancel()
 
 	// Disconnect from the network
+	lg.connMtx.Lock()
 	lg.disconnect()
+	lg.connMtx.Unlock()
+
+	// Close the fallback file
+	lg.fallbackMtx.Lock()
+	if lg.fallbackFd != nil {
+		_ = lg.fallbackFd.Sync()
+		_ = lg.fallbackFd.Close()
+		lg.fallbackFd = nil
+	}
+	lg.fallbackMtx.Unlock()
+}
+
+func (lg *syslogAdapter) health() SysLogHealth {
+	h := SysLogHealth{
+		Sent:    atomic.LoadUint64(&lg.sentCount),
+		Dropped: atomic.LoadUint64(&lg.droppedCount),
+	}
+
+	if atomic.LoadInt32(&lg.lastWasError) != 0 {
+		h.State = SysLogReconnecting
+	} else if atomic.LoadInt32(&lg.connected) != 0 {
+		h.State = SysLogConnected
+	}
+
+	if ts := atomic.LoadInt64(&lg.lastErrorTime); ts != 0 {
+		h.LastErrorTime = time.Unix(0, ts)
+	}
+
+	lg.mtx.Lock()
+	h.QueueLength = lg.queue.Len()
+	lg.mtx.Unlock()
+
+	// Done
+	return h
 }
 
 func (lg *syslogAdapter) setLevel(level LogLevel, debugLevel uint) {
@@ -196,33 +505,174 @@ func (lg *syslogAdapter) setLevel(level LogLevel, debugLevel uint) {
 	lg.globals.DebugLevel = debugLevel
 }
 
+func (lg *syslogAdapter) getLevel() (LogLevel, uint) {
+	return lg.globals.Level, lg.globals.DebugLevel
+}
+
+func (lg *syslogAdapter) enabled(level LogLevel, debugLevel uint) bool {
+	if lg.queueDisabled {
+		return lg.globals.passesLevel(level, debugLevel)
+	}
+	return lg.globals.isEnabled(level, debugLevel)
+}
+
+func (lg *syslogAdapter) setEnabled(enabled bool) {
+	lg.globals.setEnabled(enabled)
+
+	// Wake up the worker to send the messages queued while disabled
+	if enabled && lg.queueDisabled {
+		lg.mtx.Lock()
+		lg.notEmptyCond.Signal()
+		lg.mtx.Unlock()
+	}
+}
+
+// paused returns true if messages must be kept in the queue because the target is disabled.
+func (lg *syslogAdapter) paused() bool {
+	return lg.queueDisabled && lg.globals.isDisabled()
+}
+
+// sync sends the queued messages synchronously and returns an error if some of them could not be delivered. They
+// are kept in the queue to be retried by the worker.
+func (lg *syslogAdapter) sync() error {
+	if lg.paused() {
+		return nil // Queued messages are sent once enabled again
+	}
+
+	deadline := time.Now().Add(flushTimeout)
+
+	// Waiting for the connection lock ensures the message being sent by the worker, if any, was delivered
+	lg.connMtx.Lock()
+	defer lg.connMtx.Unlock()
+
+	for time.Now().Before(deadline) {
+		lg.mtx.Lock()
+		elem := lg.queue.Front()
+		if elem != nil {
+			lg.queue.Remove(elem)
+		}
+		lg.mtx.Unlock()
+		if elem == nil {
+			return nil // Reached the end
+		}
+
+		// Send message to server
+		err := lg.writeBytes([]byte(elem.Value.(string)))
+		lg.handleError(err)
+		if err != nil {
+			lg.mtx.Lock()
+			lg.queue.PushFront(elem.Value)
+			lg.mtx.Unlock()
+			return err
+		}
+	}
+	return errors.New("timeout while sending queued messages")
+}
+
 func (lg *syslogAdapter) logError(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelError {
-		lg.writeString(facilityUser, severityError, now, msg, raw)
+		lg.writeString(lg.facility, SeverityError, now, msg, raw)
 	}
 }
 
 func (lg *syslogAdapter) logWarning(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelWarning {
-		lg.writeString(facilityUser, severityWarning, now, msg, raw)
+		lg.writeString(lg.facility, SeverityWarning, now, msg, raw)
 	}
 }
 
 func (lg *syslogAdapter) logInfo(now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelInfo {
-		lg.writeString(facilityUser, severityInformational, now, msg, raw)
+		lg.writeString(lg.facility, SeverityInformational, now, msg, raw)
 	}
 }
 
 func (lg *syslogAdapter) logDebug(level uint, now time.Time, msg string, raw bool) {
 	if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= level {
-		lg.writeString(facilityUser, severityDebug, now, msg, raw)
+		lg.writeString(lg.facility, SeverityDebug, now, msg, raw)
+	}
+}
+
+func (lg *syslogAdapter) logAudit(now time.Time, msg string, raw bool) {
+	lg.writeAudit(lg.facility, now, msg, raw)
+}
+
+func (lg *syslogAdapter) logNamed(name string, level LogLevel, debugLevel uint, now time.Time, msg string, raw bool) {
+	facility, ok := lg.facilityNames[name]
+	if !ok {
+		facility = lg.facility
+	}
+
+	switch level {
+	case LogLevelError:
+		if lg.globals.Level >= LogLevelError {
+			lg.writeString(facility, SeverityError, now, msg, raw)
+		}
+	case LogLevelWarning:
+		if lg.globals.Level >= LogLevelWarning {
+			lg.writeString(facility, SeverityWarning, now, msg, raw)
+		}
+	case LogLevelInfo:
+		if lg.globals.Level >= LogLevelInfo {
+			lg.writeString(facility, SeverityInformational, now, msg, raw)
+		}
+	case LogLevelDebug:
+		if lg.globals.Level >= LogLevelDebug && lg.globals.DebugLevel >= debugLevel {
+			lg.writeString(facility, SeverityDebug, now, msg, raw)
+		}
+	case logLevelAudit:
+		lg.writeAudit(facility, now, msg, raw)
 	}
 }
 
-func (lg *syslogAdapter) writeString(facility int, severity int, now time.Time, msg string, _ bool) {
+func (lg *syslogAdapter) writeAudit(facility Facility, now time.Time, msg string, raw bool) {
+	// Audit messages are sent synchronously and queued only if delivery fails or the target is disabled
+	for _, frame := range lg.formatMessage(facility, SeverityNotice, now, msg, raw) {
+		if lg.paused() {
+			lg.queueMessage(frame)
+			continue
+		}
+
+		lg.connMtx.Lock()
+		err := lg.writeBytes([]byte(frame))
+		lg.connMtx.Unlock()
+
+		lg.handleError(err)
+		if err != nil {
+			lg.queueMessage(frame)
+		}
+	}
+}
+
+func (lg *syslogAdapter) writeString(facility Facility, severity Severity, now time.Time, msg string, raw bool) {
+	for _, frame := range lg.formatMessage(facility, severity, now, msg, raw) {
+		lg.queueMessage(frame)
+	}
+}
+
+// formatMessage returns the message to send, formatted according to the selected protocol. It can return more
+// than one frame if the message must be split.
+func (lg *syslogAdapter) formatMessage(facility Facility, severity Severity, now time.Time, msg string,
+	raw bool,
+) []string {
+	if raw && len(lg.severityField) > 0 {
+		if fields, ok := parseJSONFields(msg); ok {
+			for _, f := range fields {
+				if f.key == lg.severityField {
+					if s, valid := parseSeverity(f.value); valid {
+						severity = s
+					}
+					break
+				}
+			}
+		}
+	}
+	if lg.cef != nil {
+		msg = formatCEF(lg.cef, severity, now, msg, raw)
+	}
+
 	// Establish priority
-	priority := (facility * 8) + severity
+	priority := (int(facility) * 8) + int(severity)
 
 	// Remove or add new line depending on the transport protocol
 	if lg.useTcp {
@@ -233,31 +683,131 @@ func (lg *syslogAdapter) writeString(facility int, severity int, now time.Time,
 		msg = strings.TrimSuffix(msg, "\n")
 	}
 
-	// Format and queue the message
+	// Format the message header
 	// NOTE: We don't need to care here about the message type because level and timestamp are in separate fields.
+	var header string
+	hostname := lg.getHostname()
 	if !lg.useRFC5424 {
-		lg.queueMessage("<" + strconv.Itoa(priority) + ">" + now.Format("Jan _2 15:04:05") + " " +
-			lg.hostname + " " + msg)
+		header = "<" + strconv.Itoa(priority) + ">" + now.Format("Jan _2 15:04:05") + " " + hostname + " "
 	} else {
-		lg.queueMessage("<" + strconv.Itoa(priority) + ">1 " + now.Format("2006-02-01T15:04:05Z") + " " +
-			lg.hostname + " " + lg.appName + " " + strconv.Itoa(lg.pid) + " - - " + msg)
+		header = "<" + strconv.Itoa(priority) + ">1 " + now.Format("2006-01-02T15:04:05Z") + " " +
+			hostname + " " + lg.appName + " " + strconv.Itoa(lg.pid) + " - - "
+	}
+
+	// Check the datagram size limit if using UDP
+	if !lg.useTcp && lg.maxDgramSize > 0 && uint(len(header)+len(msg)) > lg.maxDgramSize {
+		if lg.splitDgrams {
+			fragments, ok := lg.splitMessage(header, msg)
+			if ok {
+				return fragments
+			}
+		}
+
+		if lg.globals.ErrorHandler != nil {
+			lg.globals.ErrorHandler(fmt.Sprintf("SysLog message size of %v bytes exceeds the maximum datagram size",
+				len(header)+len(msg)))
+		}
+	}
+
+	// Done
+	return []string{header + msg}
+}
+
+// getHostname returns the client host name, reading it again if the refresh interval elapsed.
+func (lg *syslogAdapter) getHostname() string {
+	if lg.refreshHost > 0 || lg.userHostFunc {
+		now := time.Now().UnixNano()
+		nextRead := atomic.LoadInt64(&lg.nextHostRead)
+		if now >= nextRead && atomic.CompareAndSwapInt64(&lg.nextHostRead, nextRead, now+int64(lg.refreshHost)) {
+			hostname, err := lg.hostnameFunc()
+			if err == nil {
+				lg.hostname.Store(hostname)
+			}
+		}
+	}
+	return lg.hostname.Load().(string)
+}
+
+// parseSeverity converts a number, from 0 to 7, or a name, like "err" or "error", into a severity.
+func parseSeverity(s string) (Severity, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n >= int(SeverityEmergency) && n <= int(SeverityDebug) {
+			return Severity(n), true
+		}
+		return 0, false
+	}
+
+	switch strings.ToLower(s) {
+	case "emerg", "emergency", "panic":
+		return SeverityEmergency, true
+	case "alert":
+		return SeverityAlert, true
+	case "crit", "critical":
+		return SeverityCritical, true
+	case "err", "error":
+		return SeverityError, true
+	case "warn", "warning":
+		return SeverityWarning, true
+	case "notice":
+		return SeverityNotice, true
+	case "info", "informational":
+		return SeverityInformational, true
+	case "debug":
+		return SeverityDebug, true
 	}
+	return 0, false
+}
+
+// splitMessage splits a long message in several fragments, each one prefixed with the syslog header so they can
+// be independently parsed. All but the last fragment ends with a continuation marker.
+func (lg *syslogAdapter) splitMessage(header string, msg string) ([]string, bool) {
+	maxLen := int(lg.maxDgramSize) - len(header) - len(datagramContinuationMarker)
+	if maxLen < utf8.UTFMax {
+		return nil, false // Not enough room to split
+	}
+
+	fragments := make([]string, 0)
+	for len(header)+len(msg) > int(lg.maxDgramSize) {
+		// Avoid splitting a multibyte character
+		cut := maxLen
+		for cut > 0 && !utf8.RuneStart(msg[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = maxLen // Not valid UTF-8
+		}
+
+		fragments = append(fragments, header+msg[:cut]+datagramContinuationMarker)
+		msg = msg[cut:]
+	}
+	fragments = append(fragments, header+msg)
+
+	// Done
+	return fragments, true
 }
 
 func (lg *syslogAdapter) queueMessage(msg string) {
+	var evicted []string
+
 	lg.mtx.Lock()
-	defer lg.mtx.Unlock()
 
 	if uint(lg.queue.Len()) > lg.maxQueueSize {
 		elem := lg.queue.Front()
 		if elem != nil {
 			lg.queue.Remove(elem)
+			evicted = append(evicted, elem.Value.(string))
 		}
 	}
 	lg.queue.PushBack(msg)
 
 	// Wake up worker if needed
 	lg.notEmptyCond.Signal()
+
+	lg.mtx.Unlock()
+
+	if len(evicted) > 0 {
+		lg.dropMessages(evicted)
+	}
 }
 
 func (lg *syslogAdapter) dequeueMessage() (string, bool) {
@@ -270,8 +820,9 @@ func (lg *syslogAdapter) dequeueMessage() (string, bool) {
 		}
 
 		elem := lg.queue.Front()
-		if elem != nil {
+		if elem != nil && !lg.paused() {
 			lg.queue.Remove(elem)
+			atomic.StoreInt32(&lg.sending, 1)
 			return elem.Value.(string), false
 		}
 
@@ -285,20 +836,36 @@ func (lg *syslogAdapter) messengerWorker() {
 	for {
 		msg, quit := lg.dequeueMessage()
 		if quit {
-			lg.workerDoneCh <- struct {}{}
+			close(lg.workerDoneCh)
 			return
 		}
 
 		// Send message to server
+		lg.connMtx.Lock()
 		err := lg.writeBytes([]byte(msg))
+		lg.connMtx.Unlock()
 
 		// Handle error
+		if err != nil {
+			lg.dropMessages([]string{msg})
+		} else if lg.spool != nil && lg.spool.pending() {
+			// The server is reachable again, so send the spooled messages
+			lg.replaySpool()
+		}
 		lg.handleError(err)
+		atomic.StoreInt32(&lg.sending, 0)
 	}
 }
 
-func (lg *syslogAdapter) flushQueue() {
-	deadline := time.Now().Add(flushTimeout)
+func (lg *syslogAdapter) idle() bool {
+	lg.mtx.Lock()
+	defer lg.mtx.Unlock()
+
+	return lg.queue.Len() == 0 && atomic.LoadInt32(&lg.sending) == 0
+}
+
+func (lg *syslogAdapter) flushQueue(deadline time.Time) {
+	lg.connMtx.Lock()
 
 	for time.Now().Before(deadline) {
 		// Dequeue next message
@@ -306,58 +873,214 @@ func (lg *syslogAdapter) flushQueue() {
 		if elem == nil {
 			break // Reached the end
 		}
-		lg.queue.Remove(elem)
 
 		// Send message to server
 		err := lg.writeBytes([]byte(elem.Value.(string)))
 		if err != nil {
 			break // Stop on error
 		}
+		lg.queue.Remove(elem)
+	}
+
+	lg.connMtx.Unlock()
+
+	// Discard the messages that could not be sent
+	remaining := make([]string, 0, lg.queue.Len())
+	for elem := lg.queue.Front(); elem != nil; elem = elem.Next() {
+		remaining = append(remaining, elem.Value.(string))
+	}
+	lg.queue.Init()
+	if len(remaining) > 0 {
+		lg.dropMessages(remaining)
+	}
+}
+
+// dropMessages accounts the given messages as dropped and appends them to the fallback file if one was set.
+func (lg *syslogAdapter) dropMessages(msgs []string) {
+	// Persist them in the spool to send them later
+	if lg.spool != nil {
+		stored, err := lg.spool.store(msgs)
+		lg.handleSpoolError(err)
+		msgs = msgs[stored:]
+		if len(msgs) == 0 {
+			return
+		}
+	}
+
+	atomic.AddUint64(&lg.droppedCount, uint64(len(msgs)))
+
+	if len(lg.fallbackFile) == 0 {
+		return
+	}
+
+	// Lock access
+	lg.fallbackMtx.Lock()
+	defer lg.fallbackMtx.Unlock()
+
+	if lg.fallbackFd == nil {
+		var err error
+
+		_ = os.MkdirAll(filepath.Dir(lg.fallbackFile), 0755)
+		lg.fallbackFd, err = os.OpenFile(lg.fallbackFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			lg.fallbackFd = nil
+			if !lg.fallbackErr && lg.globals.ErrorHandler != nil {
+				lg.globals.ErrorHandler(fmt.Sprintf("Unable to open SysLog fallback file [%v]", err))
+			}
+			lg.fallbackErr = true
+			return
+		}
+		lg.fallbackErr = false
+	}
+
+	for _, msg := range msgs {
+		_, _ = lg.fallbackFd.WriteString(strings.TrimRight(msg, "\n") + newLine)
 	}
 }
 
-func (lg *syslogAdapter) handleError(err error) {
+// replaySpool queues again the messages stored in the spool.
+func (lg *syslogAdapter) replaySpool() {
+	msgs, err := lg.spool.load()
+	lg.handleSpoolError(err)
+	for _, msg := range msgs {
+		lg.queueMessage(msg)
+	}
+}
+
+func (lg *syslogAdapter) handleSpoolError(err error) {
 	if err == nil {
-		atomic.StoreInt32(&lg.lastWasError, 0)
+		atomic.StoreInt32(&lg.spoolErr, 0)
 	} else {
-		if atomic.CompareAndSwapInt32(&lg.lastWasError, 0, 1) && lg.globals.ErrorHandler != nil {
-			lg.globals.ErrorHandler(fmt.Sprintf("Unable to deliver notification to SysLog [%v]", err))
+		if atomic.CompareAndSwapInt32(&lg.spoolErr, 0, 1) && lg.globals.ErrorHandler != nil {
+			lg.globals.ErrorHandler(fmt.Sprintf("Unable to use SysLog spool [%v]", err))
 		}
 	}
 }
 
+func (lg *syslogAdapter) handleError(err error) {
+	if err != nil {
+		atomic.StoreInt64(&lg.lastErrorTime, time.Now().UnixNano())
+	}
+	if lg.globals.shouldNotifyError(err, &lg.lastWasError, &lg.nextErrNotify) && lg.globals.ErrorHandler != nil {
+		lg.globals.ErrorHandler(fmt.Sprintf("Unable to deliver notification to SysLog [%v]", err))
+	}
+}
+
 func (lg *syslogAdapter) connect() error {
 	var err error
 
 	lg.disconnect()
 
-	if lg.useTcp {
+	if lg.isAborted() {
+		return errors.New("logger is being destroyed")
+	}
+
+	if lg.connFactory != nil {
+		lg.conn, err = lg.connFactory()
+		if err == nil && lg.conn == nil {
+			err = errors.New("connection factory returned no connection")
+		}
+	} else if lg.useTcp {
 		if lg.tlsConfig != nil {
-			lg.conn, err = tls.Dial("tcp", lg.serverAddress, lg.tlsConfig)
+			var conn net.Conn
+
+			tlsDialer := tls.Dialer{
+				NetDialer: lg.dialer,
+				Config:    lg.tlsConfig,
+			}
+			conn, err = tlsDialer.DialContext(lg.abortCtx, "tcp", lg.serverAddress)
+			if err == nil {
+				tlsConn := conn.(*tls.Conn)
+				lg.conn = tlsConn
+
+				// TLS 1.3 session tickets are sent by the server after the handshake and only processed while
+				// reading, so keep reading the connection if sessions must be cached.
+				if lg.tlsConfig.ClientSessionCache != nil {
+					go drainConnection(tlsConn)
+				}
+			}
 		} else {
-			lg.conn, err = net.Dial("tcp", lg.serverAddress)
+			lg.conn, err = lg.dialer.DialContext(lg.abortCtx, "tcp", lg.serverAddress)
 		}
 	} else {
-		lg.conn, err = net.Dial("udp", lg.serverAddress)
+		lg.conn, err = lg.dialer.DialContext(lg.abortCtx, "udp", lg.serverAddress)
+	}
+	if err == nil {
+		atomic.StoreInt32(&lg.connected, 1)
+		lg.trackConn(lg.conn)
 	}
 
 	return err
 }
 
+// drainConnection discards any data sent by the server until the connection is closed.
+func drainConnection(conn net.Conn) {
+	buf := make([]byte, 256)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			return
+		}
+	}
+}
+
 func (lg *syslogAdapter) disconnect() {
+	atomic.StoreInt32(&lg.connected, 0)
 	if lg.conn != nil {
+		lg.trackConn(nil)
 		_ = lg.conn.Close()
 		lg.conn = nil
 	}
 }
 
+// trackConn keeps a reference to the connection in use, so abortWrites can close it while the connection lock is
+// held by a blocked write. A connection established after aborting is closed right away.
+func (lg *syslogAdapter) trackConn(conn net.Conn) {
+	lg.abortMtx.Lock()
+	lg.liveConn = conn
+	aborted := lg.aborted
+	lg.abortMtx.Unlock()
+
+	if aborted && conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// abortWrites makes pending and future writes fail by closing the connection in use, canceling the one being
+// established, if any, and preventing new ones.
+func (lg *syslogAdapter) abortWrites() {
+	lg.abortMtx.Lock()
+	lg.aborted = true
+	conn := lg.liveConn
+	lg.abortMtx.Unlock()
+
+	lg.abortCancel()
+
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+func (lg *syslogAdapter) isAborted() bool {
+	lg.abortMtx.Lock()
+	defer lg.abortMtx.Unlock()
+
+	return lg.aborted
+}
+
 func (lg *syslogAdapter) writeBytes(b []byte) error {
 	var err error
 
+	// Do not trust a connection that was idle for too long
+	if lg.conn != nil && lg.useTcp && lg.idleReconnect > 0 && time.Since(lg.lastWrite) >= lg.idleReconnect {
+		lg.disconnect()
+	}
+
 	// Send the message if connected
 	if lg.conn != nil {
-		_, err = lg.conn.Write(b)
+		err = writeFull(lg.conn, b)
 		if err == nil {
+			atomic.AddUint64(&lg.sentCount, 1)
+			lg.lastWrite = time.Now()
 			return nil
 		}
 	}
@@ -365,8 +1088,11 @@ func (lg *syslogAdapter) writeBytes(b []byte) error {
 	// On error or if disconnected, try to connect
 	err = lg.connect()
 	if err == nil {
-		_, err = lg.conn.Write(b)
-		if err != nil {
+		err = writeFull(lg.conn, b)
+		if err == nil {
+			atomic.AddUint64(&lg.sentCount, 1)
+			lg.lastWrite = time.Now()
+		} else {
 			lg.disconnect()
 		}
 	}
@@ -374,3 +1100,19 @@ func (lg *syslogAdapter) writeBytes(b []byte) error {
 	// Done
 	return err
 }
+
+// writeFull writes the whole buffer, continuing after short writes, so a frame is never cut in the middle of a
+// stream connection.
+func writeFull(conn net.Conn, b []byte) error {
+	for len(b) > 0 {
+		n, err := conn.Write(b)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return io.ErrShortWrite
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package go_logger
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	defaultSpoolMaxBytes = 16 * 1024 * 1024
+
+	spoolRecordHeaderSize = 4
+)
+
+//------------------------------------------------------------------------------
+
+// syslogSpool persists undelivered syslog messages on disk so they can be sent on reconnection or after a restart.
+// Each record is stored as a 32-bit big-endian length followed by the formatted message.
+type syslogSpool struct {
+	mtx      sync.Mutex
+	filename string
+	maxBytes int64
+	size     int64
+}
+
+//------------------------------------------------------------------------------
+
+func openSysLogSpool(dir string, appName string, maxBytes int64) (*syslogSpool, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &syslogSpool{
+		filename: filepath.Join(dir, strings.ToLower(appName)+".spool"),
+		maxBytes: maxBytes,
+	}
+	if s.maxBytes <= 0 {
+		s.maxBytes = defaultSpoolMaxBytes
+	}
+
+	// Take into account the messages left by a previous run
+	fi, err := os.Stat(s.filename)
+	if err == nil {
+		s.size = fi.Size()
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Done
+	return s, nil
+}
+
+// store appends the messages to the spool file until the size limit is reached. It returns the amount of messages
+// stored.
+func (s *syslogSpool) store(msgs []string) (int, error) {
+	var header [spoolRecordHeaderSize]byte
+
+	// Lock access
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	fd, err := os.OpenFile(s.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	for _, msg := range msgs {
+		recordSize := int64(spoolRecordHeaderSize + len(msg))
+		if s.size+recordSize > s.maxBytes {
+			break // Spool is full
+		}
+
+		binary.BigEndian.PutUint32(header[:], uint32(len(msg)))
+		_, err = fd.Write(append(header[:], msg...))
+		if err != nil {
+			break
+		}
+		s.size += recordSize
+		stored += 1
+	}
+
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	return stored, err
+}
+
+// load returns all the spooled messages and empties the spool.
+func (s *syslogSpool) load() ([]string, error) {
+	// Lock access
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.size == 0 {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(s.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.size = 0
+			err = nil
+		}
+		return nil, err
+	}
+
+	// Parse records. A truncated record at the end, for example, after a crash, is discarded.
+	msgs := make([]string, 0)
+	for len(content) >= spoolRecordHeaderSize {
+		msgLen := int(binary.BigEndian.Uint32(content))
+		if len(content) < spoolRecordHeaderSize+msgLen {
+			break
+		}
+		msgs = append(msgs, string(content[spoolRecordHeaderSize:spoolRecordHeaderSize+msgLen]))
+		content = content[spoolRecordHeaderSize+msgLen:]
+	}
+
+	err = os.Remove(s.filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	s.size = 0
+
+	// Done
+	return msgs, nil
+}
+
+// pending returns true if the spool contains messages.
+func (s *syslogSpool) pending() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.size > 0
+}
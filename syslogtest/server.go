@@ -0,0 +1,354 @@
+// Package syslogtest provides a mock syslog server to integration-test syslog configurations.
+package syslogtest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
+	"github.com/influxdata/go-syslog/v3/rfc5424"
+)
+
+//------------------------------------------------------------------------------
+
+// MockServerOptions specifies the mock server settings to use when it is started.
+type MockServerOptions struct {
+	// Address to listen on. Defaults to 127.0.0.1 on a random port.
+	Address string
+
+	// Listen for TCP connections instead of UDP datagrams. Messages must be separated by new lines.
+	UseTcp bool
+
+	// Optionally accept TLS connections. Implies TCP.
+	TlsConfig *tls.Config
+}
+
+// Message is a syslog message received by the mock server.
+type Message struct {
+	// Raw is the message as it was received.
+	Raw string
+
+	// RFC5424 is true if the message was sent using the RFC 5424 format.
+	RFC5424 bool
+
+	Priority uint8
+	Facility uint8
+	Severity uint8
+	Hostname string
+	AppName  string
+	Text     string
+}
+
+// MockServer is a syslog server that stores the received messages.
+type MockServer struct {
+	mtx      sync.Mutex
+	cond     *sync.Cond
+	conn     net.PacketConn
+	listener net.Listener
+	clients  map[net.Conn]struct{}
+	messages []Message
+	errs     []error
+	accepted int
+	resumed  int
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+//------------------------------------------------------------------------------
+
+var rfc5424Header = regexp.MustCompile(`^<\d{1,3}>1 `)
+
+//------------------------------------------------------------------------------
+
+// StartMockServer starts a mock syslog server. It is ready to receive messages when this function returns.
+func StartMockServer(opts MockServerOptions) (*MockServer, error) {
+	var err error
+
+	address := opts.Address
+	if len(address) == 0 {
+		address = "127.0.0.1:0"
+	}
+
+	srv := &MockServer{
+		clients:  make(map[net.Conn]struct{}),
+		messages: make([]Message, 0),
+		errs:     make([]error, 0),
+	}
+	srv.cond = sync.NewCond(&srv.mtx)
+
+	if opts.UseTcp || opts.TlsConfig != nil {
+		if opts.TlsConfig != nil {
+			srv.listener, err = tls.Listen("tcp", address, opts.TlsConfig)
+		} else {
+			srv.listener, err = net.Listen("tcp", address)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		srv.wg.Add(1)
+		go srv.acceptLoop()
+	} else {
+		srv.conn, err = net.ListenPacket("udp", address)
+		if err != nil {
+			return nil, err
+		}
+
+		srv.wg.Add(1)
+		go srv.packetLoop()
+	}
+
+	// Done
+	return srv, nil
+}
+
+// Addr returns the address the server is listening on.
+func (srv *MockServer) Addr() net.Addr {
+	if srv.listener != nil {
+		return srv.listener.Addr()
+	}
+	return srv.conn.LocalAddr()
+}
+
+// Port returns the port the server is listening on.
+func (srv *MockServer) Port() uint16 {
+	switch addr := srv.Addr().(type) {
+	case *net.TCPAddr:
+		return uint16(addr.Port)
+	case *net.UDPAddr:
+		return uint16(addr.Port)
+	}
+	return 0
+}
+
+// Close stops the server and closes all the client connections.
+func (srv *MockServer) Close() {
+	srv.mtx.Lock()
+	if srv.closed {
+		srv.mtx.Unlock()
+		return
+	}
+	srv.closed = true
+	if srv.listener != nil {
+		_ = srv.listener.Close()
+	}
+	if srv.conn != nil {
+		_ = srv.conn.Close()
+	}
+	for client := range srv.clients {
+		_ = client.Close()
+	}
+	srv.cond.Broadcast()
+	srv.mtx.Unlock()
+
+	srv.wg.Wait()
+}
+
+// Messages returns the text of the received messages.
+func (srv *MockServer) Messages() []string {
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+
+	texts := make([]string, len(srv.messages))
+	for idx, msg := range srv.messages {
+		texts[idx] = msg.Text
+	}
+	return texts
+}
+
+// Entries returns the received messages.
+func (srv *MockServer) Entries() []Message {
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+
+	return append([]Message{}, srv.messages...)
+}
+
+// Errors returns the errors found while parsing the received messages.
+func (srv *MockServer) Errors() []error {
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+
+	return append([]error{}, srv.errs...)
+}
+
+// Connections returns the amount of TCP or TLS connections accepted.
+func (srv *MockServer) Connections() int {
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+
+	return srv.accepted
+}
+
+// ResumedSessions returns the amount of TLS connections that resumed a previous session instead of doing a full
+// handshake.
+func (srv *MockServer) ResumedSessions() int {
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+
+	return srv.resumed
+}
+
+// WaitForMessages waits until at least the specified amount of messages were received or the timeout elapses.
+// It returns true if the messages were received.
+func (srv *MockServer) WaitForMessages(count int, timeout time.Duration) bool {
+	timer := time.AfterFunc(timeout, func() {
+		srv.mtx.Lock()
+		srv.cond.Broadcast()
+		srv.mtx.Unlock()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+
+	srv.mtx.Lock()
+	defer srv.mtx.Unlock()
+
+	for len(srv.messages) < count && !srv.closed && time.Now().Before(deadline) {
+		srv.cond.Wait()
+	}
+	return len(srv.messages) >= count
+}
+
+//------------------------------------------------------------------------------
+// Private methods
+
+func (srv *MockServer) packetLoop() {
+	defer srv.wg.Done()
+
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := srv.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		// Ignore trailing control characters and NULs
+		for ; n > 0 && buf[n-1] < 32; n-- {
+		}
+		if n > 0 {
+			srv.processMessage(string(buf[:n]))
+		}
+	}
+}
+
+func (srv *MockServer) acceptLoop() {
+	defer srv.wg.Done()
+
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		srv.mtx.Lock()
+		if srv.closed {
+			srv.mtx.Unlock()
+			_ = conn.Close()
+			return
+		}
+		srv.clients[conn] = struct{}{}
+		srv.accepted += 1
+		srv.mtx.Unlock()
+
+		srv.wg.Add(1)
+		go srv.connectionLoop(conn)
+	}
+}
+
+func (srv *MockServer) connectionLoop(conn net.Conn) {
+	defer srv.wg.Done()
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if tlsConn.Handshake() == nil && tlsConn.ConnectionState().DidResume {
+			srv.mtx.Lock()
+			srv.resumed += 1
+			srv.mtx.Unlock()
+		}
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for len(line) > 0 && line[len(line)-1] < 32 {
+			line = line[:len(line)-1]
+		}
+		if len(line) > 0 {
+			srv.processMessage(line)
+		}
+	}
+
+	srv.mtx.Lock()
+	delete(srv.clients, conn)
+	srv.mtx.Unlock()
+	_ = conn.Close()
+}
+
+func (srv *MockServer) processMessage(raw string) {
+	var parsed syslog.Message
+	var err error
+
+	msg := Message{
+		Raw:     raw,
+		RFC5424: rfc5424Header.MatchString(raw),
+	}
+
+	// Parse the syslog message
+	if msg.RFC5424 {
+		parsed, err = rfc5424.NewParser().Parse([]byte(raw))
+		if err == nil {
+			m := parsed.(*rfc5424.SyslogMessage)
+			msg.Priority, msg.Facility, msg.Severity = derefUint8(m.Priority), derefUint8(m.Facility),
+				derefUint8(m.Severity)
+			msg.Hostname, msg.AppName, msg.Text = derefString(m.Hostname), derefString(m.Appname),
+				derefString(m.Message)
+		}
+	} else {
+		parsed, err = rfc3164.NewParser().Parse([]byte(raw))
+		if err == nil {
+			m := parsed.(*rfc3164.SyslogMessage)
+			msg.Priority, msg.Facility, msg.Severity = derefUint8(m.Priority), derefUint8(m.Facility),
+				derefUint8(m.Severity)
+			msg.Hostname, msg.AppName, msg.Text = derefString(m.Hostname), derefString(m.Appname),
+				derefString(m.Message)
+		}
+	}
+
+	srv.mtx.Lock()
+	if err == nil {
+		srv.messages = append(srv.messages, msg)
+	} else {
+		srv.errs = append(srv.errs, err)
+	}
+	srv.cond.Broadcast()
+	srv.mtx.Unlock()
+}
+
+func derefUint8(v *uint8) uint8 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
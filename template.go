@@ -0,0 +1,137 @@
+package go_logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Fields are the values of the named placeholders of a message template.
+type Fields map[string]interface{}
+
+//------------------------------------------------------------------------------
+
+// Errort emits an error message built from a template with named placeholders, like "user {user} did {action}".
+// Like structs, it is a JSON message unless StructTextModeKeyValue is set. The message field of JSON messages holds
+// the template with the placeholders replaced by the values of the fields, the message_template field holds the
+// template itself and each field is also added to the message. Plain text messages hold the rendered template
+// followed by the fields as key=value pairs. Use {{ and }} to output literal braces. Placeholders without a field are
+// kept as they are.
+func (lg *Logger) Errort(template string, fields Fields) {
+	lg.emit(LogLevelError, 0, templateMessage(template, fields))
+}
+
+// Warningt emits a warning message built from a template with named placeholders. See Errort.
+func (lg *Logger) Warningt(template string, fields Fields) {
+	lg.emit(LogLevelWarning, 0, templateMessage(template, fields))
+}
+
+// Infot emits an information message built from a template with named placeholders. See Errort.
+func (lg *Logger) Infot(template string, fields Fields) {
+	lg.emit(LogLevelInfo, 0, templateMessage(template, fields))
+}
+
+// Debugt emits a debug message built from a template with named placeholders. See Errort.
+func (lg *Logger) Debugt(level uint, template string, fields Fields) {
+	lg.emit(LogLevelDebug, level, templateMessage(template, fields))
+}
+
+//------------------------------------------------------------------------------
+
+// templatePayload is a message built from a template. It is rendered when it is output, depending on the struct
+// text mode of the logger.
+type templatePayload struct {
+	template string
+	fields   Fields
+}
+
+// templateMessage returns a deferred message so the template is only rendered if the message is output.
+func templateMessage(template string, fields Fields) func() interface{} {
+	return func() interface{} {
+		return templatePayload{
+			template: template,
+			fields:   fields,
+		}
+	}
+}
+
+// text returns the rendered template followed by the fields as key=value pairs.
+func (p templatePayload) text() string {
+	keys := p.sortedKeys(false)
+	fields := make([]field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, field{
+			key:   key,
+			value: p.fields[key],
+		})
+	}
+	return addFieldsToText(renderTemplate(p.template, p.fields), fields)
+}
+
+// json returns a JSON object with the rendered template, the template itself and the fields.
+func (p templatePayload) json() string {
+	b := make([]byte, 0, 2*len(p.template)+32*len(p.fields))
+	b = append(b, `{"message":`...)
+	b = appendJSONString(b, renderTemplate(p.template, p.fields))
+	b = append(b, `,"message_template":`...)
+	b = appendJSONString(b, p.template)
+
+	for _, key := range p.sortedKeys(true) {
+		value, err := json.Marshal(p.fields[key])
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(p.fields[key]))
+		}
+		b = append(b, ',')
+		b = appendJSONString(b, key)
+		b = append(b, ':')
+		b = append(b, value...)
+	}
+
+	b = append(b, '}')
+	return string(b)
+}
+
+// sortedKeys returns the names of the fields in a stable order. The message and message_template fields of JSON
+// messages cannot be overridden.
+func (p templatePayload) sortedKeys(isJSON bool) []string {
+	keys := make([]string, 0, len(p.fields))
+	for key := range p.fields {
+		if !isJSON || (key != "message" && key != "message_template") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderTemplate replaces the {name} placeholders of the template with the values of the fields.
+func renderTemplate(template string, fields Fields) string {
+	sb := strings.Builder{}
+	for idx := 0; idx < len(template); idx++ {
+		ch := template[idx]
+		switch {
+		case (ch == '{' || ch == '}') && idx+1 < len(template) && template[idx+1] == ch:
+			// Escaped brace
+			sb.WriteByte(ch)
+			idx += 1
+
+		case ch == '{':
+			end := strings.IndexByte(template[idx+1:], '}')
+			if end >= 0 {
+				if value, ok := fields[template[idx+1:idx+1+end]]; ok {
+					sb.WriteString(fmt.Sprint(value))
+					idx += end + 1
+					continue
+				}
+			}
+			sb.WriteByte(ch)
+
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package go_logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// LevelWriter is an io.Writer that logs each written line with the level indicated by its prefix.
+type LevelWriter struct {
+	mtx sync.Mutex
+	lg  *Logger
+	buf []byte
+}
+
+//------------------------------------------------------------------------------
+
+var levelWriterPrefixes = []struct {
+	prefix string
+	level  LogLevel
+}{
+	{prefix: "ERROR:", level: LogLevelError},
+	{prefix: "WARNING:", level: LogLevelWarning},
+	{prefix: "WARN:", level: LogLevelWarning},
+	{prefix: "INFO:", level: LogLevelInfo},
+	{prefix: "DEBUG:", level: LogLevelDebug},
+}
+
+//------------------------------------------------------------------------------
+
+// LevelInferringWriter returns a writer that logs each line written to it. If the line starts with an
+// `ERROR:`, `WARN:`, `WARNING:`, `INFO:` or `DEBUG:` token, the message is logged with that level and the token is
+// removed. Otherwise, it is logged as information. Debug messages use level 1.
+// Lines can be written in several calls. Use Flush to log the last line if it does not end with a new line.
+func (lg *Logger) LevelInferringWriter() *LevelWriter {
+	return &LevelWriter{
+		lg:  lg,
+		buf: make([]byte, 0),
+	}
+}
+
+// Write logs the complete lines contained in p and keeps the remaining data until a new line is written.
+func (w *LevelWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		w.logLine(string(w.buf[:idx]))
+		w.buf = w.buf[idx+1:]
+	}
+
+	// Done
+	return len(p), nil
+}
+
+// Flush logs any pending data not terminated by a new line.
+func (w *LevelWriter) Flush() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if len(w.buf) > 0 {
+		w.logLine(string(w.buf))
+		w.buf = w.buf[:0]
+	}
+}
+
+func (w *LevelWriter) logLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	if len(strings.TrimSpace(line)) == 0 {
+		return
+	}
+
+	level := LogLevelInfo
+	for _, p := range levelWriterPrefixes {
+		if len(line) >= len(p.prefix) && strings.EqualFold(line[:len(p.prefix)], p.prefix) {
+			level = p.level
+			line = strings.TrimLeft(line[len(p.prefix):], " \t")
+			break
+		}
+	}
+
+	w.lg.emit(level, 1, line)
+}